@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/config"
+)
+
+// runConfig runs the config command, which prints the configuration the
+// server would run with:
+//
+//	go-auth config print [--redacted] [--format yaml|toml] [config flags]
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New("usage: go-auth config print [--redacted] [--format yaml|toml] [config flags]")
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	redacted := flags.Bool("redacted", false, "replace secrets with REDACTED and remove passwords from URLs")
+	format := flags.String("format", "yaml", "output format, yaml or toml")
+
+	cfg, err := config.Load(flags, args[1:])
+	if err != nil {
+		return err
+	}
+	if *redacted {
+		cfg = cfg.Redacted()
+	}
+
+	return cfg.Print(os.Stdout, *format)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/migrations"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+)
+
+// readinessChecks are the dependencies the server needs to serve logins.
+// Their errors are shown to anyone, so the underlying errors are logged instead.
+func readinessChecks(conn *sql.DB, migrator *migrations.Migrator) []usecases.HealthCheck {
+	return []usecases.HealthCheck{
+		{
+			Name: "database",
+			Check: func(ctx context.Context) error {
+				if err := conn.PingContext(ctx); err != nil {
+					logging.FromContext(ctx).Error("Readiness check: database is not reachable", "error", err)
+					return errors.New("database is not reachable")
+				}
+				return nil
+			},
+		},
+		{
+			Name: "migrations",
+			Check: func(ctx context.Context) error {
+				pending, err := migrator.Pending(ctx)
+				if err != nil {
+					logging.FromContext(ctx).Error("Readiness check: reading the applied migrations", "error", err)
+					return errors.New("applied migrations could not be read")
+				}
+				if len(pending) > 0 {
+					return fmt.Errorf("%d pending, run: go-auth migrate up", len(pending))
+				}
+				return nil
+			},
+		},
+		{
+			Name: "mailer",
+			Check: func(ctx context.Context) error {
+				if !utils.MailerConfigured() {
+					return errors.New("no SMTP server is configured, set smtp.host")
+				}
+				return nil
+			},
+		},
+		{
+			Name: "signing_keys",
+			Check: func(ctx context.Context) error {
+				if !utils.IDTokenKeysLoaded() {
+					return errors.New("no ID token signing key is loaded")
+				}
+				return nil
+			},
+		},
+	}
+}
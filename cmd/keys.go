@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/config"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+)
+
+const keysUsage = `usage: go-auth keys rotate [config flags]
+
+Generates a new ID token signing key in id_token_signing_key_file, keeping
+the previous key so the ID tokens it signed still verify. The server uses the
+new key once it is restarted or receives SIGHUP.`
+
+// runKeys runs the keys command, which rotates the ID token signing key
+func runKeys(args []string) error {
+	if len(args) == 0 || args[0] != "rotate" {
+		return errors.New(keysUsage)
+	}
+
+	cfg, err := config.Load(flag.NewFlagSet("keys rotate", flag.ContinueOnError), args[1:])
+	if err != nil {
+		return err
+	}
+	if cfg.IDTokenSigningKeyFile == "" {
+		return errors.New("id_token_signing_key_file is not set, the server generates a temporary key on every start")
+	}
+
+	keyID, err := utils.RotateIDTokenSigningKey(cfg.IDTokenSigningKeyFile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Generated ID token signing key %s, restart the server or send it SIGHUP to use it\n", keyID)
+	return nil
+}
@@ -1,54 +1,319 @@
 package main
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
 	"github.com/StaphoneWizzoh/Go_Auth/pkg/config"
 	"github.com/StaphoneWizzoh/Go_Auth/pkg/handlers"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/metrics"
 	"github.com/StaphoneWizzoh/Go_Auth/pkg/middleware"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/migrations"
 	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository/sqlc"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/tracing"
 	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+	"github.com/StaphoneWizzoh/Go_Auth/sql/schema"
 	"github.com/gorilla/mux"
 )
 
+const usage = `usage: go-auth [command] [arguments]
+
+commands:
+  serve    start the server, the default command
+  migrate  apply the database migrations
+  user     create and manage users
+  tokens   revoke refresh tokens
+  keys     rotate the ID token signing key
+  secrets  manage the encrypted keystore
+  config   print the configuration
+
+Run go-auth <command> -h for the flags of a command.`
+
+// commands are the subcommands of the binary, run with the arguments that follow them
+var commands = map[string]func(args []string) error{
+	"serve":   runServe,
+	"migrate": runMigrate,
+	"user":    runUser,
+	"tokens":  runTokens,
+	"keys":    runKeys,
+	"secrets": runSecrets,
+	"config":  runConfig,
+}
+
 func main(){
-	cfg := config.LoadConfig()
+	// Subcommands, serving when only flags are given
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	if command == "help" {
+		fmt.Println(usage)
+		return
+	}
+
+	run, ok := commands[command]
+	if !ok {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err := run(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// tracingShutdownTimeout is how long the traces still buffered have to be exported once the server stopped
+const tracingShutdownTimeout = 5 * time.Second
+
+// runServe runs the serve command, which starts the server:
+//
+//	go-auth serve [config flags]
+func runServe(args []string) error {
+	cfg, err := config.Load(flag.NewFlagSet("serve", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+
+	// Logging, through the log package too
+	if err := logging.Setup(cfg.Log); err != nil {
+		return err
+	}
+
+	// Stopping on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Exporting traces
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		fatal("Error setting up tracing", err)
+	}
 
 	// Database connection initialization
-	conn, err := config.NewDatabaseConnection(cfg.DbURL)
+	conn, err := openDatabase(ctx, cfg)
+	if err != nil {
+		fatal("Error connecting to database", err)
+	}
+	defer conn.Close()
+	metrics.RegisterDatabase(conn)
+
+	migrator, err := migrations.NewMigrator(conn, schema.FS)
 	if err != nil {
-		log.Printf("Error connecting to database: %v", err)
+		fatal("Error loading migrations", err)
+	}
+
+	// Applying pending migrations
+	if cfg.AutoMigrate {
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			slog.Info("Applied migration", "migration", migration.Name)
+		}
+		if err != nil {
+			fatal("Error migrating database", err)
+		}
 	}
 
-	db := database.New(conn)
+	db := database.New(tracing.WrapDB(conn))
+
+	// Applying the configuration of tokens, emails and listings
+	utils.SetTokenSecret(cfg.JWT.Secret)
+	utils.ConfigureMailer(cfg.SMTP, cfg.PublicURL)
+	handlers.DefaultPageSize = cfg.DefaultPageSize
+
+	// Loading the key ID tokens are signed with
+	if err := utils.LoadIDTokenSigningKey(cfg.IDTokenSigningKeyFile); err != nil {
+		fatal("Error loading ID token signing key", err)
+	}
 
 	// Repository initializations
 	userRepo := sqlc.NewSQLUserRepository(db)
+	roleRepo := sqlc.NewSQLRoleRepository(db, conn)
+	orgRepo := sqlc.NewSQLOrganizationRepository(db)
+	invitationRepo := sqlc.NewSQLInvitationRepository(db)
+	oauthRepo := sqlc.NewSQLOAuthRepository(db)
+	identityRepo := sqlc.NewSQLIdentityRepository(db)
+	apiKeyRepo := sqlc.NewSQLAPIKeyRepository(db)
+	serviceAccountRepo := sqlc.NewSQLServiceAccountRepository(db)
+	impersonationRepo := sqlc.NewSQLImpersonationRepository(db)
+	metrics.RegisterSessionCounter(activeSessions(userRepo, impersonationRepo))
+
+	// Social login providers
+	var socialProviders []*utils.SocialProvider
+	for _, providerConfig := range cfg.SocialProviders {
+		socialProviders = append(socialProviders, utils.NewSocialProvider(providerConfig))
+	}
+
+	// Background workers, stopped once requests in flight are drained
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	runWorker := func(worker func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			worker(workersCtx)
+		}()
+	}
+
+	// Reloading secrets on SIGHUP
+	runWorker(func(ctx context.Context) {
+		reloadSecretsOnHangup(ctx, args, cfg, socialProviders)
+	})
+
+	// Registration policy
+	registration, err := usecases.NewRegistrationPolicy(cfg.Registration.Mode, cfg.Registration.DefaultRole, cfg.Registration.AllowedDomains, cfg.Registration.DeniedDomains)
+	if err != nil {
+		fatal("Error in registration policy", err)
+	}
 
 	// Services initializations
-	userService := usecases.NewUserService(userRepo)
+	userService := usecases.NewUserService(userRepo, roleRepo, orgRepo, serviceAccountRepo, registration)
+	roleService := usecases.NewRoleService(roleRepo, userRepo)
+	orgService := usecases.NewOrganizationService(orgRepo, userRepo, roleRepo)
+	invitationService := usecases.NewInvitationService(invitationRepo, orgRepo, userRepo, userService)
+	oauthService := usecases.NewOAuthService(oauthRepo, userRepo, userService, cfg.Issuer)
+	socialLoginService := usecases.NewSocialLoginService(identityRepo, userRepo, userService, socialProviders)
+	apiKeyService := usecases.NewAPIKeyService(apiKeyRepo, userRepo, roleRepo, userService)
+	serviceAccountService := usecases.NewServiceAccountService(serviceAccountRepo, roleRepo, orgRepo, userService, cfg.Issuer)
+	impersonationService := usecases.NewImpersonationService(impersonationRepo, userRepo, roleRepo, userService)
+	healthService := usecases.NewHealthService(readinessChecks(conn, migrator)...)
 
 	// Handlers initializations
 	userHandler := handlers.NewUserHandler(userService)
-	
+	roleHandler := handlers.NewRoleHandler(roleService, userService)
+	orgHandler := handlers.NewOrganizationHandler(orgService, userService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
+	oauthHandler := handlers.NewOAuthHandler(oauthService, serviceAccountService)
+	socialLoginHandler := handlers.NewSocialLoginHandler(socialLoginService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	serviceAccountHandler := handlers.NewServiceAccountHandler(serviceAccountService)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationService, userService)
+	healthHandler := handlers.NewHealthHandler(healthService)
+
+	// Middleware initializations
+	authMw := middleware.NewAuthMiddleware(apiKeyService, impersonationService)
+	permissionMw := middleware.NewPermissionMiddleware(roleService, serviceAccountService)
+	orgMw := middleware.NewOrganizationMiddleware(orgService)
+	csrfMw := middleware.NewCSRFMiddleware(cfg.PublicURL)
 
 	// Setting up routes
 	router := mux.NewRouter()
 	// r.Use(middleware.CORS) 
 
-	getUserRouter(router, userHandler)
+	getUserRouter(router, userHandler, roleHandler, orgHandler, invitationHandler, oauthHandler, socialLoginHandler, apiKeyHandler, serviceAccountHandler, impersonationHandler, healthHandler, authMw, permissionMw, orgMw, csrfMw)
+
+	// Serving metrics, recorded by the route template requests matched
+	if cfg.Metrics.Enabled {
+		router.Handle("/metrics", middleware.RequireBearerToken(cfg.Metrics.BearerToken, metrics.Handler())).Methods(http.MethodGet)
+	}
+	router.Use(middleware.RouteTemplate)
 
 	// Setting up middleware
-	corsMw, err := middleware.CreateCORSMiddleware()
+	corsMw, err := middleware.CreateCORSMiddleware(cfg.CORS)
 	if err != nil{
-		log.Fatal("Error in creating middleware required for routing :", err)
+		fatal("Error in creating middleware required for routing", err)
 	}
 	
-	handler := corsMw.Wrap(router)
+	// Requests are traced, then tagged with an ID everything logged about them carries
+	handler := middleware.SecurityHeaders(cfg.Security, corsMw.Wrap(router))
+	if cfg.Log.Access {
+		handler = middleware.AccessLog(handler)
+	}
+	handler = middleware.Metrics(middleware.Tracing(middleware.RequestID(handler)))
 
 	// Starting the server
-	log.Printf("Server listening on port %s", cfg.Port)
-	log.Fatal(http.ListenAndServe(":"+cfg.Port, handler))
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+	}
+
+	serverErrors := make(chan error, 1)
+	go func() {
+		slog.Info("Server listening", "port", cfg.Port)
+		serverErrors <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErrors:
+		stopWorkers()
+		workers.Wait()
+		return err
+	case <-ctx.Done():
+	}
+
+	// Reporting the server is no longer ready, and giving load balancers
+	// shutdown_delay to notice, before draining requests in flight and stopping
+	// the workers. A second signal stops the server right away.
+	stop()
+	healthService.ShutDown()
+	if cfg.Server.ShutdownDelay > 0 {
+		slog.Info("Shutting down after a delay, no longer ready", "delay", cfg.Server.ShutdownDelay.String())
+		time.Sleep(cfg.Server.ShutdownDelay)
+	}
+	slog.Info("Shutting down, waiting for requests in flight", "timeout", cfg.Server.ShutdownTimeout.String())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		slog.Error("Error draining requests in flight", "error", err)
+	}
+
+	stopWorkers()
+	workers.Wait()
+
+	// Flushing the traces of the last requests
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancelTracing()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		slog.Error("Error flushing traces", "error", err)
+	}
+
+	slog.Info("Server stopped")
+	return err
+}
+
+// fatal logs the error the server cannot start because of and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// openDatabase connects to the configured database, waiting for it to be
+// reachable for up to db_connect_timeout
+func openDatabase(ctx context.Context, cfg config.Config) (*sql.DB, error) {
+	conn, err := config.NewDatabaseConnection(cfg.DbURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.WaitForDatabase(ctx, conn, cfg.DBConnectTimeout); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
 }
\ No newline at end of file
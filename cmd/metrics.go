@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/metrics"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+)
+
+// activeSessions counts the refresh tokens and impersonations that are still usable
+func activeSessions(userRepo repository.UserRepository, impersonationRepo repository.ImpersonationRepository) metrics.SessionCounter {
+	return func(ctx context.Context) (map[string]int64, error) {
+		refreshTokens, err := userRepo.CountActiveRefreshTokens(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		impersonations, err := impersonationRepo.CountActiveImpersonations(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]int64{
+			"refresh_token": refreshTokens,
+			"impersonation": impersonations,
+		}, nil
+	}
+}
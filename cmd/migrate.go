@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/config"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/migrations"
+	"github.com/StaphoneWizzoh/Go_Auth/sql/schema"
+)
+
+const migrateUsage = "usage: go-auth migrate up|down|status|redo [config flags]"
+
+// runMigrate runs the migrate command, which applies the embedded migrations:
+//
+//	go-auth migrate up      apply every pending migration
+//	go-auth migrate down    roll back the latest migration
+//	go-auth migrate status  list the migrations and when they were applied
+//	go-auth migrate redo    roll back the latest migration and apply it again
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	command := args[0]
+
+	flags := flag.NewFlagSet("migrate "+command, flag.ContinueOnError)
+	cfg, err := config.Load(flags, args[1:])
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	conn, err := openDatabase(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	migrator, err := migrations.NewMigrator(conn, schema.FS)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("Applied %s\n", migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+		return err
+	case "down":
+		migration, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %s\n", migration.Name)
+		return nil
+	case "redo":
+		migration, err := migrator.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Redid %s\n", migration.Name)
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(writer, "Applied At\tMigration")
+		for _, status := range statuses {
+			appliedAt := "Pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(writer, "%s\t%s\n", appliedAt, status.Name)
+		}
+		return writer.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
@@ -5,45 +5,120 @@ import (
 
 	"github.com/StaphoneWizzoh/Go_Auth/pkg/handlers"
 	"github.com/StaphoneWizzoh/Go_Auth/pkg/middleware"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
 	"github.com/gorilla/mux"
 )
 
-func getUserRouter(r *mux.Router, userHandler *handlers.UserHandler) {
+func getUserRouter(r *mux.Router, userHandler *handlers.UserHandler, roleHandler *handlers.RoleHandler, orgHandler *handlers.OrganizationHandler,
+	invitationHandler *handlers.InvitationHandler, oauthHandler *handlers.OAuthHandler, socialLoginHandler *handlers.SocialLoginHandler, apiKeyHandler *handlers.APIKeyHandler, serviceAccountHandler *handlers.ServiceAccountHandler, impersonationHandler *handlers.ImpersonationHandler, healthHandler *handlers.HealthHandler, auth *middleware.AuthMiddleware, permissions *middleware.PermissionMiddleware, organizations *middleware.OrganizationMiddleware, csrf *middleware.CSRFMiddleware) {
+	r.HandleFunc("/healthz", healthHandler.Liveness).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", healthHandler.Readiness).Methods(http.MethodGet)
+
+	oauthRouter := r.PathPrefix("/oauth").Subrouter()
+	oauthRouter.HandleFunc("/authorize", csrf.Protect(oauthHandler.Authorize)).Methods(http.MethodGet, http.MethodPost)
+	oauthRouter.HandleFunc("/token", oauthHandler.Token).Methods(http.MethodPost)
+	oauthRouter.HandleFunc("/revoke", oauthHandler.Revoke).Methods(http.MethodPost)
+	oauthRouter.HandleFunc("/introspect", oauthHandler.Introspect).Methods(http.MethodPost)
+	oauthRouter.HandleFunc("/userinfo", oauthHandler.UserInfo).Methods(http.MethodGet, http.MethodPost)
+	oauthRouter.HandleFunc("/logout", oauthHandler.Logout).Methods(http.MethodGet, http.MethodPost)
+
+	wellKnownRouter := r.PathPrefix("/.well-known").Subrouter()
+	wellKnownRouter.HandleFunc("/openid-configuration", oauthHandler.OpenIDConfiguration).Methods(http.MethodGet)
+	wellKnownRouter.HandleFunc("/jwks.json", oauthHandler.JSONWebKeySet).Methods(http.MethodGet)
+
+	socialLoginRouter := r.PathPrefix("/auth").Subrouter()
+	socialLoginRouter.HandleFunc("/providers", socialLoginHandler.ListProviders).Methods(http.MethodGet)
+	socialLoginRouter.HandleFunc("/{provider}/login", socialLoginHandler.Login).Methods(http.MethodGet)
+	socialLoginRouter.HandleFunc("/{provider}/callback", socialLoginHandler.Callback).Methods(http.MethodGet)
+
 	resetPasswordRouter := r.PathPrefix("/reset-password").Subrouter()
-	resetPasswordRouter.HandleFunc("", userHandler.ResetPassword).Methods(http.MethodGet)
-	resetPasswordRouter.HandleFunc("", userHandler.ResetPassword).Methods(http.MethodPost)
+	resetPasswordRouter.HandleFunc("", csrf.Protect(userHandler.ResetPassword)).Methods(http.MethodGet)
+	resetPasswordRouter.HandleFunc("", csrf.Protect(userHandler.ResetPassword)).Methods(http.MethodPost)
+
+	magicLinkRouter := r.PathPrefix("/magic-link").Subrouter()
+	magicLinkRouter.HandleFunc("", csrf.Protect(userHandler.MagicLink)).Methods(http.MethodGet)
+	magicLinkRouter.HandleFunc("", csrf.Protect(userHandler.MagicLink)).Methods(http.MethodPost)
+
+	acceptInvitationRouter := r.PathPrefix("/accept-invitation").Subrouter()
+	acceptInvitationRouter.HandleFunc("", invitationHandler.AcceptInvitation).Methods(http.MethodGet)
+	acceptInvitationRouter.HandleFunc("", invitationHandler.AcceptInvitation).Methods(http.MethodPost)
 
 	userRouter := r.PathPrefix("/api/users").Subrouter()
 	userRouter.HandleFunc("/register", userHandler.RegisterUser).Methods(http.MethodPost)
 	userRouter.HandleFunc("/login", userHandler.LoginUser).Methods(http.MethodPost)
 	userRouter.HandleFunc("/refresh", userHandler.RefreshToken).Methods(http.MethodPost)
+	userRouter.HandleFunc("/magic-link", userHandler.RequestMagicLink).Methods(http.MethodPost)
 
 	// Protected Routes
 
 	// Authenticated user routes
 	protectedUserRouter := userRouter.PathPrefix("").Subrouter()
-	protectedUserRouter.Use(middleware.CorsAuth)
-	protectedUserRouter.HandleFunc("/update", userHandler.UpdateUser).Methods(http.MethodPut)
+	protectedUserRouter.Use(auth.CorsAuth, middleware.RejectServiceAccounts, middleware.RejectScopedAPIKeys)
+	protectedUserRouter.HandleFunc("/update", middleware.RejectImpersonation(userHandler.UpdateUser)).Methods(http.MethodPut)
 	protectedUserRouter.HandleFunc("/update-profile-picture", userHandler.UpdateProfilePicture).Methods(http.MethodPut)
-	protectedUserRouter.HandleFunc("/reset-password", userHandler.RequestPasswordReset).Methods(http.MethodPut)
+	protectedUserRouter.HandleFunc("/reset-password", middleware.RejectImpersonation(userHandler.RequestPasswordReset)).Methods(http.MethodPut)
+	protectedUserRouter.HandleFunc("/organizations", orgHandler.GetMyOrganizations).Methods(http.MethodGet)
+	protectedUserRouter.HandleFunc("/switch-organization", middleware.RejectImpersonation(orgHandler.SwitchOrganization)).Methods(http.MethodPost)
+	protectedUserRouter.HandleFunc("/identities", socialLoginHandler.GetIdentities).Methods(http.MethodGet)
+	protectedUserRouter.HandleFunc("/identities", middleware.RejectImpersonation(socialLoginHandler.UnlinkIdentity)).Methods(http.MethodDelete)
+	protectedUserRouter.HandleFunc("/identities/link", middleware.RejectImpersonation(socialLoginHandler.LinkIdentity)).Methods(http.MethodPost)
+	protectedUserRouter.HandleFunc("/api-keys", apiKeyHandler.ListAPIKeys).Methods(http.MethodGet)
+	protectedUserRouter.HandleFunc("/api-keys", middleware.RejectImpersonation(apiKeyHandler.CreateAPIKey)).Methods(http.MethodPost)
+	protectedUserRouter.HandleFunc("/api-keys", middleware.RejectImpersonation(apiKeyHandler.RevokeAPIKey)).Methods(http.MethodDelete)
+	protectedUserRouter.HandleFunc("/impersonations", impersonationHandler.GetMyImpersonations).Methods(http.MethodGet)
 
-	// Authenticated admin routes
+	// Authenticated admin routes, each guarded by the permission it requires
 	protectedAdminRouter := r.PathPrefix("/api/admin").Subrouter()
-	protectedAdminRouter.Use(middleware.CorsAuth)
-	protectedAdminRouter.Use(middleware.CorsAdmin)
-	protectedAdminRouter.HandleFunc("/promote-admin", userHandler.PromoteUserToAdmin).Methods(http.MethodPut)
-	protectedAdminRouter.HandleFunc("/promote-super-admin", userHandler.PromoteUserToSuperAdmin).Methods(http.MethodPut)
-	protectedAdminRouter.HandleFunc("/demote-super-admin-to-admin", userHandler.DemoteSuperAdminToAdmin).Methods(http.MethodPut)
-	protectedAdminRouter.HandleFunc("/demote-super-admin-to-user", userHandler.DemoteSuperAdminToUser).Methods(http.MethodPut)
-	protectedAdminRouter.HandleFunc("/demote-admin-to-user", userHandler.DemoteAdminToUser).Methods(http.MethodPut)
-	protectedAdminRouter.HandleFunc("/suspend-user", userHandler.SuspendUser).Methods(http.MethodPut)
-	protectedAdminRouter.HandleFunc("/recover-user", userHandler.RecoverUser).Methods(http.MethodPut)
-	protectedAdminRouter.HandleFunc("/delete-user", userHandler.DeleteUser).Methods(http.MethodDelete)
-	protectedAdminRouter.HandleFunc("/all-users", userHandler.GetAllUsers).Methods(http.MethodPost)
-	protectedAdminRouter.HandleFunc("/active-users", userHandler.GetActiveUsers).Methods(http.MethodPost)
-	protectedAdminRouter.HandleFunc("/admin-users", userHandler.GetAdminUsers).Methods(http.MethodPost)
-	protectedAdminRouter.HandleFunc("/super-admin-users", userHandler.GetSuperAdminUsers).Methods(http.MethodPost)
-	protectedAdminRouter.HandleFunc("/deleted-users", userHandler.GetDeletedUsers).Methods(http.MethodPost)
-	protectedAdminRouter.HandleFunc("/inactive-users", userHandler.GetInactiveUsers).Methods(http.MethodPost)
-	protectedAdminRouter.HandleFunc("/suspended-users", userHandler.GetSuspendedUsers).Methods(http.MethodPost)
+	protectedAdminRouter.Use(auth.CorsAuth)
+	protectedAdminRouter.HandleFunc("/create-user", permissions.Require(model.PermissionUsersCreate, userHandler.CreateUser)).Methods(http.MethodPost)
+	protectedAdminRouter.HandleFunc("/suspend-user", permissions.Require(model.PermissionUsersSuspend, userHandler.SuspendUser)).Methods(http.MethodPut)
+	protectedAdminRouter.HandleFunc("/recover-user", permissions.Require(model.PermissionUsersRecover, userHandler.RecoverUser)).Methods(http.MethodPut)
+	protectedAdminRouter.HandleFunc("/delete-user", permissions.Require(model.PermissionUsersDelete, userHandler.DeleteUser)).Methods(http.MethodDelete)
+	protectedAdminRouter.HandleFunc("/all-users", permissions.Require(model.PermissionUsersRead, userHandler.GetAllUsers)).Methods(http.MethodPost)
+	protectedAdminRouter.HandleFunc("/active-users", permissions.Require(model.PermissionUsersRead, userHandler.GetActiveUsers)).Methods(http.MethodPost)
+	protectedAdminRouter.HandleFunc("/admin-users", permissions.Require(model.PermissionUsersRead, userHandler.GetAdminUsers)).Methods(http.MethodPost)
+	protectedAdminRouter.HandleFunc("/super-admin-users", permissions.Require(model.PermissionUsersRead, userHandler.GetSuperAdminUsers)).Methods(http.MethodPost)
+	protectedAdminRouter.HandleFunc("/deleted-users", permissions.Require(model.PermissionUsersRead, userHandler.GetDeletedUsers)).Methods(http.MethodPost)
+	protectedAdminRouter.HandleFunc("/inactive-users", permissions.Require(model.PermissionUsersRead, userHandler.GetInactiveUsers)).Methods(http.MethodPost)
+	protectedAdminRouter.HandleFunc("/suspended-users", permissions.Require(model.PermissionUsersRead, userHandler.GetSuspendedUsers)).Methods(http.MethodPost)
+	protectedAdminRouter.HandleFunc("/impersonate", permissions.Require(model.PermissionUsersImpersonate, middleware.RejectImpersonation(impersonationHandler.Impersonate))).Methods(http.MethodPost)
+
+	// Role management routes
+	protectedAdminRouter.HandleFunc("/roles", permissions.Require(model.PermissionRolesRead, roleHandler.ListRoles)).Methods(http.MethodGet)
+	protectedAdminRouter.HandleFunc("/roles", permissions.Require(model.PermissionRolesManage, roleHandler.CreateRole)).Methods(http.MethodPost)
+	protectedAdminRouter.HandleFunc("/roles", permissions.Require(model.PermissionRolesManage, roleHandler.DeleteRole)).Methods(http.MethodDelete)
+	protectedAdminRouter.HandleFunc("/roles/rename", permissions.Require(model.PermissionRolesManage, roleHandler.RenameRole)).Methods(http.MethodPut)
+	protectedAdminRouter.HandleFunc("/roles/permissions", permissions.Require(model.PermissionRolesManage, roleHandler.SetRolePermissions)).Methods(http.MethodPut)
+	protectedAdminRouter.HandleFunc("/permissions", permissions.Require(model.PermissionRolesRead, roleHandler.ListPermissions)).Methods(http.MethodGet)
+	protectedAdminRouter.HandleFunc("/user-roles", permissions.Require(model.PermissionRolesRead, roleHandler.GetUserRoles)).Methods(http.MethodPost)
+	protectedAdminRouter.HandleFunc("/assign-role", permissions.Require(model.PermissionRolesAssign, roleHandler.AssignRole)).Methods(http.MethodPut)
+	protectedAdminRouter.HandleFunc("/revoke-role", permissions.Require(model.PermissionRolesAssign, roleHandler.RevokeRole)).Methods(http.MethodPut)
+
+	// Organization management routes
+	protectedAdminRouter.HandleFunc("/organizations", permissions.Require(model.PermissionOrgsManage, orgHandler.CreateOrganization)).Methods(http.MethodPost)
+	protectedAdminRouter.HandleFunc("/organizations/list", permissions.Require(model.PermissionOrgsManage, orgHandler.ListOrganizations)).Methods(http.MethodPost)
+	protectedAdminRouter.HandleFunc("/organizations/members", permissions.Require(model.PermissionOrgsManage, orgHandler.SetOrganizationMember)).Methods(http.MethodPut)
+	protectedAdminRouter.HandleFunc("/organizations/members", permissions.Require(model.PermissionOrgsManage, orgHandler.RemoveOrganizationMember)).Methods(http.MethodDelete)
+
+	// Authenticated organization admin routes, confined to the organization on the caller's token
+	protectedOrgRouter := r.PathPrefix("/api/org").Subrouter()
+	protectedOrgRouter.Use(auth.CorsAuth, middleware.RejectScopedAPIKeys)
+	protectedOrgRouter.HandleFunc("/members", organizations.RequireOrgRole(model.OrgRoleAdmin, orgHandler.GetOrganizationMembers)).Methods(http.MethodPost)
+	protectedOrgRouter.HandleFunc("/suspend-member", organizations.RequireOrgRole(model.OrgRoleAdmin, orgHandler.SuspendOrganizationMember)).Methods(http.MethodPut)
+	protectedOrgRouter.HandleFunc("/recover-member", organizations.RequireOrgRole(model.OrgRoleAdmin, orgHandler.RecoverOrganizationMember)).Methods(http.MethodPut)
+	protectedOrgRouter.HandleFunc("/invitations", organizations.RequireOrgRole(model.OrgRoleAdmin, invitationHandler.InviteMember)).Methods(http.MethodPost)
+	protectedOrgRouter.HandleFunc("/invitations", organizations.RequireOrgRole(model.OrgRoleAdmin, invitationHandler.ListPendingInvitations)).Methods(http.MethodGet)
+	protectedOrgRouter.HandleFunc("/invitations", organizations.RequireOrgRole(model.OrgRoleAdmin, invitationHandler.RevokeInvitation)).Methods(http.MethodDelete)
+
+	// OAuth client registry routes
+	protectedAdminRouter.HandleFunc("/oauth-clients", permissions.Require(model.PermissionOAuthClientsManage, oauthHandler.ListClients)).Methods(http.MethodGet)
+	protectedAdminRouter.HandleFunc("/oauth-clients", permissions.Require(model.PermissionOAuthClientsManage, oauthHandler.CreateClient)).Methods(http.MethodPost)
+	protectedAdminRouter.HandleFunc("/oauth-clients", permissions.Require(model.PermissionOAuthClientsManage, oauthHandler.DeleteClient)).Methods(http.MethodDelete)
+
+	// Service account routes
+	protectedAdminRouter.HandleFunc("/service-accounts", permissions.Require(model.PermissionServiceAccountsManage, serviceAccountHandler.ListServiceAccounts)).Methods(http.MethodGet)
+	protectedAdminRouter.HandleFunc("/service-accounts", permissions.Require(model.PermissionServiceAccountsManage, serviceAccountHandler.CreateServiceAccount)).Methods(http.MethodPost)
+	protectedAdminRouter.HandleFunc("/service-accounts", permissions.Require(model.PermissionServiceAccountsManage, serviceAccountHandler.UpdateServiceAccount)).Methods(http.MethodPut)
+	protectedAdminRouter.HandleFunc("/service-accounts", permissions.Require(model.PermissionServiceAccountsManage, serviceAccountHandler.DeleteServiceAccount)).Methods(http.MethodDelete)
+	protectedAdminRouter.HandleFunc("/service-accounts/rotate-secret", permissions.Require(model.PermissionServiceAccountsManage, serviceAccountHandler.RotateServiceAccountSecret)).Methods(http.MethodPut)
 }
\ No newline at end of file
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/config"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/secrets"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+)
+
+const secretsUsage = `usage: go-auth secrets <command> [--keystore-file file] [--master-key key]
+
+commands:
+  list           list the names of the secrets in the keystore
+  set <name>     add or replace a secret, reading its value from standard input
+  delete <name>  remove a secret
+
+The keystore file and master key default to SECRETS_KEYSTORE_FILE and
+SECRETS_MASTER_KEY. The master key can be a file:// or env: reference.`
+
+// runSecrets runs the secrets command, which manages the encrypted keystore
+// keystore: references are resolved from
+func runSecrets(args []string) error {
+	if len(args) == 0 {
+		return errors.New(secretsUsage)
+	}
+	command := args[0]
+
+	flags := flag.NewFlagSet("secrets "+command, flag.ContinueOnError)
+	keystoreFile := flags.String("keystore-file", os.Getenv("SECRETS_KEYSTORE_FILE"), "encrypted keystore file")
+	masterKey := flags.String("master-key", os.Getenv("SECRETS_MASTER_KEY"), "master key of the keystore, or a file:// or env: reference to it")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *keystoreFile == "" {
+		return errors.New("a keystore file is required, set --keystore-file or SECRETS_KEYSTORE_FILE")
+	}
+
+	// open keystore
+	resolver, err := secrets.NewResolver("", "")
+	if err != nil {
+		return err
+	}
+	key, err := resolver.Resolve(*masterKey)
+	if err != nil {
+		return fmt.Errorf("resolving master key: %w", err)
+	}
+	keystore, err := secrets.OpenKeystore(*keystoreFile, key)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case command == "list" && flags.NArg() == 0:
+		for _, name := range keystore.Names() {
+			fmt.Println(name)
+		}
+		return nil
+	case command == "set" && flags.NArg() == 1:
+		value, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		keystore.Set(flags.Arg(0), strings.TrimRight(string(value), "\r\n"))
+		return keystore.Save()
+	case command == "delete" && flags.NArg() == 1:
+		if err := keystore.Delete(flags.Arg(0)); err != nil {
+			return err
+		}
+		return keystore.Save()
+	default:
+		return errors.New(secretsUsage)
+	}
+}
+
+// reloadSecretsOnHangup loads the configuration again whenever the server
+// receives SIGHUP and applies the secrets and ID token signing keys that
+// changed. Secrets that cannot
+// be replaced while the server runs, like the database URL, are only applied
+// by a restart. It returns once the context is done.
+func reloadSecretsOnHangup(ctx context.Context, args []string, current config.Config, socialProviders []*utils.SocialProvider) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangups:
+		}
+
+		cfg, err := config.Load(flag.NewFlagSet("serve", flag.ContinueOnError), args)
+		if err != nil {
+			slog.Error("Error reloading secrets, keeping the current ones", "error", err)
+			continue
+		}
+
+		// tokens
+		if cfg.JWT.Secret != current.JWT.Secret {
+			utils.SetTokenSecret(cfg.JWT.Secret)
+			current.JWT.Secret = cfg.JWT.Secret
+			slog.Info("Reloaded JWT secret, tokens signed with the previous one are no longer valid")
+		}
+
+		// emails
+		if cfg.SMTP.Password != current.SMTP.Password {
+			current.SMTP.Password = cfg.SMTP.Password
+			utils.ConfigureMailer(current.SMTP, current.PublicURL)
+			slog.Info("Reloaded SMTP password")
+		}
+
+		// social login
+		for i, provider := range socialProviders {
+			for _, providerConfig := range cfg.SocialProviders {
+				if providerConfig.Name == provider.Name && providerConfig.ClientSecret != current.SocialProviders[i].ClientSecret {
+					provider.SetClientSecret(providerConfig.ClientSecret)
+					current.SocialProviders[i].ClientSecret = providerConfig.ClientSecret
+					slog.Info("Reloaded client secret of social login provider", "provider", provider.Name)
+				}
+			}
+		}
+
+		// ID token signing keys, which keys rotate replaces
+		if cfg.IDTokenSigningKeyFile != "" {
+			if err := utils.LoadIDTokenSigningKey(cfg.IDTokenSigningKeyFile); err != nil {
+				slog.Error("Error reloading ID token signing keys, keeping the current ones", "error", err)
+			} else {
+				slog.Info("Reloaded ID token signing keys")
+			}
+		}
+
+		if cfg.DbURL != current.DbURL {
+			slog.Warn("The database URL changed, restart the server to use it")
+		}
+		if cfg.Metrics.BearerToken != current.Metrics.BearerToken {
+			slog.Warn("The metrics bearer token changed, restart the server to use it")
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/config"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository/sqlc"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+	"github.com/google/uuid"
+)
+
+const userUsage = `usage: go-auth user <command> [flags] <arguments>
+
+commands:
+  create [--role role]... --first-name name --last-name name <email>
+      create a user, with the default role unless roles are given, reading the
+      password from standard input
+  set-role <user> <role>
+      replace the roles of a user with the role
+  suspend <user>
+      suspend a user. Operators are deliberately exempt from the guard keeping
+      the last active superadmin, so they can lock out a compromised account.
+  recover <user>
+      recover a suspended or deleted user, e.g. the last superadmin
+  reset-password [--send-email] <user>
+      set the password of a user, read from standard input, and sign them out
+      everywhere, or email them a reset password link
+
+Users are given by email or id. Every command also takes the config flags.`
+
+const tokensUsage = `usage: go-auth tokens revoke [flags] <user> | --all
+
+Revokes the refresh tokens of a user, or of every user with --all. Access
+tokens stay valid until they expire. Every command also takes the config flags.`
+
+// runUser runs the user command, which manages users as an operator who
+// outranks every role and is not subject to the registration policy
+func runUser(args []string) error {
+	if len(args) == 0 {
+		return errors.New(userUsage)
+	}
+	command := args[0]
+	flags := flag.NewFlagSet("user "+command, flag.ContinueOnError)
+
+	switch command {
+	case "create":
+		var roles []string
+		flags.Func("role", "role of the user, can be repeated (default: the registration default role)", func(role string) error {
+			roles = append(roles, role)
+			return nil
+		})
+		firstName := flags.String("first-name", "", "first name of the user")
+		lastName := flags.String("last-name", "", "last name of the user")
+
+		return withUserService(flags, args[1:], func(ctx context.Context, userService *usecases.UserService) error {
+			if err := expectArgs(flags, 1); err != nil {
+				return err
+			}
+			password, err := readPassword("Password: ")
+			if err != nil {
+				return err
+			}
+
+			user, err := userService.CreateUserWithRoles(ctx, flags.Arg(0), password, *firstName, *lastName, roles)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Created user %s (%s) with role %s\n", user.Username, user.ID, user.UserRole)
+			return nil
+		})
+	case "set-role":
+		return withUserService(flags, args[1:], func(ctx context.Context, userService *usecases.UserService) error {
+			if err := expectArgs(flags, 2); err != nil {
+				return err
+			}
+			user, err := findUser(ctx, userService, flags.Arg(0))
+			if err != nil {
+				return err
+			}
+
+			user, err = userService.SetUserRole(ctx, user.ID, flags.Arg(1))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Set the role of %s to %s\n", user.Email, user.UserRole)
+			return nil
+		})
+	case "suspend":
+		return withUserService(flags, args[1:], func(ctx context.Context, userService *usecases.UserService) error {
+			if err := expectArgs(flags, 1); err != nil {
+				return err
+			}
+			user, err := findUser(ctx, userService, flags.Arg(0))
+			if err != nil {
+				return err
+			}
+
+			if _, err := userService.SuspendUser(ctx, user.ID); err != nil {
+				return err
+			}
+			fmt.Printf("Suspended %s\n", user.Email)
+			return nil
+		})
+	case "recover":
+		return withUserService(flags, args[1:], func(ctx context.Context, userService *usecases.UserService) error {
+			if err := expectArgs(flags, 1); err != nil {
+				return err
+			}
+			user, err := findUser(ctx, userService, flags.Arg(0))
+			if err != nil {
+				return err
+			}
+
+			if _, err := userService.RecoverUser(ctx, user.ID); err != nil {
+				return err
+			}
+			fmt.Printf("Recovered %s\n", user.Email)
+			return nil
+		})
+	case "reset-password":
+		sendEmail := flags.Bool("send-email", false, "email a reset password link instead of setting the password")
+
+		return withUserService(flags, args[1:], func(ctx context.Context, userService *usecases.UserService) error {
+			if err := expectArgs(flags, 1); err != nil {
+				return err
+			}
+			user, err := findUser(ctx, userService, flags.Arg(0))
+			if err != nil {
+				return err
+			}
+
+			if *sendEmail {
+				if err := userService.SendResetPasswordEmail(ctx, user.Email); err != nil {
+					return err
+				}
+				fmt.Printf("Sent a reset password link to %s\n", user.Email)
+				return nil
+			}
+
+			password, err := readPassword("New password: ")
+			if err != nil {
+				return err
+			}
+			if err := userService.SetPassword(ctx, user.ID, password); err != nil {
+				return err
+			}
+			fmt.Printf("Reset the password of %s and signed them out\n", user.Email)
+			return nil
+		})
+	default:
+		return errors.New(userUsage)
+	}
+}
+
+// runTokens runs the tokens command, which revokes refresh tokens
+func runTokens(args []string) error {
+	if len(args) == 0 || args[0] != "revoke" {
+		return errors.New(tokensUsage)
+	}
+
+	flags := flag.NewFlagSet("tokens revoke", flag.ContinueOnError)
+	all := flags.Bool("all", false, "revoke the refresh tokens of every user")
+
+	return withUserService(flags, args[1:], func(ctx context.Context, userService *usecases.UserService) error {
+		if *all {
+			if err := expectArgs(flags, 0); err != nil {
+				return err
+			}
+			revoked, err := userService.RevokeAllTokens(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Revoked %d refresh tokens\n", revoked)
+			return nil
+		}
+
+		if err := expectArgs(flags, 1); err != nil {
+			return err
+		}
+		user, err := findUser(ctx, userService, flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		revoked, err := userService.RevokeUserTokens(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Revoked %d refresh tokens of %s\n", revoked, user.Email)
+		return nil
+	})
+}
+
+// withUserService loads the configuration from the args with the flags, which
+// may hold flags of the command, and runs fn with the user service on the
+// configured database, in the context of an operator
+func withUserService(flags *flag.FlagSet, args []string, fn func(ctx context.Context, userService *usecases.UserService) error) error {
+	cfg, err := config.Load(flags, args)
+	if err != nil {
+		return err
+	}
+
+	ctx := utils.SetOperatorInContext(context.Background())
+	conn, err := openDatabase(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Applying the configuration of tokens and emails
+	utils.SetTokenSecret(cfg.JWT.Secret)
+	utils.ConfigureMailer(cfg.SMTP, cfg.PublicURL)
+
+	registration, err := usecases.NewRegistrationPolicy(cfg.Registration.Mode, cfg.Registration.DefaultRole, cfg.Registration.AllowedDomains, cfg.Registration.DeniedDomains)
+	if err != nil {
+		return err
+	}
+
+	db := database.New(conn)
+	userService := usecases.NewUserService(sqlc.NewSQLUserRepository(db), sqlc.NewSQLRoleRepository(db, conn), sqlc.NewSQLOrganizationRepository(db), sqlc.NewSQLServiceAccountRepository(db), registration)
+
+	return fn(ctx, userService)
+}
+
+// expectArgs makes sure the command was given that many arguments after its flags
+func expectArgs(flags *flag.FlagSet, arguments int) error {
+	if flags.NArg() != arguments {
+		noun := "arguments"
+		if arguments == 1 {
+			noun = "argument"
+		}
+		return fmt.Errorf("%s expects %d %s after its flags, got %d, see go-auth %s -h", flags.Name(), arguments, noun, flags.NArg(), strings.Fields(flags.Name())[0])
+	}
+
+	return nil
+}
+
+// findUser returns the user with the id or email
+func findUser(ctx context.Context, userService *usecases.UserService, user string) (model.User, error) {
+	if userId, err := uuid.Parse(user); err == nil {
+		return userService.GetUserById(ctx, userId)
+	}
+
+	return userService.GetUserByEmail(ctx, user)
+}
+
+// readPassword reads a line from standard input, prompting for it when the
+// input is a terminal. The line is echoed, so pipe secrets in scripts.
+func readPassword(prompt string) (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, prompt)
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("reading the password from standard input: %w", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -11,6 +11,109 @@ import (
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	CreatedAt  time.Time
+}
+
+type Impersonation struct {
+	ID            uuid.UUID
+	ActorID       uuid.NullUUID
+	ActorUsername string
+	TargetID      uuid.UUID
+	OrgID         uuid.NullUUID
+	Reason        string
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
+
+type ImpersonationEvent struct {
+	ID              uuid.UUID
+	ImpersonationID uuid.UUID
+	Method          string
+	Path            string
+	CreatedAt       time.Time
+}
+
+type MagicLink struct {
+	ID                 uuid.UUID
+	UserID             uuid.UUID
+	BrowserBindingHash sql.NullString
+	ExpiresAt          time.Time
+	UsedAt             sql.NullTime
+	CreatedAt          time.Time
+}
+
+type OauthAuthorizationCode struct {
+	CodeHash            string
+	ClientID            string
+	UserID              uuid.UUID
+	RedirectUri         string
+	Scope               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	ExpiresAt           time.Time
+	UsedAt              sql.NullTime
+	CreatedAt           time.Time
+	Nonce               string
+}
+
+type OauthClient struct {
+	ID                     string
+	Name                   string
+	SecretHash             sql.NullString
+	RedirectUris           []string
+	GrantTypes             []string
+	Scopes                 []string
+	CreatedAt              time.Time
+	PostLogoutRedirectUris []string
+}
+
+type OauthConsent struct {
+	UserID    uuid.UUID
+	ClientID  string
+	Scope     string
+	CreatedAt time.Time
+}
+
+type Organization struct {
+	ID        uuid.UUID
+	Name      string
+	Slug      string
+	CreatedAt time.Time
+}
+
+type OrganizationInvitation struct {
+	ID         uuid.UUID
+	OrgID      uuid.UUID
+	Email      string
+	OrgRole    string
+	InvitedBy  uuid.NullUUID
+	ExpiresAt  time.Time
+	AcceptedAt sql.NullTime
+	RevokedAt  sql.NullTime
+	CreatedAt  time.Time
+}
+
+type OrganizationMember struct {
+	OrgID     uuid.UUID
+	UserID    uuid.UUID
+	OrgRole   string
+	CreatedAt time.Time
+}
+
+type Permission struct {
+	Name        string
+	Description string
+}
+
 type RefreshToken struct {
 	ID        uuid.UUID
 	UserID    uuid.UUID
@@ -18,6 +121,35 @@ type RefreshToken struct {
 	CreatedAt time.Time
 	ExpiresAt time.Time
 	RevokedAt sql.NullTime
+	ClientID  sql.NullString
+	Scope     sql.NullString
+}
+
+type Role struct {
+	Name        string
+	Description string
+	CreatedAt   time.Time
+	Rank        int32
+	BuiltIn     bool
+}
+
+type RolePermission struct {
+	RoleName       string
+	PermissionName string
+}
+
+type ServiceAccount struct {
+	ID          uuid.UUID
+	ClientID    string
+	Name        string
+	Description string
+	OrgID       uuid.NullUUID
+	SecretHash  sql.NullString
+	PublicKey   sql.NullString
+	Permissions []string
+	CreatedBy   uuid.NullUUID
+	CreatedAt   time.Time
+	LastUsedAt  sql.NullTime
 }
 
 type User struct {
@@ -39,3 +171,19 @@ type User struct {
 	ProfilePicture  sql.NullString
 	TwoFactorAuth   bool
 }
+
+type UserIdentity struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Provider    string
+	Subject     string
+	Email       sql.NullString
+	CreatedAt   time.Time
+	LastLoginAt sql.NullTime
+}
+
+type UserRole struct {
+	UserID    uuid.UUID
+	RoleName  string
+	CreatedAt time.Time
+}
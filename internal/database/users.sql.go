@@ -45,6 +45,18 @@ func (q *Queries) ActivateUser(ctx context.Context, id uuid.UUID) (User, error)
 	return i, err
 }
 
+const countActiveRefreshTokens = `-- name: CountActiveRefreshTokens :one
+SELECT COUNT(*) FROM refresh_tokens
+WHERE revoked_at IS NULL AND expires_at > NOW()
+`
+
+func (q *Queries) CountActiveRefreshTokens(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countActiveRefreshTokens)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countActiveUsers = `-- name: CountActiveUsers :one
 SELECT COUNT(*) FROM users
 WHERE account_status = 'active'
@@ -92,18 +104,6 @@ func (q *Queries) CountAllUsersByUsername(ctx context.Context, username string)
 	return count, err
 }
 
-const countCustomers = `-- name: CountCustomers :one
-SELECT COUNT(*) FROM users
-WHERE user_role = 'customer'
-`
-
-func (q *Queries) CountCustomers(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countCustomers)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
-}
-
 const countDeletedUsers = `-- name: CountDeletedUsers :one
 SELECT COUNT(*) FROM users
 WHERE account_status = 'deleted'
@@ -244,134 +244,6 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
-const demoteAdminToUser = `-- name: DemoteAdminToUser :one
-UPDATE users SET
-    user_role = 'user'
-WHERE id = $1
-RETURNING id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth
-`
-
-func (q *Queries) DemoteAdminToUser(ctx context.Context, id uuid.UUID) (User, error) {
-	row := q.db.QueryRowContext(ctx, demoteAdminToUser, id)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Username,
-		&i.Email,
-		&i.HashedPassword,
-		&i.FirstName,
-		&i.LastName,
-		&i.PhoneNumber,
-		&i.DateOfBirth,
-		&i.Gender,
-		&i.ShippingAddress,
-		&i.BillingAddress,
-		&i.CreatedAt,
-		&i.LastLogin,
-		&i.AccountStatus,
-		&i.UserRole,
-		&i.ProfilePicture,
-		&i.TwoFactorAuth,
-	)
-	return i, err
-}
-
-const demoteSuperAdminToAdmin = `-- name: DemoteSuperAdminToAdmin :one
-UPDATE users SET
-    user_role = 'admin'
-WHERE id = $1
-RETURNING id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth
-`
-
-func (q *Queries) DemoteSuperAdminToAdmin(ctx context.Context, id uuid.UUID) (User, error) {
-	row := q.db.QueryRowContext(ctx, demoteSuperAdminToAdmin, id)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Username,
-		&i.Email,
-		&i.HashedPassword,
-		&i.FirstName,
-		&i.LastName,
-		&i.PhoneNumber,
-		&i.DateOfBirth,
-		&i.Gender,
-		&i.ShippingAddress,
-		&i.BillingAddress,
-		&i.CreatedAt,
-		&i.LastLogin,
-		&i.AccountStatus,
-		&i.UserRole,
-		&i.ProfilePicture,
-		&i.TwoFactorAuth,
-	)
-	return i, err
-}
-
-const demoteSuperAdminToUser = `-- name: DemoteSuperAdminToUser :one
-UPDATE users SET
-    user_role = 'user'
-WHERE id = $1
-RETURNING id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth
-`
-
-func (q *Queries) DemoteSuperAdminToUser(ctx context.Context, id uuid.UUID) (User, error) {
-	row := q.db.QueryRowContext(ctx, demoteSuperAdminToUser, id)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Username,
-		&i.Email,
-		&i.HashedPassword,
-		&i.FirstName,
-		&i.LastName,
-		&i.PhoneNumber,
-		&i.DateOfBirth,
-		&i.Gender,
-		&i.ShippingAddress,
-		&i.BillingAddress,
-		&i.CreatedAt,
-		&i.LastLogin,
-		&i.AccountStatus,
-		&i.UserRole,
-		&i.ProfilePicture,
-		&i.TwoFactorAuth,
-	)
-	return i, err
-}
-
-const demoteUserToCustomer = `-- name: DemoteUserToCustomer :one
-UPDATE users SET
-    user_role = 'customer'
-WHERE id = $1
-RETURNING id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth
-`
-
-func (q *Queries) DemoteUserToCustomer(ctx context.Context, id uuid.UUID) (User, error) {
-	row := q.db.QueryRowContext(ctx, demoteUserToCustomer, id)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Username,
-		&i.Email,
-		&i.HashedPassword,
-		&i.FirstName,
-		&i.LastName,
-		&i.PhoneNumber,
-		&i.DateOfBirth,
-		&i.Gender,
-		&i.ShippingAddress,
-		&i.BillingAddress,
-		&i.CreatedAt,
-		&i.LastLogin,
-		&i.AccountStatus,
-		&i.UserRole,
-		&i.ProfilePicture,
-		&i.TwoFactorAuth,
-	)
-	return i, err
-}
-
 const disableTwoFactorAuth = `-- name: DisableTwoFactorAuth :one
 UPDATE users SET
     two_factor_auth = FALSE
@@ -559,16 +431,20 @@ func (q *Queries) FindUserByUsername(ctx context.Context, username string) (User
 const getActiveUsers = `-- name: GetActiveUsers :many
 SELECT id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth FROM users
 WHERE account_status = 'active'
-LIMIT $1 OFFSET $2
+AND ($1::uuid IS NULL OR id IN (
+    SELECT user_id FROM organization_members WHERE org_id = $1::uuid
+))
+LIMIT $2 OFFSET $3
 `
 
 type GetActiveUsersParams struct {
+	OrgID  uuid.NullUUID
 	Limit  int32
 	Offset int32
 }
 
 func (q *Queries) GetActiveUsers(ctx context.Context, arg GetActiveUsersParams) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, getActiveUsers, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, getActiveUsers, arg.OrgID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -611,16 +487,20 @@ func (q *Queries) GetActiveUsers(ctx context.Context, arg GetActiveUsersParams)
 const getAdminUsers = `-- name: GetAdminUsers :many
 SELECT id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth FROM users
 WHERE user_role = 'admin'
-LIMIT $1 OFFSET $2
+AND ($1::uuid IS NULL OR id IN (
+    SELECT user_id FROM organization_members WHERE org_id = $1::uuid
+))
+LIMIT $2 OFFSET $3
 `
 
 type GetAdminUsersParams struct {
+	OrgID  uuid.NullUUID
 	Limit  int32
 	Offset int32
 }
 
 func (q *Queries) GetAdminUsers(ctx context.Context, arg GetAdminUsersParams) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, getAdminUsers, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, getAdminUsers, arg.OrgID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -662,68 +542,20 @@ func (q *Queries) GetAdminUsers(ctx context.Context, arg GetAdminUsersParams) ([
 
 const getAllUsers = `-- name: GetAllUsers :many
 SELECT id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth FROM users
-LIMIT $1 OFFSET $2
+WHERE ($1::uuid IS NULL OR id IN (
+    SELECT user_id FROM organization_members WHERE org_id = $1::uuid
+))
+LIMIT $2 OFFSET $3
 `
 
 type GetAllUsersParams struct {
+	OrgID  uuid.NullUUID
 	Limit  int32
 	Offset int32
 }
 
 func (q *Queries) GetAllUsers(ctx context.Context, arg GetAllUsersParams) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, getAllUsers, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []User
-	for rows.Next() {
-		var i User
-		if err := rows.Scan(
-			&i.ID,
-			&i.Username,
-			&i.Email,
-			&i.HashedPassword,
-			&i.FirstName,
-			&i.LastName,
-			&i.PhoneNumber,
-			&i.DateOfBirth,
-			&i.Gender,
-			&i.ShippingAddress,
-			&i.BillingAddress,
-			&i.CreatedAt,
-			&i.LastLogin,
-			&i.AccountStatus,
-			&i.UserRole,
-			&i.ProfilePicture,
-			&i.TwoFactorAuth,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const getCustomers = `-- name: GetCustomers :many
-SELECT id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth FROM users
-WHERE user_role = 'customer'
-LIMIT $1 OFFSET $2
-`
-
-type GetCustomersParams struct {
-	Limit  int32
-	Offset int32
-}
-
-func (q *Queries) GetCustomers(ctx context.Context, arg GetCustomersParams) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, getCustomers, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, getAllUsers, arg.OrgID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -766,16 +598,20 @@ func (q *Queries) GetCustomers(ctx context.Context, arg GetCustomersParams) ([]U
 const getDeletedUsers = `-- name: GetDeletedUsers :many
 SELECT id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth FROM users
 WHERE account_status = 'deleted'
-LIMIT $1 OFFSET $2
+AND ($1::uuid IS NULL OR id IN (
+    SELECT user_id FROM organization_members WHERE org_id = $1::uuid
+))
+LIMIT $2 OFFSET $3
 `
 
 type GetDeletedUsersParams struct {
+	OrgID  uuid.NullUUID
 	Limit  int32
 	Offset int32
 }
 
 func (q *Queries) GetDeletedUsers(ctx context.Context, arg GetDeletedUsersParams) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, getDeletedUsers, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, getDeletedUsers, arg.OrgID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -818,16 +654,20 @@ func (q *Queries) GetDeletedUsers(ctx context.Context, arg GetDeletedUsersParams
 const getInactiveUsers = `-- name: GetInactiveUsers :many
 SELECT id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth FROM users
 WHERE account_status = 'inactive'
-LIMIT $1 OFFSET $2
+AND ($1::uuid IS NULL OR id IN (
+    SELECT user_id FROM organization_members WHERE org_id = $1::uuid
+))
+LIMIT $2 OFFSET $3
 `
 
 type GetInactiveUsersParams struct {
+	OrgID  uuid.NullUUID
 	Limit  int32
 	Offset int32
 }
 
 func (q *Queries) GetInactiveUsers(ctx context.Context, arg GetInactiveUsersParams) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, getInactiveUsers, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, getInactiveUsers, arg.OrgID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -867,8 +707,29 @@ func (q *Queries) GetInactiveUsers(ctx context.Context, arg GetInactiveUsersPara
 	return items, nil
 }
 
+const getRefreshToken = `-- name: GetRefreshToken :one
+SELECT id, user_id, token, created_at, expires_at, revoked_at, client_id, scope FROM refresh_tokens
+WHERE token = $1
+`
+
+func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshToken, token)
+	var i RefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Token,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.ClientID,
+		&i.Scope,
+	)
+	return i, err
+}
+
 const getRefreshTokenByUserID = `-- name: GetRefreshTokenByUserID :one
-SELECT id, user_id, token, created_at, expires_at, revoked_at FROM refresh_tokens
+SELECT id, user_id, token, created_at, expires_at, revoked_at, client_id, scope FROM refresh_tokens
 WHERE user_id = $1
 `
 
@@ -882,6 +743,8 @@ func (q *Queries) GetRefreshTokenByUserID(ctx context.Context, userID uuid.UUID)
 		&i.CreatedAt,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.ClientID,
+		&i.Scope,
 	)
 	return i, err
 }
@@ -889,16 +752,20 @@ func (q *Queries) GetRefreshTokenByUserID(ctx context.Context, userID uuid.UUID)
 const getSuperAdminUsers = `-- name: GetSuperAdminUsers :many
 SELECT id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth FROM users
 WHERE user_role = 'superadmin'
-LIMIT $1 OFFSET $2
+AND ($1::uuid IS NULL OR id IN (
+    SELECT user_id FROM organization_members WHERE org_id = $1::uuid
+))
+LIMIT $2 OFFSET $3
 `
 
 type GetSuperAdminUsersParams struct {
+	OrgID  uuid.NullUUID
 	Limit  int32
 	Offset int32
 }
 
 func (q *Queries) GetSuperAdminUsers(ctx context.Context, arg GetSuperAdminUsersParams) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, getSuperAdminUsers, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, getSuperAdminUsers, arg.OrgID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -941,16 +808,20 @@ func (q *Queries) GetSuperAdminUsers(ctx context.Context, arg GetSuperAdminUsers
 const getSuspendedUsers = `-- name: GetSuspendedUsers :many
 SELECT id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth FROM users
 WHERE account_status = 'suspended'
-LIMIT $1 OFFSET $2
+AND ($1::uuid IS NULL OR id IN (
+    SELECT user_id FROM organization_members WHERE org_id = $1::uuid
+))
+LIMIT $2 OFFSET $3
 `
 
 type GetSuspendedUsersParams struct {
+	OrgID  uuid.NullUUID
 	Limit  int32
 	Offset int32
 }
 
 func (q *Queries) GetSuspendedUsers(ctx context.Context, arg GetSuspendedUsersParams) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, getSuspendedUsers, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, getSuspendedUsers, arg.OrgID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -1076,15 +947,15 @@ func (q *Queries) PartialFindUsersByUsername(ctx context.Context, arg PartialFin
 	return items, nil
 }
 
-const promoteUserToAdmin = `-- name: PromoteUserToAdmin :one
+const recoverUser = `-- name: RecoverUser :one
 UPDATE users SET
-    user_role = 'admin'
+    account_status = 'active'
 WHERE id = $1
 RETURNING id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth
 `
 
-func (q *Queries) PromoteUserToAdmin(ctx context.Context, id uuid.UUID) (User, error) {
-	row := q.db.QueryRowContext(ctx, promoteUserToAdmin, id)
+func (q *Queries) RecoverUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, recoverUser, id)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -1108,74 +979,78 @@ func (q *Queries) PromoteUserToAdmin(ctx context.Context, id uuid.UUID) (User, e
 	return i, err
 }
 
-const promoteUserToSuperAdmin = `-- name: PromoteUserToSuperAdmin :one
-UPDATE users SET
-    user_role = 'superadmin'
-WHERE id = $1
-RETURNING id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth
+const revokeAllRefreshTokens = `-- name: RevokeAllRefreshTokens :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE revoked_at IS NULL
 `
 
-func (q *Queries) PromoteUserToSuperAdmin(ctx context.Context, id uuid.UUID) (User, error) {
-	row := q.db.QueryRowContext(ctx, promoteUserToSuperAdmin, id)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Username,
-		&i.Email,
-		&i.HashedPassword,
-		&i.FirstName,
-		&i.LastName,
-		&i.PhoneNumber,
-		&i.DateOfBirth,
-		&i.Gender,
-		&i.ShippingAddress,
-		&i.BillingAddress,
-		&i.CreatedAt,
-		&i.LastLogin,
-		&i.AccountStatus,
-		&i.UserRole,
-		&i.ProfilePicture,
-		&i.TwoFactorAuth,
-	)
-	return i, err
+func (q *Queries) RevokeAllRefreshTokens(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeAllRefreshTokens)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const recoverUser = `-- name: RecoverUser :one
+const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshToken(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshToken, id)
+	return err
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setAccountStatusUnlessLastSuperAdmin = `-- name: SetAccountStatusUnlessLastSuperAdmin :execrows
 UPDATE users SET
-    account_status = 'active'
-WHERE id = $1
-RETURNING id, username, email, hashed_password, first_name, last_name, phone_number, date_of_birth, gender, shipping_address, billing_address, created_at, last_login, account_status, user_role, profile_picture, two_factor_auth
+    account_status = $1
+WHERE id = $2
+AND (
+    SELECT COUNT(*) FROM (
+        SELECT 1 FROM users members
+        JOIN user_roles ur ON ur.user_id = members.id
+        WHERE ur.role_name = 'superadmin' AND members.account_status = 'active'
+        FOR UPDATE OF members
+    ) AS locked_members
+) > 1
 `
 
-func (q *Queries) RecoverUser(ctx context.Context, id uuid.UUID) (User, error) {
-	row := q.db.QueryRowContext(ctx, recoverUser, id)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Username,
-		&i.Email,
-		&i.HashedPassword,
-		&i.FirstName,
-		&i.LastName,
-		&i.PhoneNumber,
-		&i.DateOfBirth,
-		&i.Gender,
-		&i.ShippingAddress,
-		&i.BillingAddress,
-		&i.CreatedAt,
-		&i.LastLogin,
-		&i.AccountStatus,
-		&i.UserRole,
-		&i.ProfilePicture,
-		&i.TwoFactorAuth,
-	)
-	return i, err
+type SetAccountStatusUnlessLastSuperAdminParams struct {
+	AccountStatus string
+	ID            uuid.UUID
+}
+
+// Locks the active super administrators so concurrent suspensions and
+// deletions cannot both see another one left and deactivate the last one
+func (q *Queries) SetAccountStatusUnlessLastSuperAdmin(ctx context.Context, arg SetAccountStatusUnlessLastSuperAdminParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setAccountStatusUnlessLastSuperAdmin, arg.AccountStatus, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const storeRefreshToken = `-- name: StoreRefreshToken :one
 INSERT INTO refresh_tokens (id, user_id, token, created_at, expires_at)
 VALUES ($1, $2, $3, $4, $5)
-RETURNING id, user_id, token, created_at, expires_at, revoked_at
+RETURNING id, user_id, token, created_at, expires_at, revoked_at, client_id, scope
 `
 
 type StoreRefreshTokenParams struct {
@@ -1202,6 +1077,8 @@ func (q *Queries) StoreRefreshToken(ctx context.Context, arg StoreRefreshTokenPa
 		&i.CreatedAt,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.ClientID,
+		&i.Scope,
 	)
 	return i, err
 }
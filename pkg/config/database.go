@@ -1,7 +1,11 @@
 package config
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
 
 	_ "github.com/lib/pq"
 )
@@ -13,4 +17,28 @@ func NewDatabaseConnection(dbURL string) (*sql.DB, error){
 	}
 
 	return db, nil
-}
\ No newline at end of file
+}
+
+// WaitForDatabase pings the database until it answers, waiting longer after
+// each failed attempt, and gives up once the timeout has passed
+func WaitForDatabase(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := 250 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		slog.Warn("Database is not reachable, retrying", "attempt", attempt, "backoff", backoff.String(), "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database still not reachable after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, 5*time.Second)
+	}
+}
@@ -1,34 +1,239 @@
 package config
 
 import (
-	"log"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 const errorString = "Package Config Error: "
 
-type Config struct{
-	Port string
-	DbURL string
-	DefaultPageSize int32
-	DefaultPage int32
+// Config is the configuration of the server. Every setting can come from the
+// defaults, a YAML or TOML config file, environment variables and command line
+// flags, each overriding the ones before it. The yaml tag of a setting is its
+// key in config files, its env tag or upper-cased path its environment
+// variable and its path with dashes its flag, e.g. smtp.host, SMTP_HOST and
+// --smtp-host. Settings tagged secret can be references to secrets, see
+// SecretsConfig, and are hidden by config print --redacted.
+type Config struct {
+	Port                  string                 `yaml:"port" toml:"port" usage:"port the server listens on"`
+	DbURL                 string                 `yaml:"db_url" toml:"db_url" secret:"url" usage:"PostgreSQL connection URL"`
+	DBConnectTimeout      time.Duration          `yaml:"db_connect_timeout" toml:"db_connect_timeout" usage:"how long to keep retrying to reach the database on start"`
+	AutoMigrate           bool                   `yaml:"auto_migrate" toml:"auto_migrate" usage:"apply pending database migrations when the server starts"`
+	DefaultPageSize       int32                  `yaml:"default_page_size" toml:"default_page_size" usage:"number of records listed when a request sets no limit"`
+	Issuer                string                 `yaml:"issuer_url" toml:"issuer_url" usage:"public base URL of the server, used as the token issuer"`
+	PublicURL             string                 `yaml:"public_url" toml:"public_url" usage:"base URL of links sent by email (default: issuer_url)"`
+	IDTokenSigningKeyFile string                 `yaml:"id_token_signing_key_file" toml:"id_token_signing_key_file" usage:"PEM file of the RSA key ID tokens are signed with"`
+	Server                ServerConfig           `yaml:"server" toml:"server"`
+	Metrics               MetricsConfig          `yaml:"metrics" toml:"metrics"`
+	Tracing               TracingConfig          `yaml:"tracing" toml:"tracing"`
+	Log                   LogConfig              `yaml:"log" toml:"log"`
+	Security              SecurityConfig         `yaml:"security" toml:"security"`
+	JWT                   JWTConfig              `yaml:"jwt" toml:"jwt"`
+	SMTP                  SMTPConfig             `yaml:"smtp" toml:"smtp"`
+	CORS                  CORSConfig             `yaml:"cors" toml:"cors"`
+	Registration          RegistrationConfig     `yaml:"registration" toml:"registration"`
+	SocialProviders       []SocialProviderConfig `yaml:"social_providers" toml:"social_providers"`
+	Secrets               SecretsConfig          `yaml:"secrets" toml:"secrets"`
 }
 
-func LoadConfig() Config {
-	err := godotenv.Load()
-	if err := godotenv.Load(".env"); err != nil {
-		log.Fatal("Error loading .env file: ", err)
-	}
+// ServerConfig configures the limits of the HTTP server
+type ServerConfig struct {
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout" usage:"longest time to read a request, body included"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout" usage:"longest time to read the headers of a request"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout" usage:"longest time to handle a request and write its response"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout" usage:"how long keep-alive connections wait for their next request"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" toml:"max_header_bytes" usage:"largest size of the headers of a request, in bytes"`
+	// ShutdownTimeout is how long requests in flight have to complete once the server is asked to stop
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" usage:"how long requests in flight have to complete when shutting down"`
+	// ShutdownDelay is how long the server keeps serving, reporting it is not
+	// ready, before it stops accepting connections, so load balancers can
+	// notice and route requests elsewhere
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay" usage:"how long to keep serving while reporting not ready before shutting down"`
+}
+
+// MetricsConfig configures the Prometheus metrics served at /metrics
+type MetricsConfig struct {
+	Enabled     bool   `yaml:"enabled" toml:"enabled" usage:"serve Prometheus metrics at /metrics"`
+	BearerToken string `yaml:"bearer_token" toml:"bearer_token" secret:"true" usage:"token scrapers must send as a bearer token, metrics are public without one"`
+}
+
+// Exporters of traces
+const (
+	TracingExporterNone   = "none"
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+	TracingExporterFile   = "file"
+)
+
+// TracingConfig configures the OpenTelemetry traces of requests and where they are exported to
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" toml:"exporter" usage:"where traces are exported to: none, otlp, stdout or file"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" toml:"otlp_endpoint" usage:"URL of the OTLP/HTTP collector (default: OTEL_EXPORTER_OTLP_ENDPOINT or http://localhost:4318)"`
+	File         string  `yaml:"file" toml:"file" usage:"file traces are appended to with the file exporter"`
+	SampleRatio  float64 `yaml:"sample_ratio" toml:"sample_ratio" usage:"share of traces started by the server that are recorded, from 0 to 1"`
+	ServiceName  string  `yaml:"service_name" toml:"service_name" usage:"service name traces are reported under"`
+}
+
+// Formats of logs
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// LogConfig configures the structured logs of the server
+type LogConfig struct {
+	Format string `yaml:"format" toml:"format" usage:"format of logs: text or json"`
+	Level  string `yaml:"level" toml:"level" usage:"least severe level logged: debug, info, warn or error"`
+	Access bool   `yaml:"access" toml:"access" usage:"log every request the server handles"`
+}
+
+// SecurityConfig configures the security headers of responses
+type SecurityConfig struct {
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age" toml:"hsts_max_age" usage:"how long browsers only reach the server over HTTPS, 0 sends no Strict-Transport-Security header"`
+	ContentSecurityPolicy string        `yaml:"content_security_policy" toml:"content_security_policy" usage:"Content-Security-Policy header of responses, none when empty"`
+}
+
+// JWTConfig configures the signing of tokens
+type JWTConfig struct {
+	// Secret is the key every HMAC signed token is signed with a key derived from
+	Secret string `yaml:"secret" toml:"secret" secret:"true" usage:"secret signing keys of tokens are derived from, at least 32 bytes"`
+}
+
+// SMTPConfig configures the server emails are sent through
+type SMTPConfig struct {
+	Host     string `yaml:"host" toml:"host" usage:"SMTP server host, emails are not sent without one"`
+	Port     int    `yaml:"port" toml:"port" usage:"SMTP server port"`
+	Username string `yaml:"username" toml:"username" usage:"SMTP username (default: from)"`
+	Password string `yaml:"password" toml:"password" secret:"true" usage:"SMTP password"`
+	From     string `yaml:"from" toml:"from" usage:"address emails are sent from"`
+}
+
+// CORSConfig configures the browser origins allowed to call the API
+type CORSConfig struct {
+	Origins []string `yaml:"origins" toml:"origins" usage:"comma separated origins allowed to make cross-origin requests"`
+	Debug   bool     `yaml:"debug" toml:"debug" usage:"log why cross-origin requests are refused"`
+}
+
+// Default returns the configuration used for settings that are not set
+func Default() Config {
 	return Config{
-		Port: os.Getenv("PORT"),
-		DbURL: os.Getenv("DB_URL"),
-		DefaultPageSize: 100,
-		DefaultPage: 1,
+		Port:             "8080",
+		DBConnectTimeout: 30 * time.Second,
+		DefaultPageSize:  100,
+		Issuer:           "http://localhost:8000",
+		Server: ServerConfig{
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			MaxHeaderBytes:    64 << 10,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Tracing: TracingConfig{
+			Exporter:    TracingExporterNone,
+			SampleRatio: 1,
+			ServiceName: "go-auth",
+		},
+		Log: LogConfig{
+			Format: LogFormatText,
+			Level:  "info",
+			Access: true,
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            365 * 24 * time.Hour,
+			ContentSecurityPolicy: "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'; base-uri 'none'",
+		},
+		SMTP: SMTPConfig{
+			Port: 587,
+		},
+		CORS: CORSConfig{
+			Origins: []string{"http://localhost:5173"},
+		},
+		Registration: RegistrationConfig{
+			Mode:        "open",
+			DefaultRole: "user",
+		},
 	}
 }
 
+// Load loads the configuration from the defaults, the config file, the
+// environment and the command line flags in args, in increasing order of
+// precedence, resolves its secret references and validates it. The config
+// flags are registered on flags, which callers can add flags of their own to.
+// The config file is given by --config or CONFIG_FILE; a .env file is read
+// into the environment if there is one.
+func Load(flags *flag.FlagSet, args []string) (Config, error) {
+	cfg := Default()
+	settings := settingsOf(&cfg)
+
+	// flags are parsed first to find the config file, and applied last
+	configFile := flags.String("config", "", "YAML or TOML config file (env: CONFIG_FILE)")
+	setFlags := registerFlags(flags, settings)
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	// .env files are optional
+	if err := godotenv.Load(".env"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Config{}, fmt.Errorf("reading .env: %w", err)
+	}
+
+	// config file
+	if *configFile == "" {
+		*configFile = os.Getenv("CONFIG_FILE")
+	}
+	if *configFile != "" {
+		if err := loadFile(*configFile, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	// environment
+	if err := loadEnv(settings); err != nil {
+		return Config{}, err
+	}
+	if providers := loadSocialProviders(); providers != nil {
+		cfg.SocialProviders = providers
+	}
+
+	// flags
+	for _, setting := range settings {
+		if value, ok := setFlags[setting.flag]; ok {
+			if err := setting.set(value); err != nil {
+				return Config{}, fmt.Errorf("--%s: %w", setting.flag, err)
+			}
+		}
+	}
+
+	// secrets
+	if err := cfg.resolveSecrets(); err != nil {
+		return Config{}, err
+	}
+
+	// settings derived from others
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = cfg.Issuer
+	}
+	if cfg.SMTP.Username == "" {
+		cfg.SMTP.Username = cfg.SMTP.From
+	}
+	cfg.SocialProviders = withProviderDefaults(cfg.SocialProviders, cfg.Issuer)
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
 func getEnv(key string, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// redactedValue replaces secrets in redacted configurations
+const redactedValue = "REDACTED"
+
+// Redacted returns a copy of the configuration with its secrets replaced and
+// the passwords of URLs removed
+func (c Config) Redacted() Config {
+	redacted := c
+	eachSecret(reflect.ValueOf(&redacted).Elem(), "", func(path string, kind string, value reflect.Value) error {
+		if kind == "url" {
+			if parsed, err := url.Parse(value.String()); err == nil {
+				value.SetString(parsed.Redacted())
+				return nil
+			}
+		}
+		value.SetString(redactedValue)
+		return nil
+	})
+	return redacted
+}
+
+// Print writes the configuration to w in the format, yaml or toml
+func (c Config) Print(w io.Writer, format string) error {
+	switch format {
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(c); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "toml":
+		return toml.NewEncoder(w).Encode(c)
+	default:
+		return fmt.Errorf("unknown format %q, expected yaml or toml", format)
+	}
+}
@@ -0,0 +1,11 @@
+package config
+
+// RegistrationConfig configures who can create an account for themselves
+type RegistrationConfig struct {
+	// Mode is open, invite_only or closed
+	Mode string `yaml:"mode" toml:"mode" usage:"open, invite_only or closed"`
+	// DefaultRole is the role of users who sign up themselves
+	DefaultRole    string   `yaml:"default_role" toml:"default_role" env:"DEFAULT_ROLE" usage:"role of users who sign up themselves"`
+	AllowedDomains []string `yaml:"allowed_domains" toml:"allowed_domains" usage:"comma separated email domains that can sign up, any when empty"`
+	DeniedDomains  []string `yaml:"denied_domains" toml:"denied_domains" usage:"comma separated email domains that cannot sign up"`
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/secrets"
+)
+
+// SecretsConfig configures where secret references are resolved from. Every
+// setting tagged secret can be a file://, env: or keystore: reference.
+type SecretsConfig struct {
+	KeystoreFile string `yaml:"keystore_file" toml:"keystore_file" usage:"encrypted keystore file keystore: references are read from"`
+	// MasterKey is the key the keystore is encrypted with, or a file:// or env: reference to it
+	MasterKey string `yaml:"master_key" toml:"master_key" secret:"true" usage:"master key of the keystore, or a file:// or env: reference to it"`
+}
+
+// resolveSecrets replaces the secret references of the configuration with the
+// secrets they refer to
+func (c *Config) resolveSecrets() error {
+	resolver, err := secrets.NewResolver(c.Secrets.KeystoreFile, c.Secrets.MasterKey)
+	if err != nil {
+		return fmt.Errorf("secrets: %w", err)
+	}
+
+	return eachSecret(reflect.ValueOf(c).Elem(), "", func(path string, kind string, value reflect.Value) error {
+		// the master key was resolved by the resolver
+		if path == "secrets.master_key" {
+			return nil
+		}
+
+		secret, err := resolver.Resolve(value.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		value.SetString(secret)
+		return nil
+	})
+}
+
+// eachSecret calls fn with every setting tagged secret, along with its path and
+// the kind of secret of its tag. Lists are copied first, so changes made by fn
+// do not reach copies of the configuration that share them.
+func eachSecret(value reflect.Value, prefix string, fn func(path string, kind string, value reflect.Value) error) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		fieldValue := value.Field(i)
+		path := prefix + field.Tag.Get("yaml")
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			if err := eachSecret(fieldValue, path+".", fn); err != nil {
+				return err
+			}
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			copied := reflect.MakeSlice(field.Type, fieldValue.Len(), fieldValue.Len())
+			reflect.Copy(copied, fieldValue)
+			for j := 0; j < copied.Len(); j++ {
+				if err := eachSecret(copied.Index(j), fmt.Sprintf("%s.%d.", path, j), fn); err != nil {
+					return err
+				}
+			}
+			fieldValue.Set(copied)
+		case field.Tag.Get("secret") != "" && fieldValue.String() != "":
+			if err := fn(path, field.Tag.Get("secret"), fieldValue); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting is a single configurable value of the configuration
+type setting struct {
+	// path is the dotted key of the setting in config files, e.g. smtp.host
+	path  string
+	env   string
+	flag  string
+	usage string
+	value reflect.Value
+}
+
+// settingsOf lists the settings of the configuration. Lists of structs, like
+// the social login providers, can only be set in config files.
+func settingsOf(cfg *Config) []setting {
+	return appendSettings(nil, reflect.ValueOf(cfg).Elem(), "")
+}
+
+func appendSettings(settings []setting, value reflect.Value, prefix string) []setting {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		path := prefix + field.Tag.Get("yaml")
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			settings = appendSettings(settings, value.Field(i), path+".")
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			continue
+		default:
+			env := field.Tag.Get("env")
+			if env == "" {
+				env = strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+			}
+
+			settings = append(settings, setting{
+				path:  path,
+				env:   env,
+				flag:  strings.NewReplacer(".", "-", "_", "-").Replace(path),
+				usage: field.Tag.Get("usage"),
+				value: value.Field(i),
+			})
+		}
+	}
+
+	return settings
+}
+
+// set parses the text into the setting
+func (s setting) set(text string) error {
+	switch s.value.Interface().(type) {
+	case string:
+		s.value.SetString(text)
+	case bool:
+		parsed, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", text)
+		}
+		s.value.SetBool(parsed)
+	case time.Duration:
+		parsed, err := time.ParseDuration(text)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration, e.g. 30s", text)
+		}
+		s.value.SetInt(int64(parsed))
+	case int, int32, int64:
+		parsed, err := strconv.ParseInt(text, 10, s.value.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", text)
+		}
+		s.value.SetInt(parsed)
+	case float64:
+		parsed, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", text)
+		}
+		s.value.SetFloat(parsed)
+	case []string:
+		s.value.Set(reflect.ValueOf(splitList(text)))
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+
+	return nil
+}
+
+// loadEnv sets the settings that have an environment variable
+func loadEnv(settings []setting) error {
+	for _, setting := range settings {
+		if text, ok := os.LookupEnv(setting.env); ok {
+			if err := setting.set(text); err != nil {
+				return fmt.Errorf("%s: %w", setting.env, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// registerFlags adds a flag for every setting and returns the values of the
+// flags that were given once the flags are parsed
+func registerFlags(flags *flag.FlagSet, settings []setting) map[string]string {
+	setFlags := map[string]string{}
+
+	for _, setting := range settings {
+		name := setting.flag
+		usage := fmt.Sprintf("%s (env: %s)", setting.usage, setting.env)
+
+		if setting.value.Kind() == reflect.Bool {
+			flags.BoolFunc(name, usage, func(text string) error {
+				setFlags[name] = text
+				return nil
+			})
+			continue
+		}
+		flags.Func(name, usage, func(text string) error {
+			setFlags[name] = text
+			return nil
+		})
+	}
+
+	return setFlags
+}
+
+// splitList splits a comma separated list, dropping blank entries
+func splitList(list string) []string {
+	values := []string{}
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Types of social login providers
+const (
+	SocialProviderOIDC   = "oidc"
+	SocialProviderGitHub = "github"
+)
+
+// SocialProviderConfig configures an external identity provider users can sign in with
+type SocialProviderConfig struct {
+	Name         string   `yaml:"name" toml:"name"`
+	Type         string   `yaml:"type" toml:"type"`
+	ClientID     string   `yaml:"client_id" toml:"client_id"`
+	ClientSecret string   `yaml:"client_secret" toml:"client_secret" secret:"true"`
+	Issuer       string   `yaml:"issuer" toml:"issuer"`
+	Scopes       []string `yaml:"scopes" toml:"scopes"`
+	RedirectURL  string   `yaml:"redirect_url" toml:"redirect_url"`
+}
+
+// loadSocialProviders reads the providers listed in SOCIAL_PROVIDERS, which
+// replace the ones of the config file. Each one is configured by variables
+// prefixed with its upper-cased name, e.g. GOOGLE_CLIENT_ID. It returns nil
+// when SOCIAL_PROVIDERS is not set.
+func loadSocialProviders() []SocialProviderConfig {
+	names, ok := os.LookupEnv("SOCIAL_PROVIDERS")
+	if !ok {
+		return nil
+	}
+
+	providers := []SocialProviderConfig{}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+
+		provider := SocialProviderConfig{
+			Name:         name,
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+		}
+		if name == "microsoft" && provider.Issuer == "" {
+			provider.Issuer = "https://login.microsoftonline.com/" + getEnv("MICROSOFT_TENANT", "common") + "/v2.0"
+		}
+		if scopes := os.Getenv(prefix + "SCOPES"); scopes != "" {
+			provider.Scopes = strings.Fields(scopes)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers
+}
+
+// withProviderDefaults fills in the settings the providers left out. Google,
+// Microsoft and GitHub have presets; other providers must be OpenID Connect
+// providers with an issuer. Providers missing a client ID or issuer are skipped.
+func withProviderDefaults(providers []SocialProviderConfig, baseURL string) []SocialProviderConfig {
+	var configured []SocialProviderConfig
+
+	for _, provider := range providers {
+		provider.Name = strings.ToLower(provider.Name)
+
+		// presets
+		switch provider.Name {
+		case "google":
+			if provider.Issuer == "" {
+				provider.Issuer = "https://accounts.google.com"
+			}
+		case "microsoft":
+			if provider.Issuer == "" {
+				provider.Issuer = "https://login.microsoftonline.com/common/v2.0"
+			}
+		case "github":
+			if provider.Type == "" {
+				provider.Type = SocialProviderGitHub
+			}
+			if len(provider.Scopes) == 0 {
+				provider.Scopes = []string{"read:user", "user:email"}
+			}
+		}
+
+		if provider.Type == "" {
+			provider.Type = SocialProviderOIDC
+		}
+		if len(provider.Scopes) == 0 {
+			provider.Scopes = []string{"openid", "profile", "email"}
+		}
+		if provider.RedirectURL == "" {
+			provider.RedirectURL = strings.TrimSuffix(baseURL, "/") + "/auth/" + provider.Name + "/callback"
+		}
+
+		if provider.ClientID == "" || (provider.Type == SocialProviderOIDC && provider.Issuer == "") {
+			slog.Warn("Skipping social login provider: a client ID and, for OpenID Connect providers, an issuer are required", "provider", provider.Name)
+			continue
+		}
+
+		configured = append(configured, provider)
+	}
+
+	return configured
+}
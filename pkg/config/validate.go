@@ -0,0 +1,159 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// minSecretLength is the shortest secret tokens can be signed with
+const minSecretLength = 32
+
+// Validate checks every setting and returns an error listing each invalid one
+// along with where it can be set
+func (c Config) Validate() error {
+	settings := map[string]setting{}
+	for _, setting := range settingsOf(&c) {
+		settings[setting.path] = setting
+	}
+
+	var errs []error
+	invalid := func(path string, format string, args ...any) {
+		setting := settings[path]
+		errs = append(errs, fmt.Errorf("%s (env %s, flag --%s): %s", path, setting.env, setting.flag, fmt.Sprintf(format, args...)))
+	}
+
+	// server
+	if !isPort(c.Port) {
+		invalid("port", "must be a port number between 1 and 65535, got %q", c.Port)
+	}
+	if c.DbURL == "" {
+		invalid("db_url", "is required")
+	}
+	if c.DefaultPageSize < 1 || c.DefaultPageSize > 1000 {
+		invalid("default_page_size", "must be between 1 and 1000, got %d", c.DefaultPageSize)
+	}
+	if c.DBConnectTimeout < 0 {
+		invalid("db_connect_timeout", "must not be negative, got %s", c.DBConnectTimeout)
+	}
+	if !isAbsoluteURL(c.Issuer) {
+		invalid("issuer_url", "must be an absolute http or https URL, got %q", c.Issuer)
+	}
+	if !isAbsoluteURL(c.PublicURL) {
+		invalid("public_url", "must be an absolute http or https URL, got %q", c.PublicURL)
+	}
+
+	// http server
+	timeouts := []struct {
+		path    string
+		timeout time.Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.timeout <= 0 {
+			invalid(timeout.path, "must be a positive duration, e.g. 30s, got %s", timeout.timeout)
+		}
+	}
+	if c.Server.ShutdownDelay < 0 {
+		invalid("server.shutdown_delay", "must not be negative, got %s", c.Server.ShutdownDelay)
+	}
+	if c.Server.MaxHeaderBytes < 1<<10 {
+		invalid("server.max_header_bytes", "must be at least 1024, got %d", c.Server.MaxHeaderBytes)
+	}
+
+	// tracing
+	tracingExporters := []string{TracingExporterNone, TracingExporterOTLP, TracingExporterStdout, TracingExporterFile}
+	if !slices.Contains(tracingExporters, c.Tracing.Exporter) {
+		invalid("tracing.exporter", "must be none, otlp, stdout or file, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.Exporter == TracingExporterFile && c.Tracing.File == "" {
+		invalid("tracing.file", "is required with the file exporter")
+	}
+	if c.Tracing.OTLPEndpoint != "" && !isAbsoluteURL(c.Tracing.OTLPEndpoint) {
+		invalid("tracing.otlp_endpoint", "must be an absolute http or https URL, got %q", c.Tracing.OTLPEndpoint)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("tracing.sample_ratio", "must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	}
+
+	// logs
+	if c.Log.Format != LogFormatText && c.Log.Format != LogFormatJSON {
+		invalid("log.format", "must be text or json, got %q", c.Log.Format)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		invalid("log.level", "must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+
+	// security headers
+	if c.Security.HSTSMaxAge < 0 {
+		invalid("security.hsts_max_age", "must not be negative, got %s", c.Security.HSTSMaxAge)
+	}
+
+	// tokens
+	if c.JWT.Secret == "" {
+		invalid("jwt.secret", "is required, generate one with: openssl rand -base64 32")
+	} else if len(c.JWT.Secret) < minSecretLength {
+		invalid("jwt.secret", "must be at least %d bytes long, got %d", minSecretLength, len(c.JWT.Secret))
+	}
+
+	// email
+	if c.SMTP.Host != "" {
+		if c.SMTP.Port < 1 || c.SMTP.Port > 65535 {
+			invalid("smtp.port", "must be a port number between 1 and 65535, got %d", c.SMTP.Port)
+		}
+		if _, err := mail.ParseAddress(c.SMTP.From); err != nil {
+			invalid("smtp.from", "must be an email address when smtp.host is set, got %q", c.SMTP.From)
+		}
+	}
+
+	// browsers
+	for _, origin := range c.CORS.Origins {
+		if !isAbsoluteURL(origin) {
+			invalid("cors.origins", "must be absolute http or https URLs, got %q", origin)
+		}
+	}
+
+	// registration
+	if !slices.Contains([]string{"open", "invite_only", "closed"}, c.Registration.Mode) {
+		invalid("registration.mode", "must be open, invite_only or closed, got %q", c.Registration.Mode)
+	}
+	if c.Registration.DefaultRole == "" {
+		invalid("registration.default_role", "is required")
+	}
+
+	// social login, which can only be configured in config files and SOCIAL_PROVIDERS
+	for _, provider := range c.SocialProviders {
+		if provider.Type != SocialProviderOIDC && provider.Type != SocialProviderGitHub {
+			errs = append(errs, fmt.Errorf("social_providers.%s.type: must be %s or %s, got %q", provider.Name, SocialProviderOIDC, SocialProviderGitHub, provider.Type))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// isPort reports whether the text is a port number
+func isPort(text string) bool {
+	port, err := strconv.Atoi(text)
+	return err == nil && port > 0 && port <= 65535
+}
+
+// isAbsoluteURL reports whether the text is an absolute http or https URL
+func isAbsoluteURL(text string) bool {
+	parsed, err := url.Parse(text)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
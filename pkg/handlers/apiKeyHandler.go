@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *usecases.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *usecases.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	apiKeys, err := h.apiKeyService.GetAPIKeys(r.Context())
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch API keys")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, apiKeys)
+}
+
+// CreateAPIKey responds with the new key, which is not shown again
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Name      string     `json:"name" validate:"required,max=50"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// create API key
+	apiKey, err := h.apiKeyService.CreateAPIKey(r.Context(), params.Name, params.Scopes, params.ExpiresAt)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to create API key")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, apiKey)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		ID uuid.UUID `json:"id" validate:"required"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// revoke API key
+	err := h.apiKeyService.RevokeAPIKey(r.Context(), params.ID)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to revoke API key")
+		return
+	}
+
+	RespondWithSuccess(w, http.StatusOK, "Successfully revoked the API key")
+}
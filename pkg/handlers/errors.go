@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/tracing"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// errorKindStatus maps the kinds of domain errors to HTTP status codes
+var errorKindStatus = map[error]int{
+	usecases.ErrNotFound:     http.StatusNotFound,
+	usecases.ErrConflict:     http.StatusConflict,
+	usecases.ErrUnauthorized: http.StatusUnauthorized,
+	usecases.ErrForbidden:    http.StatusForbidden,
+	usecases.ErrValidation:   http.StatusBadRequest,
+	usecases.ErrGone:         http.StatusGone,
+	usecases.ErrUpstream:     http.StatusBadGateway,
+	usecases.ErrRateLimited:  http.StatusTooManyRequests,
+}
+
+// RespondWithServiceError responds with an error returned by a service as
+// problem details. Domain errors respond with the status of their kind and
+// their code; anything else is logged with action as an internal error. The
+// error is recorded on the span of the request, e.g. the span of the handler.
+func RespondWithServiceError(w http.ResponseWriter, r *http.Request, err error, action string) {
+	tracing.RecordError(trace.SpanFromContext(r.Context()), err)
+
+	// invalid request fields
+	var validationErr *usecases.ValidationError
+	if errors.As(err, &validationErr) {
+		RespondWithProblem(w, r, Problem{
+			Status:        http.StatusBadRequest,
+			Detail:        "The request has invalid fields",
+			Code:          "validation_failed",
+			InvalidParams: validationErr.Fields,
+		})
+		return
+	}
+
+	// domain errors with a code of their own
+	var domainErr *usecases.Error
+	if errors.As(err, &domainErr) {
+		status := errorKindStatus[domainErr.Kind]
+		message := domainErr.Message
+		if status >= http.StatusInternalServerError {
+			// logged with its cause
+			message = fmt.Sprintf("%s: %v", action, err)
+		}
+		RespondWithErrorCode(w, r, status, domainErr.Code, message)
+		return
+	}
+
+	// repository errors
+	for kind, status := range errorKindStatus {
+		if errors.Is(err, kind) {
+			RespondWithError(w, r, status, http.StatusText(status))
+			return
+		}
+	}
+
+	RespondWithError(w, r, http.StatusInternalServerError, fmt.Sprintf("%s: %v", action, err))
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+)
+
+type HealthHandler struct {
+	healthService *usecases.HealthService
+}
+
+func NewHealthHandler(healthService *usecases.HealthService) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// Liveness responds as long as the process is able to serve requests at all
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	RespondWithJSON(w, http.StatusOK, map[string]string{"status": usecases.HealthStatusOK})
+}
+
+// Readiness responds with the outcome of each health check, with 503 Service
+// Unavailable when one fails or the server is shutting down
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := h.healthService.Readiness(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	RespondWithJSON(w, status, report)
+}
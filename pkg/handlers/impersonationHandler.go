@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+)
+
+type ImpersonationHandler struct {
+	impersonationService *usecases.ImpersonationService
+	userService          *usecases.UserService
+}
+
+func NewImpersonationHandler(impersonationService *usecases.ImpersonationService, userService *usecases.UserService) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+		userService:          userService,
+	}
+}
+
+// Impersonate responds with a short-lived access token acting as the user
+func (h *ImpersonationHandler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Email  string `json:"email" validate:"required,email"`
+		Reason string `json:"reason" validate:"required,max=255"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// get user id
+	user, err := h.userService.GetUserByEmail(r.Context(), params.Email)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch user details")
+		return
+	}
+
+	// impersonate user
+	impersonation, err := h.impersonationService.Impersonate(r.Context(), user.ID, params.Reason)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to impersonate user")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	RespondWithJSON(w, http.StatusCreated, impersonation)
+}
+
+// GetMyImpersonations lists the times administrators impersonated the caller
+// and what they did while doing so
+func (h *ImpersonationHandler) GetMyImpersonations(w http.ResponseWriter, r *http.Request) {
+	impersonations, err := h.impersonationService.GetMyImpersonations(r.Context())
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch impersonations")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, impersonations)
+}
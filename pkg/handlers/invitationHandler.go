@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+	"github.com/google/uuid"
+)
+
+type InvitationHandler struct {
+	invitationService *usecases.InvitationService
+}
+
+func NewInvitationHandler(invitationService *usecases.InvitationService) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+	}
+}
+
+// Organization administration handlers
+
+func (h *InvitationHandler) InviteMember(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Email   string `json:"email" validate:"required,email,max=50"`
+		OrgRole string `json:"org_role" validate:"oneof=admin member"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// invite member
+	invitation, err := h.invitationService.InviteMember(r.Context(), params.Email, params.OrgRole)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to invite member")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, invitation)
+}
+
+func (h *InvitationHandler) ListPendingInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := h.invitationService.ListPendingInvitations(r.Context())
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch invitations")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, invitations)
+}
+
+func (h *InvitationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		InvitationID uuid.UUID `json:"invitation_id" validate:"required"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// revoke invitation
+	err := h.invitationService.RevokeInvitation(r.Context(), params.InvitationID)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to revoke invitation")
+		return
+	}
+
+	RespondWithSuccess(w, http.StatusOK, "Successfully revoked the invitation")
+}
+
+// Invitee handlers
+
+func (h *InvitationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	// Show the invitation to the holder of the link
+	if r.Method == http.MethodGet {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			RespondWithError(w, r, http.StatusBadRequest, "Missing invitation token")
+			return
+		}
+
+		preview, err := h.invitationService.PreviewInvitation(r.Context(), token)
+		if err != nil {
+			RespondWithServiceError(w, r, err, "Failed to fetch invitation")
+			return
+		}
+
+		RespondWithJSON(w, http.StatusOK, preview)
+		return
+	}
+
+	// params
+	var params struct {
+		Token     string `json:"token" validate:"required"`
+		Password  string `json:"password" validate:"maxbytes=72"`
+		FirstName string `json:"first_name" validate:"max=50"`
+		LastName  string `json:"last_name" validate:"max=50"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// accept invitation
+	member, err := h.invitationService.AcceptInvitation(r.Context(), params.Token, params.Password, params.FirstName, params.LastName)
+	if err != nil {
+		// invalid invitations, sign-ups the registration policy refuses and other errors
+		RespondWithServiceError(w, r, err, "Failed to accept invitation")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, member)
+}
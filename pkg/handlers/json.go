@@ -2,26 +2,89 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
 )
 
-func RespondWithError(w http.ResponseWriter, code int, message string) {
-	if code > 499 {
+// Problem is an RFC 7807 problem details response. Code is a stable
+// identifier of the error and InvalidParams lists invalid request fields.
+type Problem struct {
+	Type          string                `json:"type"`
+	Title         string                `json:"title"`
+	Status        int                   `json:"status"`
+	Detail        string                `json:"detail,omitempty"`
+	Code          string                `json:"code"`
+	InvalidParams []usecases.FieldError `json:"invalid_params,omitempty"`
+}
+
+// statusCodes are the codes of errors that have none of their own
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusConflict:              "conflict",
+	http.StatusGone:                  "gone",
+	http.StatusRequestEntityTooLarge: "request_too_large",
+	http.StatusUnprocessableEntity:   "unprocessable_entity",
+	http.StatusTooManyRequests:       "rate_limited",
+	http.StatusInternalServerError:   "internal_error",
+}
+
+// internalErrorDetail replaces the detail of server errors so internal errors are not shown to clients
+const internalErrorDetail = "An internal error occurred"
+
+func RespondWithError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	RespondWithErrorCode(w, r, code, statusCodes[code], message)
+}
+
+func RespondWithErrorCode(w http.ResponseWriter, r *http.Request, code int, errorCode string, message string) {
+	if errorCode == "" {
+		errorCode = statusCodes[code]
+	}
+
+	RespondWithProblem(w, r, Problem{
+		Status: code,
+		Detail: message,
+		Code:   errorCode,
+	})
+}
+
+// RespondWithProblem responds with problem details. The details of server
+// errors are logged with the logger of the request and not sent.
+func RespondWithProblem(w http.ResponseWriter, r *http.Request, problem Problem) {
+	if problem.Status > 499 {
 		// log error
-		log.Printf("ERROR %d: %s", code, message)
+		logging.FromContext(r.Context()).Error("Server error", "status", problem.Status, "detail", problem.Detail)
+		problem.Detail = internalErrorDetail
+	}
+	if problem.Type == "" {
+		problem.Type = "about:blank"
 	}
-	type errorResponse struct {
-		Error string `json:"error"`
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
 	}
 
-	RespondWithJSON(w, code, errorResponse{Error: message})
+	response, err := json.Marshal(problem)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to marshal JSON", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	w.Write(response)
 }
 
 func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Failed to marshal JSON: %v", payload)
+		slog.Error("Failed to marshal JSON", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,526 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+	"github.com/google/uuid"
+)
+
+type OAuthHandler struct {
+	oauthService          *usecases.OAuthService
+	serviceAccountService *usecases.ServiceAccountService
+}
+
+func NewOAuthHandler(
+	oauthService *usecases.OAuthService,
+	serviceAccountService *usecases.ServiceAccountService,
+) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService:          oauthService,
+		serviceAccountService: serviceAccountService,
+	}
+}
+
+// respondWithOAuthError responds with an RFC 6749 error body. Failed client
+// authentication is a 401, other refused requests a 400.
+func respondWithOAuthError(w http.ResponseWriter, r *http.Request, err error) {
+	var oauthErr *usecases.OAuthError
+	if !errors.As(err, &oauthErr) {
+		logging.FromContext(r.Context()).Error("Server error", "status", http.StatusInternalServerError, "error", err)
+		RespondWithJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "server_error",
+		})
+		return
+	}
+
+	status := http.StatusBadRequest
+	if oauthErr.Code == "invalid_client" {
+		status = http.StatusUnauthorized
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+
+	RespondWithJSON(w, status, map[string]string{
+		"error":             oauthErr.Code,
+		"error_description": oauthErr.Description,
+	})
+}
+
+// authenticateClient authenticates the client with HTTP Basic credentials or
+// client_id and client_secret form parameters
+func (h *OAuthHandler) authenticateClient(r *http.Request) (model.OAuthClient, error) {
+	clientId, clientSecret, ok := r.BasicAuth()
+	if ok {
+		// Basic credentials are form-encoded (RFC 6749 section 2.3.1)
+		clientId, _ = url.QueryUnescape(clientId)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientId = r.PostFormValue("client_id")
+		clientSecret = r.PostFormValue("client_secret")
+	}
+
+	return h.oauthService.AuthenticateClient(r.Context(), clientId, clientSecret)
+}
+
+// authorizationRequestFromForm reads the authorization request parameters from the query or the form
+func authorizationRequestFromForm(r *http.Request) model.AuthorizationRequest {
+	return model.AuthorizationRequest{
+		ResponseType:        r.FormValue("response_type"),
+		ClientID:            r.FormValue("client_id"),
+		RedirectURI:         r.FormValue("redirect_uri"),
+		Scope:               r.FormValue("scope"),
+		State:               r.FormValue("state"),
+		CodeChallenge:       r.FormValue("code_challenge"),
+		CodeChallengeMethod: r.FormValue("code_challenge_method"),
+		Nonce:               r.FormValue("nonce"),
+	}
+}
+
+// renderOAuthPage renders one of the authorization pages, with the CSRF token its forms post back
+func renderOAuthPage(w http.ResponseWriter, r *http.Request, status int, page string, data map[string]interface{}) {
+	if data != nil {
+		data["CSRFToken"] = utils.GetCSRFTokenFromContext(r.Context())
+	}
+
+	tmpl, err := template.ParseFiles("pkg/templates/" + page)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to open authorization page")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		RespondWithServiceError(w, r, err, "Failed to render authorization page")
+	}
+}
+
+// Authorization endpoint
+
+// Authorize serves the login and consent pages of the authorization code flow.
+// GET shows the login page, POST handles the login and consent forms.
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		RespondWithError(w, r, http.StatusBadRequest, "Invalid form data")
+		return
+	}
+
+	if r.Method == http.MethodPost && r.PostFormValue("action") == "consent" {
+		h.handleConsent(w, r)
+		return
+	}
+
+	// validate the request
+	client, request, ok := h.validateAuthorizationRequest(w, r, authorizationRequestFromForm(r))
+	if !ok {
+		return
+	}
+
+	// show the login page
+	if r.Method == http.MethodGet {
+		renderOAuthPage(w, r, http.StatusOK, "oauth-login.html", map[string]interface{}{
+			"ClientName": client.Name,
+			"Request":    request,
+		})
+		return
+	}
+
+	// check credentials
+	user, err := h.oauthService.AuthenticateUser(r.Context(), r.PostFormValue("email"), r.PostFormValue("password"))
+	if err != nil {
+		message := "Invalid email or password"
+		if errors.Is(err, usecases.ErrAccountNotActive) {
+			message = "This account is not active"
+		}
+		renderOAuthPage(w, r, http.StatusUnauthorized, "oauth-login.html", map[string]interface{}{
+			"ClientName": client.Name,
+			"Request":    request,
+			"Error":      message,
+		})
+		return
+	}
+
+	// skip the consent page when the user already consented to the scope
+	needsConsent, err := h.oauthService.NeedsConsent(r.Context(), user.ID, request)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to check consent")
+		return
+	}
+
+	if !needsConsent {
+		h.redirectWithCode(w, r, user.ID, request)
+		return
+	}
+
+	ticket, err := utils.GenerateAuthorizationTicket(user.ID, request)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to continue authorization")
+		return
+	}
+
+	renderOAuthPage(w, r, http.StatusOK, "oauth-consent.html", map[string]interface{}{
+		"ClientName": client.Name,
+		"Scopes":     strings.Fields(request.Scope),
+		"Ticket":     ticket,
+	})
+}
+
+// handleConsent handles the answer to the consent page
+func (h *OAuthHandler) handleConsent(w http.ResponseWriter, r *http.Request) {
+	claims, err := utils.VerifyAuthorizationTicket(r.PostFormValue("ticket"))
+	if err != nil {
+		RespondWithError(w, r, http.StatusBadRequest, "The authorization request has expired, please start again")
+		return
+	}
+
+	// the client may have changed since the user logged in
+	_, request, ok := h.validateAuthorizationRequest(w, r, claims.Request)
+	if !ok {
+		return
+	}
+
+	if r.PostFormValue("decision") != "allow" {
+		http.Redirect(w, r, usecases.AuthorizationRedirect(request, url.Values{
+			"error":             {"access_denied"},
+			"error_description": {"The user denied the request"},
+		}), http.StatusFound)
+		return
+	}
+
+	err = h.oauthService.GrantConsent(r.Context(), claims.UserID, request)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to record consent")
+		return
+	}
+
+	h.redirectWithCode(w, r, claims.UserID, request)
+}
+
+// validateAuthorizationRequest validates the request and responds with the
+// error if it is invalid. Errors are only redirected to verified redirect URIs.
+func (h *OAuthHandler) validateAuthorizationRequest(w http.ResponseWriter, r *http.Request, request model.AuthorizationRequest) (model.OAuthClient, model.AuthorizationRequest, bool) {
+	client, redirectURI, err := h.oauthService.ValidateClientRedirect(r.Context(), request.ClientID, request.RedirectURI)
+	if err != nil {
+		var oauthErr *usecases.OAuthError
+		if errors.As(err, &oauthErr) {
+			RespondWithError(w, r, http.StatusBadRequest, oauthErr.Description)
+			return model.OAuthClient{}, model.AuthorizationRequest{}, false
+		}
+		RespondWithServiceError(w, r, err, "Failed to fetch client")
+		return model.OAuthClient{}, model.AuthorizationRequest{}, false
+	}
+	// requests carried over from the login page keep the flag once the URI is resolved
+	if request.RedirectURI == "" {
+		request.RedirectURIOmitted = true
+	}
+	request.RedirectURI = redirectURI
+
+	request, err = h.oauthService.ValidateAuthorizationRequest(client, request)
+	if err != nil {
+		var oauthErr *usecases.OAuthError
+		errors.As(err, &oauthErr)
+		http.Redirect(w, r, usecases.AuthorizationRedirect(request, url.Values{
+			"error":             {oauthErr.Code},
+			"error_description": {oauthErr.Description},
+		}), http.StatusFound)
+		return model.OAuthClient{}, model.AuthorizationRequest{}, false
+	}
+
+	return client, request, true
+}
+
+// redirectWithCode issues an authorization code and redirects the user back to the client
+func (h *OAuthHandler) redirectWithCode(w http.ResponseWriter, r *http.Request, userId uuid.UUID, request model.AuthorizationRequest) {
+	redirectURL, err := h.oauthService.IssueAuthorizationCode(r.Context(), userId, request)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to issue authorization code")
+		return
+	}
+
+	http.Redirect(w, r, redirectURL, http.StatusFound)
+}
+
+// Token, revocation and introspection endpoints
+
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	// token responses must not be cached
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := r.ParseForm(); err != nil {
+		respondWithOAuthError(w, r, &usecases.OAuthError{Code: "invalid_request", Description: "Invalid form data"})
+		return
+	}
+
+	// service accounts authenticate with their own credentials
+	if tokens, ok, err := h.serviceAccountToken(r); ok {
+		if err != nil {
+			respondWithOAuthError(w, r, err)
+			return
+		}
+		RespondWithJSON(w, http.StatusOK, tokens)
+		return
+	}
+
+	// authenticate client
+	client, err := h.authenticateClient(r)
+	if err != nil {
+		respondWithOAuthError(w, r, err)
+		return
+	}
+
+	// issue tokens for the grant
+	var tokens model.TokenResponse
+	switch r.PostFormValue("grant_type") {
+	case model.GrantTypeAuthorizationCode:
+		tokens, err = h.oauthService.ExchangeAuthorizationCode(r.Context(), client,
+			r.PostFormValue("code"), r.PostFormValue("redirect_uri"), r.PostFormValue("code_verifier"))
+	case model.GrantTypeRefreshToken:
+		tokens, err = h.oauthService.RefreshAccessToken(r.Context(), client, r.PostFormValue("refresh_token"), r.PostFormValue("scope"))
+	case model.GrantTypeClientCredentials:
+		tokens, err = h.oauthService.ClientCredentials(r.Context(), client, r.PostFormValue("scope"))
+	default:
+		err = &usecases.OAuthError{Code: "unsupported_grant_type", Description: "The grant type is not supported"}
+	}
+	if err != nil {
+		respondWithOAuthError(w, r, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, tokens)
+}
+
+// serviceAccountToken issues a token to a service account and reports whether
+// the request was a service account's: a JWT bearer grant, or a client
+// credentials grant with a service account client ID or a client assertion
+func (h *OAuthHandler) serviceAccountToken(r *http.Request) (model.TokenResponse, bool, error) {
+	switch r.PostFormValue("grant_type") {
+	case model.GrantTypeJWTBearer:
+		tokens, err := h.serviceAccountService.JWTBearer(r.Context(), r.PostFormValue("assertion"))
+		return tokens, true, err
+	case model.GrantTypeClientCredentials:
+		clientId, clientSecret, ok := r.BasicAuth()
+		if ok {
+			clientId, _ = url.QueryUnescape(clientId)
+			clientSecret, _ = url.QueryUnescape(clientSecret)
+		} else {
+			clientId = r.PostFormValue("client_id")
+			clientSecret = r.PostFormValue("client_secret")
+		}
+
+		clientAssertion := r.PostFormValue("client_assertion")
+		if !utils.IsServiceAccountClientID(clientId) && clientAssertion == "" {
+			return model.TokenResponse{}, false, nil
+		}
+
+		tokens, err := h.serviceAccountService.ClientCredentials(r.Context(), clientId, clientSecret,
+			r.PostFormValue("client_assertion_type"), clientAssertion)
+		return tokens, true, err
+	default:
+		return model.TokenResponse{}, false, nil
+	}
+}
+
+func (h *OAuthHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondWithOAuthError(w, r, &usecases.OAuthError{Code: "invalid_request", Description: "Invalid form data"})
+		return
+	}
+
+	// authenticate client
+	client, err := h.authenticateClient(r)
+	if err != nil {
+		respondWithOAuthError(w, r, err)
+		return
+	}
+
+	// revoke token
+	err = h.oauthService.RevokeToken(r.Context(), client, r.PostFormValue("token"), r.PostFormValue("token_type_hint"))
+	if err != nil {
+		respondWithOAuthError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *OAuthHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondWithOAuthError(w, r, &usecases.OAuthError{Code: "invalid_request", Description: "Invalid form data"})
+		return
+	}
+
+	// authenticate client
+	client, err := h.authenticateClient(r)
+	if err != nil {
+		respondWithOAuthError(w, r, err)
+		return
+	}
+
+	// introspect token
+	introspection, err := h.oauthService.IntrospectToken(r.Context(), client, r.PostFormValue("token"))
+	if err != nil {
+		respondWithOAuthError(w, r, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, introspection)
+}
+
+// OpenID Connect endpoints
+
+func (h *OAuthHandler) OpenIDConfiguration(w http.ResponseWriter, r *http.Request) {
+	RespondWithJSON(w, http.StatusOK, h.oauthService.OpenIDConfiguration())
+}
+
+func (h *OAuthHandler) JSONWebKeySet(w http.ResponseWriter, r *http.Request) {
+	RespondWithJSON(w, http.StatusOK, utils.IDTokenKeySet())
+}
+
+// UserInfo returns the claims about the user of the bearer access token (OpenID Connect Core section 5.3)
+func (h *OAuthHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	// get access token
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="oauth"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := utils.ParseToken(strings.TrimPrefix(authHeader, "Bearer "), true)
+	if err != nil {
+		respondWithBearerError(w, r, &usecases.OAuthError{Code: "invalid_token", Description: "The access token is invalid or has expired"})
+		return
+	}
+
+	// get claims
+	userInfo, err := h.oauthService.UserInfo(r.Context(), claims)
+	if err != nil {
+		respondWithBearerError(w, r, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, userInfo)
+}
+
+// respondWithBearerError responds with an RFC 6750 error in the WWW-Authenticate header
+func respondWithBearerError(w http.ResponseWriter, r *http.Request, err error) {
+	var oauthErr *usecases.OAuthError
+	if !errors.As(err, &oauthErr) {
+		RespondWithServiceError(w, r, err, "Failed to fetch user info")
+		return
+	}
+
+	status := http.StatusUnauthorized
+	if oauthErr.Code == "insufficient_scope" {
+		status = http.StatusForbidden
+	}
+
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="%s", error_description="%s"`, oauthErr.Code, oauthErr.Description))
+	w.WriteHeader(status)
+}
+
+// Logout handles RP-initiated logout. The user is sent back to the client when
+// it registered the post logout redirect URI, and shown a signed out page otherwise.
+func (h *OAuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		RespondWithError(w, r, http.StatusBadRequest, "Invalid form data")
+		return
+	}
+
+	// end the session
+	redirectURL, err := h.oauthService.Logout(r.Context(), model.LogoutRequest{
+		IDTokenHint:           r.FormValue("id_token_hint"),
+		ClientID:              r.FormValue("client_id"),
+		PostLogoutRedirectURI: r.FormValue("post_logout_redirect_uri"),
+		State:                 r.FormValue("state"),
+	})
+	if err != nil {
+		var oauthErr *usecases.OAuthError
+		if errors.As(err, &oauthErr) {
+			RespondWithError(w, r, http.StatusBadRequest, oauthErr.Description)
+			return
+		}
+		RespondWithServiceError(w, r, err, "Failed to log out")
+		return
+	}
+
+	if redirectURL != "" {
+		http.Redirect(w, r, redirectURL, http.StatusFound)
+		return
+	}
+
+	renderOAuthPage(w, r, http.StatusOK, "oauth-logout.html", nil)
+}
+
+// Client registry handlers
+
+func (h *OAuthHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Name         string   `json:"name" validate:"required,max=100"`
+		Public       bool     `json:"public"`
+		RedirectURIs []string `json:"redirect_uris"`
+		GrantTypes   []string `json:"grant_types"`
+		Scopes       []string `json:"scopes"`
+
+		PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// register client
+	registration, err := h.oauthService.CreateClient(r.Context(), params.Name, params.Public, params.RedirectURIs, params.GrantTypes, params.Scopes, params.PostLogoutRedirectURIs)
+	if err != nil {
+		if errors.Is(err, usecases.ErrInvalidClientMetadata) {
+			RespondWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		RespondWithServiceError(w, r, err, "Failed to register client")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, registration)
+}
+
+func (h *OAuthHandler) ListClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.oauthService.ListClients(r.Context())
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch OAuth clients")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, clients)
+}
+
+func (h *OAuthHandler) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		ClientID string `json:"client_id" validate:"required"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// delete client
+	err := h.oauthService.DeleteClient(r.Context(), params.ClientID)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to delete client")
+		return
+	}
+
+	RespondWithSuccess(w, http.StatusOK, "Successfully deleted the client")
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+	"github.com/google/uuid"
+)
+
+type OrganizationHandler struct {
+	orgService  *usecases.OrganizationService
+	userService *usecases.UserService
+}
+
+func NewOrganizationHandler(orgService *usecases.OrganizationService, userService *usecases.UserService) *OrganizationHandler {
+	return &OrganizationHandler{
+		orgService:  orgService,
+		userService: userService,
+	}
+}
+
+// Platform administration handlers
+
+func (h *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Name string `json:"name" validate:"required,max=100"`
+		Slug string `json:"slug" validate:"max=50"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// create organization
+	org, err := h.orgService.CreateOrganization(r.Context(), params.Name, params.Slug)
+	if err != nil {
+		// invalid and taken slugs and other errors
+		RespondWithServiceError(w, r, err, "Failed to create organization")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, org)
+}
+
+func (h *OrganizationHandler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Limit  int32 `json:"limit" validate:"min=0,max=1000"`
+		Offset int32 `json:"offset" validate:"min=0"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	orgs, err := h.orgService.ListOrganizations(r.Context(), pageLimit(params.Limit), params.Offset)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch organizations")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, orgs)
+}
+
+func (h *OrganizationHandler) SetOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		OrgID   uuid.UUID `json:"org_id" validate:"required"`
+		Email   string    `json:"email" validate:"required,email"`
+		OrgRole string    `json:"org_role" validate:"oneof=admin member"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// get user id
+	user, err := h.userService.GetUserByEmail(r.Context(), params.Email)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch user details")
+		return
+	}
+
+	// add or update member
+	member, err := h.orgService.SetMember(r.Context(), params.OrgID, user.ID, params.OrgRole)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to set organization member")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, member)
+}
+
+func (h *OrganizationHandler) RemoveOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		OrgID uuid.UUID `json:"org_id" validate:"required"`
+		Email string    `json:"email" validate:"required,email"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// get user id
+	user, err := h.userService.GetUserByEmail(r.Context(), params.Email)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch user details")
+		return
+	}
+
+	// remove member
+	err = h.orgService.RemoveMember(r.Context(), params.OrgID, user.ID)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to remove organization member")
+		return
+	}
+
+	RespondWithSuccess(w, http.StatusOK, "Successfully removed the user from the organization")
+}
+
+// User accessible handlers
+
+func (h *OrganizationHandler) GetMyOrganizations(w http.ResponseWriter, r *http.Request) {
+	memberships, err := h.orgService.GetUserMemberships(r.Context())
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch organizations")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, memberships)
+}
+
+func (h *OrganizationHandler) SwitchOrganization(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		OrgID uuid.UUID `json:"org_id" validate:"required"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// issue tokens for the organization
+	loginResponse, err := h.userService.SwitchOrganization(r.Context(), params.OrgID)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to switch organization")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, loginResponse)
+}
+
+// Organization administration handlers
+
+func (h *OrganizationHandler) GetOrganizationMembers(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Limit  int32 `json:"limit" validate:"min=0,max=1000"`
+		Offset int32 `json:"offset" validate:"min=0"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	members, err := h.orgService.GetMembers(r.Context(), pageLimit(params.Limit), params.Offset)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch organization members")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, members)
+}
+
+func (h *OrganizationHandler) SuspendOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	h.changeMemberStatus(w, r, h.orgService.SuspendMember, "suspend")
+}
+
+func (h *OrganizationHandler) RecoverOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	h.changeMemberStatus(w, r, h.orgService.RecoverMember, "recover")
+}
+
+// changeMemberStatus decodes the member's email and applies the status change to them
+func (h *OrganizationHandler) changeMemberStatus(w http.ResponseWriter, r *http.Request,
+	change func(ctx context.Context, userId uuid.UUID) (model.User, error), action string) {
+	// params
+	var params struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// get user id
+	user, err := h.userService.GetUserByEmail(r.Context(), params.Email)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch user details")
+		return
+	}
+
+	// change status
+	changedUser, err := change(r.Context(), user.ID)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to "+action+" user")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, changedUser)
+}
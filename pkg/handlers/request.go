@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+)
+
+// MaxRequestBodyBytes caps the size of JSON request bodies
+const MaxRequestBodyBytes = 1 << 20
+
+// DefaultPageSize is the number of records listed when a request sets no limit.
+// Requests can list at most 1000 records, the max of their limit.
+var DefaultPageSize int32 = 100
+
+// e164Pattern matches phone numbers in E.164 format, e.g. +254712345678
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// decodeRequest decodes the JSON body of the request into params and
+// validates it against the `validate` tags of its fields. Bodies larger than
+// MaxRequestBodyBytes and unknown fields are refused. It responds with the
+// error and reports false when the request is invalid.
+//
+// The rules of a `validate` tag are separated by commas:
+//
+//	required    the field must not be empty
+//	email       the field must be an email address
+//	e164        the field must be a phone number in E.164 format
+//	date        the field must be a date in usecases.DateLayout format
+//	min=N       strings must be at least N characters long, numbers at least N
+//	max=N       strings must be at most N characters long, numbers at most N
+//	maxbytes=N  strings must be at most N bytes long, e.g. passwords bcrypt hashes
+//	oneof=a b   the field must be one of the space separated values
+//
+// Rules other than required are not checked on empty fields.
+func decodeRequest(w http.ResponseWriter, r *http.Request, params any) bool {
+	// decode request body
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxRequestBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(params); err != nil {
+		respondWithDecodeError(w, r, err)
+		return false
+	}
+	if decoder.More() {
+		RespondWithError(w, r, http.StatusBadRequest, "Request body must contain a single JSON object")
+		return false
+	}
+
+	// validate fields
+	if err := validateRequest(params); err != nil {
+		RespondWithServiceError(w, r, err, "Failed to validate request")
+		return false
+	}
+
+	return true
+}
+
+// respondWithDecodeError responds with the reason a request body could not be decoded
+func respondWithDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		RespondWithError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must not be larger than %d bytes", maxBytesErr.Limit))
+	case errors.Is(err, io.EOF):
+		RespondWithError(w, r, http.StatusBadRequest, "Request body must not be empty")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		RespondWithError(w, r, http.StatusBadRequest, "Request body is not valid JSON")
+	case errors.As(err, &typeErr):
+		validation := &usecases.ValidationError{}
+		validation.Add(typeErr.Field, "invalid_type", fmt.Sprintf("must be a %s", typeErr.Type))
+		RespondWithServiceError(w, r, validation, "Failed to decode request body")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		validation := &usecases.ValidationError{}
+		validation.Add(field, "unknown_field", "is not a known field")
+		RespondWithServiceError(w, r, validation, "Failed to decode request body")
+	default:
+		RespondWithError(w, r, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	}
+}
+
+// validateRequest checks the fields of params against their `validate` tags
+// and returns a *usecases.ValidationError listing every invalid field
+func validateRequest(params any) error {
+	value := reflect.Indirect(reflect.ValueOf(params))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	validation := &usecases.ValidationError{}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		rules := field.Tag.Get("validate")
+		if rules == "" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		if code, message := validateField(value.Field(i), strings.Split(rules, ",")); code != "" {
+			validation.Add(name, code, message)
+		}
+	}
+
+	return validation.Err()
+}
+
+// validateField checks the value against the rules and returns the code and
+// message of the first rule it breaks
+func validateField(value reflect.Value, rules []string) (string, string) {
+	if value.IsZero() || (value.Kind() == reflect.String && strings.TrimSpace(value.String()) == "") {
+		if slices.Contains(rules, "required") {
+			return "required", "is required"
+		}
+		return "", ""
+	}
+
+	for _, rule := range rules {
+		name, arg, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "email":
+			address, err := mail.ParseAddress(value.String())
+			if err != nil || address.Address != value.String() {
+				return "invalid_email", "must be a valid email address"
+			}
+		case "e164":
+			if !e164Pattern.MatchString(value.String()) {
+				return "invalid_phone_number", "must be a phone number in E.164 format, e.g. +254712345678"
+			}
+		case "date":
+			if _, err := time.Parse(usecases.DateLayout, value.String()); err != nil {
+				return "invalid_date", "must be a date in YYYY-MM-DD format"
+			}
+		case "min":
+			limit, _ := strconv.Atoi(arg)
+			if length(value) < limit {
+				return "too_short", "must be at least " + describeLimit(value, limit)
+			}
+		case "max":
+			limit, _ := strconv.Atoi(arg)
+			if length(value) > limit {
+				return "too_long", "must be at most " + describeLimit(value, limit)
+			}
+		case "maxbytes":
+			limit, _ := strconv.Atoi(arg)
+			if len(value.String()) > limit {
+				return "too_long", fmt.Sprintf("must be at most %d bytes long", limit)
+			}
+		case "oneof":
+			if !slices.Contains(strings.Fields(arg), value.String()) {
+				return "invalid_choice", fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(arg), ", "))
+			}
+		}
+	}
+
+	return "", ""
+}
+
+// length is the number of characters of strings, the number of elements of
+// slices and the value of numbers
+func length(value reflect.Value) int {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String())
+	case reflect.Slice, reflect.Map:
+		return value.Len()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(value.Int())
+	default:
+		return 0
+	}
+}
+
+// describeLimit describes a min or max limit of the value
+func describeLimit(value reflect.Value, limit int) string {
+	switch value.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("%d characters long", limit)
+	case reflect.Slice, reflect.Map:
+		return fmt.Sprintf("%d items", limit)
+	default:
+		return strconv.Itoa(limit)
+	}
+}
+
+// pageLimit returns the limit of a listing, or DefaultPageSize when the request set none
+func pageLimit(limit int32) int32 {
+	if limit == 0 {
+		return DefaultPageSize
+	}
+
+	return limit
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+)
+
+// validatedParams has a field for every rule of validate tags
+type validatedParams struct {
+	Email       string   `json:"email" validate:"required,email"`
+	PhoneNumber string   `json:"phone_number" validate:"e164"`
+	DateOfBirth string   `json:"date_of_birth" validate:"date"`
+	Name        string   `json:"name" validate:"min=2,max=5"`
+	Password    string   `json:"password" validate:"maxbytes=72"`
+	Gender      string   `json:"gender" validate:"oneof=male female"`
+	Scopes      []string `json:"scopes" validate:"max=2"`
+	Limit       int32    `json:"limit" validate:"min=0,max=1000"`
+	Untagged    string
+}
+
+// validParams returns params breaking no rule
+func validParams() validatedParams {
+	return validatedParams{
+		Email:       "jane@example.com",
+		PhoneNumber: "+254712345678",
+		DateOfBirth: "1990-01-31",
+		Name:        "Jane",
+		Password:    "correct horse battery staple",
+		Gender:      "female",
+		Scopes:      []string{"users:read"},
+		Limit:       100,
+	}
+}
+
+// fieldCodes returns the codes of the invalid fields of a validation error by field
+func fieldCodes(t *testing.T, err error) map[string]string {
+	t.Helper()
+	codes := map[string]string{}
+	if err == nil {
+		return codes
+	}
+
+	var validationErr *usecases.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("got error %v, want a *usecases.ValidationError", err)
+	}
+	for _, field := range validationErr.Fields {
+		codes[field.Field] = field.Code
+	}
+	return codes
+}
+
+func TestValidateRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(params *validatedParams)
+		codes  map[string]string
+	}{
+		{
+			name:   "valid",
+			modify: func(params *validatedParams) {},
+		},
+		{
+			name: "optional fields left empty",
+			modify: func(params *validatedParams) {
+				*params = validatedParams{Email: params.Email}
+			},
+		},
+		{
+			name:   "required field missing",
+			modify: func(params *validatedParams) { params.Email = "" },
+			codes:  map[string]string{"email": "required"},
+		},
+		{
+			name:   "required field blank",
+			modify: func(params *validatedParams) { params.Email = "   " },
+			codes:  map[string]string{"email": "required"},
+		},
+		{
+			name:   "email with a display name",
+			modify: func(params *validatedParams) { params.Email = "Jane <jane@example.com>" },
+			codes:  map[string]string{"email": "invalid_email"},
+		},
+		{
+			name:   "phone number without country code",
+			modify: func(params *validatedParams) { params.PhoneNumber = "0712345678" },
+			codes:  map[string]string{"phone_number": "invalid_phone_number"},
+		},
+		{
+			name:   "date in another format",
+			modify: func(params *validatedParams) { params.DateOfBirth = "31/01/1990" },
+			codes:  map[string]string{"date_of_birth": "invalid_date"},
+		},
+		{
+			name:   "string too short",
+			modify: func(params *validatedParams) { params.Name = "J" },
+			codes:  map[string]string{"name": "too_short"},
+		},
+		{
+			name:   "string too long",
+			modify: func(params *validatedParams) { params.Name = "Janet Doe" },
+			codes:  map[string]string{"name": "too_long"},
+		},
+		{
+			name:   "max counts characters, not bytes",
+			modify: func(params *validatedParams) { params.Name = "Zoë Ö" },
+		},
+		{
+			name:   "password of 72 bytes",
+			modify: func(params *validatedParams) { params.Password = strings.Repeat("a", 72) },
+		},
+		{
+			name:   "password of 73 bytes",
+			modify: func(params *validatedParams) { params.Password = strings.Repeat("a", 73) },
+			codes:  map[string]string{"password": "too_long"},
+		},
+		{
+			name:   "password of 37 characters taking 74 bytes",
+			modify: func(params *validatedParams) { params.Password = strings.Repeat("é", 37) },
+			codes:  map[string]string{"password": "too_long"},
+		},
+		{
+			name:   "value not one of the choices",
+			modify: func(params *validatedParams) { params.Gender = "unknown" },
+			codes:  map[string]string{"gender": "invalid_choice"},
+		},
+		{
+			name:   "too many items",
+			modify: func(params *validatedParams) { params.Scopes = []string{"a", "b", "c"} },
+			codes:  map[string]string{"scopes": "too_long"},
+		},
+		{
+			name:   "negative number",
+			modify: func(params *validatedParams) { params.Limit = -1 },
+			codes:  map[string]string{"limit": "too_short"},
+		},
+		{
+			name:   "number too large",
+			modify: func(params *validatedParams) { params.Limit = 1001 },
+			codes:  map[string]string{"limit": "too_long"},
+		},
+		{
+			name: "every invalid field is listed",
+			modify: func(params *validatedParams) {
+				params.Email = "jane"
+				params.Name = "J"
+				params.Limit = 5000
+			},
+			codes: map[string]string{"email": "invalid_email", "name": "too_short", "limit": "too_long"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := validParams()
+			test.modify(&params)
+
+			codes := fieldCodes(t, validateRequest(&params))
+			if len(codes) != len(test.codes) {
+				t.Fatalf("got invalid fields %v, want %v", codes, test.codes)
+			}
+			for field, code := range test.codes {
+				if codes[field] != code {
+					t.Errorf("got code %q for %s, want %q", codes[field], field, code)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		ok     bool
+		status int
+		code   string
+	}{
+		{name: "valid", body: `{"email": "jane@example.com", "limit": 10}`, ok: true},
+		{name: "empty body", body: ``, status: http.StatusBadRequest, code: "bad_request"},
+		{name: "invalid JSON", body: `{"email": `, status: http.StatusBadRequest, code: "bad_request"},
+		{name: "several objects", body: `{"email": "jane@example.com"} {}`, status: http.StatusBadRequest, code: "bad_request"},
+		{name: "unknown field", body: `{"email": "jane@example.com", "admin": true}`, status: http.StatusBadRequest, code: "validation_failed"},
+		{name: "wrong type", body: `{"email": "jane@example.com", "limit": "ten"}`, status: http.StatusBadRequest, code: "validation_failed"},
+		{name: "invalid field", body: `{"email": "jane"}`, status: http.StatusBadRequest, code: "validation_failed"},
+		{name: "too large", body: `{"email": "` + strings.Repeat("a", MaxRequestBodyBytes) + `"}`, status: http.StatusRequestEntityTooLarge, code: "request_too_large"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+
+			var params validatedParams
+			ok := decodeRequest(w, r, &params)
+			if ok != test.ok {
+				t.Fatalf("got ok %v, want %v", ok, test.ok)
+			}
+			if ok {
+				return
+			}
+
+			var problem Problem
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatalf("decoding problem: %v", err)
+			}
+			if w.Code != test.status || problem.Code != test.code {
+				t.Errorf("got %d %s, want %d %s", w.Code, problem.Code, test.status, test.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+	"github.com/google/uuid"
+)
+
+type RoleHandler struct {
+	roleService *usecases.RoleService
+	userService *usecases.UserService
+}
+
+func NewRoleHandler(roleService *usecases.RoleService, userService *usecases.UserService) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+		userService: userService,
+	}
+}
+
+func (h *RoleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.roleService.ListRoles(r.Context())
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch roles")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, roles)
+}
+
+func (h *RoleHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, err := h.roleService.ListPermissions(r.Context())
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch permissions")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, permissions)
+}
+
+func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Name        string   `json:"name" validate:"required,max=50"`
+		Description string   `json:"description"`
+		Rank        int32    `json:"rank"`
+		Permissions []string `json:"permissions"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// create role
+	role, err := h.roleService.CreateRole(r.Context(), params.Name, params.Description, params.Rank, params.Permissions)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to create role")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, role)
+}
+
+func (h *RoleHandler) RenameRole(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Name        string `json:"name" validate:"required"`
+		NewName     string `json:"new_name" validate:"max=50"`
+		Description string `json:"description"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// rename role
+	role, err := h.roleService.RenameRole(r.Context(), params.Name, params.NewName, params.Description)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to rename role")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, role)
+}
+
+func (h *RoleHandler) SetRolePermissions(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Name        string   `json:"name" validate:"required"`
+		Permissions []string `json:"permissions"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// update permissions
+	role, err := h.roleService.SetRolePermissions(r.Context(), params.Name, params.Permissions)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to update role permissions")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, role)
+}
+
+func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// delete role
+	if err := h.roleService.DeleteRole(r.Context(), params.Name); err != nil {
+		RespondWithServiceError(w, r, err, "Failed to delete role")
+		return
+	}
+
+	RespondWithSuccess(w, http.StatusOK, "Successfully deleted the role")
+}
+
+func (h *RoleHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// get user id
+	user, err := h.userService.GetUserByEmail(r.Context(), params.Email)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch user details")
+		return
+	}
+
+	roles, err := h.roleService.GetUserRoles(r.Context(), user.ID)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch user roles")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, roles)
+}
+
+func (h *RoleHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	h.changeRole(w, r, h.roleService.AssignRole)
+}
+
+func (h *RoleHandler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	h.changeRole(w, r, h.roleService.RevokeRole)
+}
+
+// changeRole decodes a role change request and applies it with change
+func (h *RoleHandler) changeRole(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, userId uuid.UUID, role string) (model.User, error)) {
+	// params
+	var params struct {
+		Email string `json:"email" validate:"required,email"`
+		Role  string `json:"role" validate:"required"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// get user id
+	user, err := h.userService.GetUserByEmail(r.Context(), params.Email)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch user details")
+		return
+	}
+
+	// change role
+	updatedUser, err := change(r.Context(), user.ID, params.Role)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to change user role")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, updatedUser)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+	"github.com/google/uuid"
+)
+
+type ServiceAccountHandler struct {
+	serviceAccountService *usecases.ServiceAccountService
+}
+
+func NewServiceAccountHandler(serviceAccountService *usecases.ServiceAccountService) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		serviceAccountService: serviceAccountService,
+	}
+}
+
+// CreateServiceAccount responds with the service account and, unless it was
+// given a public key, its client secret, which is not shown again
+func (h *ServiceAccountHandler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Name        string     `json:"name" validate:"required,max=50"`
+		Description string     `json:"description" validate:"max=255"`
+		OrgID       *uuid.UUID `json:"org_id"`
+		Permissions []string   `json:"permissions"`
+		PublicKey   string     `json:"public_key"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// create service account
+	credentials, err := h.serviceAccountService.CreateServiceAccount(r.Context(), params.Name, params.Description,
+		params.OrgID, params.Permissions, params.PublicKey)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to create service account")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, credentials)
+}
+
+func (h *ServiceAccountHandler) ListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	serviceAccounts, err := h.serviceAccountService.ListServiceAccounts(r.Context())
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch service accounts")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, serviceAccounts)
+}
+
+func (h *ServiceAccountHandler) UpdateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		ID          uuid.UUID `json:"id" validate:"required"`
+		Name        string    `json:"name" validate:"max=50"`
+		Description string    `json:"description" validate:"max=255"`
+		Permissions []string  `json:"permissions"`
+		PublicKey   string    `json:"public_key"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// update service account
+	serviceAccount, err := h.serviceAccountService.UpdateServiceAccount(r.Context(), params.ID, params.Name,
+		params.Description, params.Permissions, params.PublicKey)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to update service account")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, serviceAccount)
+}
+
+// RotateServiceAccountSecret responds with the new client secret, which is not shown again
+func (h *ServiceAccountHandler) RotateServiceAccountSecret(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		ID uuid.UUID `json:"id" validate:"required"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// rotate secret
+	credentials, err := h.serviceAccountService.RotateServiceAccountSecret(r.Context(), params.ID)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to rotate service account secret")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, credentials)
+}
+
+func (h *ServiceAccountHandler) DeleteServiceAccount(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		ID uuid.UUID `json:"id" validate:"required"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// delete service account
+	err := h.serviceAccountService.DeleteServiceAccount(r.Context(), params.ID)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to delete service account")
+		return
+	}
+
+	RespondWithSuccess(w, http.StatusOK, "Successfully deleted the service account")
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+	"github.com/gorilla/mux"
+)
+
+// socialLoginCookie keeps the social login ticket in the browser between the login and callback requests
+const socialLoginCookie = "social_login"
+
+type SocialLoginHandler struct {
+	socialLoginService *usecases.SocialLoginService
+}
+
+func NewSocialLoginHandler(socialLoginService *usecases.SocialLoginService) *SocialLoginHandler {
+	return &SocialLoginHandler{
+		socialLoginService: socialLoginService,
+	}
+}
+
+// Sign in handlers
+
+func (h *SocialLoginHandler) ListProviders(w http.ResponseWriter, r *http.Request) {
+	RespondWithJSON(w, http.StatusOK, map[string][]string{
+		"providers": h.socialLoginService.Providers(),
+	})
+}
+
+// Login sends the user to the identity provider, keeping the state of the sign in in a cookie
+func (h *SocialLoginHandler) Login(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+
+	authURL, ticket, err := h.socialLoginService.StartLogin(r.Context(), provider, r.URL.Query().Get("link_ticket"))
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to start sign in with "+provider)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     socialLoginCookie,
+		Value:    ticket,
+		Path:     "/auth/" + provider + "/callback",
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback completes the sign in, signing the user in or linking the identity
+func (h *SocialLoginHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+	query := r.URL.Query()
+
+	// the ticket can only be used once
+	cookie, err := r.Cookie(socialLoginCookie)
+	if err != nil {
+		RespondWithServiceError(w, r, usecases.ErrInvalidSocialLoginState, "Failed to complete sign in")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:   socialLoginCookie,
+		Path:   "/auth/" + provider + "/callback",
+		MaxAge: -1,
+	})
+
+	if providerErr := query.Get("error"); providerErr != "" {
+		RespondWithError(w, r, http.StatusBadRequest, fmt.Sprintf("Sign in with %s failed: %s %s", provider, providerErr, query.Get("error_description")))
+		return
+	}
+
+	// get identity
+	identity, ticket, err := h.socialLoginService.CompleteLogin(r.Context(), provider, cookie.Value, query.Get("state"), query.Get("code"))
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to complete sign in with "+provider)
+		return
+	}
+
+	// link identity
+	if ticket.LinkUserID != nil {
+		linkedIdentity, err := h.socialLoginService.LinkIdentity(r.Context(), *ticket.LinkUserID, identity)
+		if err != nil {
+			RespondWithServiceError(w, r, err, "Failed to link identity")
+			return
+		}
+
+		RespondWithJSON(w, http.StatusOK, linkedIdentity)
+		return
+	}
+
+	// sign in
+	loginResponse, err := h.socialLoginService.SignIn(r.Context(), identity)
+	if err != nil {
+		// sign-ups the registration policy refuses and other errors
+		RespondWithServiceError(w, r, err, "Failed to sign in")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, loginResponse)
+}
+
+// Profile handlers
+
+func (h *SocialLoginHandler) GetIdentities(w http.ResponseWriter, r *http.Request) {
+	identities, err := h.socialLoginService.GetIdentities(r.Context())
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to fetch identities")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, identities)
+}
+
+// LinkIdentity returns the URL to open in the browser to link an identity at the provider
+func (h *SocialLoginHandler) LinkIdentity(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Provider string `json:"provider" validate:"required"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// create link ticket
+	linkTicket, err := h.socialLoginService.CreateLinkTicket(r.Context(), params.Provider)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to start linking")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]string{
+		"link_url": "/auth/" + url.PathEscape(params.Provider) + "/login?link_ticket=" + url.QueryEscape(linkTicket),
+	})
+}
+
+func (h *SocialLoginHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	// params
+	var params struct {
+		Provider string `json:"provider" validate:"required"`
+	}
+
+	// decode request body
+	if !decodeRequest(w, r, &params) {
+		return
+	}
+
+	// unlink identity
+	err := h.socialLoginService.UnlinkIdentity(r.Context(), params.Provider)
+	if err != nil {
+		RespondWithServiceError(w, r, err, "Failed to unlink identity")
+		return
+	}
+
+	RespondWithSuccess(w, http.StatusOK, "Successfully unlinked the identity")
+}
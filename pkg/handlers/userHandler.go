@@ -1,15 +1,13 @@
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
-	"log"
-	"strings"
 
 	"html/template"
 	"net/http"
 
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/tracing"
 	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
 )
 
 type UserHandler struct {
@@ -25,39 +23,28 @@ func NewUserHandler(userService *usecases.UserService) *UserHandler {
 // User accessible handlers
 
 func (h *UserHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
+	r, span := tracing.StartRequest(r, "UserHandler.RegisterUser")
+	defer span.End()
+
 	// params
 	var params struct {
-		Email     string `json:"email"`
-		Password  string `json:"password"`
-		FirstName string `json:"first_name"`
-		LastName  string `json:"last_name"`
-		UserRole  string `json:"user_role"`
+		Email     string `json:"email" validate:"required,email,max=50"`
+		Password  string `json:"password" validate:"required,maxbytes=72"`
+		FirstName string `json:"first_name" validate:"required,max=50"`
+		LastName  string `json:"last_name" validate:"required,max=50"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
-	// create user
-	user, err := h.userService.CreateUser(r.Context(), params.Email,
-		params.Password, params.FirstName, params.LastName, params.UserRole)
+	// register user with the default role
+	user, err := h.userService.RegisterUser(r.Context(), params.Email,
+		params.Password, params.FirstName, params.LastName)
 	if err != nil {
-		// User email already exist in the database
-		if strings.Contains(err.Error(), "pq: duplicate key value violates unique constraint \"users_email_key\""){
-			RespondWithError(w, http.StatusBadRequest, "User email already exists")
-			return
-		}
-
-		// User username(firstname+lastname) already exists in the database
-		if strings.Contains(err.Error(), "pq: duplicate key value violates unique constraint \"users_username_key\""){
-			RespondWithError(w, http.StatusBadRequest, "User firstname and lastname combination already exists")
-			return
-		}
-
-		// Other errors
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create user: %v", err))
+		// closed registration, refused email domains, taken emails and usernames and other errors
+		RespondWithServiceError(w, r, err, "Failed to create user")
 		return
 	}
 
@@ -66,36 +53,25 @@ func (h *UserHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
+	r, span := tracing.StartRequest(r, "UserHandler.LoginUser")
+	defer span.End()
+
 	// params
 	var params struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email    string `json:"email" validate:"required"`
+		Password string `json:"password" validate:"required"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
 	// login user
 	user, err := h.userService.LoginUser(r.Context(), params.Email, params.Password)
 	if err != nil {
-		// Check for specific error cases and return corresponding status codes
-		// For non-existing user
-		if strings.Contains(err.Error(), "sql: no rows in result set"){
-			RespondWithError(w, http.StatusNotFound, "User not found")
-			return
-		}
-
-		// for incorrect passwords
-		if strings.Contains(err.Error(), "crypto/bcrypt: hashedPassword is not the hash of the given password"){
-			RespondWithError(w, http.StatusUnauthorized, "Incorrect password")
-			return
-		}
-
-		// Other errors
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to login user: %v", err))
+		// unknown emails and incorrect passwords are both invalid credentials
+		RespondWithServiceError(w, r, err, "Failed to login user")
 		return
 	}
 
@@ -104,21 +80,23 @@ func (h *UserHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	r, span := tracing.StartRequest(r, "UserHandler.RefreshToken")
+	defer span.End()
+
 	// params
 	var params struct {
-		RefreshToken string `json:"refresh_token"`
+		RefreshToken string `json:"refresh_token" validate:"required"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
 	// refresh token
 	user, err := h.userService.RefreshToken(r.Context(), params.RefreshToken)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to refresh token: %v", err))
+		RespondWithServiceError(w, r, err, "Failed to refresh token")
 		return
 	}
 
@@ -127,19 +105,21 @@ func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	r, span := tracing.StartRequest(r, "UserHandler.UpdateUser")
+	defer span.End()
+
 	// params
 	var params struct {
-		Email       string `json:"email"`
-		FirstName   string `json:"first_name"`
-		LastName    string `json:"last_name"`
-		PhoneNumber string `json:"phone_number"`
-		DateOfBirth string `json:"date_of_birth"`
-		Gender      string `json:"gender"`
+		Email       string `json:"email" validate:"email,max=50"`
+		FirstName   string `json:"first_name" validate:"max=50"`
+		LastName    string `json:"last_name" validate:"max=50"`
+		PhoneNumber string `json:"phone_number" validate:"e164"`
+		DateOfBirth string `json:"date_of_birth" validate:"date"`
+		Gender      string `json:"gender" validate:"oneof=male female other"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
@@ -147,7 +127,7 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	user, err := h.userService.UpdateUser(r.Context(), params.Email, params.FirstName, params.LastName,
 		params.PhoneNumber, params.Gender, params.DateOfBirth)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update user: %v", err))
+		RespondWithServiceError(w, r, err, "Failed to update user")
 		return
 	}
 
@@ -156,21 +136,23 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) UpdateProfilePicture(w http.ResponseWriter, r *http.Request) {
+	r, span := tracing.StartRequest(r, "UserHandler.UpdateProfilePicture")
+	defer span.End()
+
 	// params
 	var params struct {
-		ProfilePicture string `json:"profile_picture"`
+		ProfilePicture string `json:"profile_picture" validate:"required"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
 	// update user
 	user, err := h.userService.UpdateProfilePicture(r.Context(), params.ProfilePicture)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update profile picture: %v", err))
+		RespondWithServiceError(w, r, err, "Failed to update profile picture")
 		return
 	}
 
@@ -179,20 +161,22 @@ func (h *UserHandler) UpdateProfilePicture(w http.ResponseWriter, r *http.Reques
 }
 
 func (h *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	r, span := tracing.StartRequest(r, "UserHandler.RequestPasswordReset")
+	defer span.End()
+
 	// params
 	var params struct {
-		Email string `json:"email"`
+		Email string `json:"email" validate:"required,email"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
 	// send reset password email
 	if err := h.userService.SendResetPasswordEmail(r.Context(), params.Email); err != nil {
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to send reset password email: %v", err))
+		RespondWithServiceError(w, r, err, "Failed to send reset password email")
 		return
 	}
 
@@ -201,28 +185,32 @@ func (h *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Reques
 }
 
 func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	r, span := tracing.StartRequest(r, "UserHandler.ResetPassword")
+	defer span.End()
+
 	if r.Method == http.MethodGet {
-		// Serve the reset password form with the token embedded as a hidden input
+		// Serve the reset password form with the token and CSRF token embedded as hidden inputs
 		token := r.URL.Query().Get("token")
 		if token == "" {
-			RespondWithError(w, http.StatusBadRequest, "Token is required")
+			RespondWithError(w, r, http.StatusBadRequest, "Token is required")
 			return
 		}
 
 		w.Header().Set("Content-Type", "text/html")
 		tmpl := template.Must(template.ParseFiles("pkg/templates/reset-password.html"))
 		data := map[string]interface{}{
-			"Token": token,
+			"Token":     token,
+			"CSRFToken": utils.GetCSRFTokenFromContext(r.Context()),
 		}
 		if err := tmpl.Execute(w, data); err != nil {
-			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to open reset password page: %v", err))
+			RespondWithServiceError(w, r, err, "Failed to open reset password page")
 			return
 		}
 	} else if r.Method == http.MethodPost {
 		// Handle form submission
 		err := r.ParseForm()
 		if err != nil {
-			RespondWithError(w, http.StatusBadRequest, "Invalid form data")
+			RespondWithError(w, r, http.StatusBadRequest, "Invalid form data")
 			return
 		}
 		token := r.FormValue("token")
@@ -231,17 +219,17 @@ func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 
 		// Validate the inputs
 		if token == "" {
-			RespondWithError(w, http.StatusBadRequest, "Token is required")
+			RespondWithError(w, r, http.StatusBadRequest, "Token is required")
 			return
 		}
 		if password != confirmPassword {
-			RespondWithError(w, http.StatusBadRequest, "Passwords do not match")
+			RespondWithError(w, r, http.StatusBadRequest, "Passwords do not match")
 			return
 		}
 
 		// Reset password logic...
 		if err := h.userService.ResetPassword(r.Context(), token, password); err != nil {
-			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to reset password: %v", err))
+			RespondWithServiceError(w, r, err, "Failed to reset password")
 			return
 		}
 
@@ -250,251 +238,161 @@ func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// Admin accessible handlers
+// magicLinkBindingCookie keeps the browser binding secret of a magic link in the browser that asked for it
+const magicLinkBindingCookie = "magic_link_binding"
 
-func (h *UserHandler) PromoteUserToAdmin(w http.ResponseWriter, r *http.Request){
-		// params
-		var params struct {
-			Email     string `json:"email"`
-		}
-	
-		// decode request body
-		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-			RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
-			return
-		}
-	
-		// get user id
-		user, err := h.userService.GetUserByEmail(r.Context(), params.Email)
+func (h *UserHandler) RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	r, span := tracing.StartRequest(r, "UserHandler.RequestMagicLink")
+	defer span.End()
 
-		// check if the if the user is already an admin
-		if user.UserRole == "admin"{
-			RespondWithError(w, http.StatusBadRequest,"User already an admin")
-			return
-		}
-
-		// Error handling
-		if err != nil {
-			// For non-existing user
-			if strings.Contains(err.Error(), "sql: no rows in result set"){
-				RespondWithError(w, http.StatusNotFound, "User not found")
-				return
-			}
-
-			// Other errors
-			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to promote user: %v", err))
-			return
-		}
-
-		// promote user
-		updatedUser, err := h.userService.PromoteUserToAdmin(r.Context(), user.ID)
-		if err != nil {
-			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to promote user to admin status : %v", err))
-			return
-		}
-	
-		// respond with promoted user
-		RespondWithJSON(w, http.StatusOK, updatedUser)
-}
-
-func (h *UserHandler) PromoteUserToSuperAdmin(w http.ResponseWriter, r *http.Request){
 	// params
 	var params struct {
-		Email     string `json:"email"`
+		Email       string `json:"email" validate:"required,email"`
+		BindBrowser bool   `json:"bind_browser"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
-		return
-	}
-
-	// get user id
-	user, err := h.userService.GetUserByEmail(r.Context(), params.Email)
-
-	// check if the if the user is already an admin
-	if user.UserRole == "superadmin"{
-		RespondWithError(w, http.StatusBadRequest,"User already a super admin")
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
+	// send magic link
+	bindingSecret, err := h.userService.SendMagicLink(r.Context(), params.Email, params.BindBrowser)
 	if err != nil {
-		// For non-existing user
-		if strings.Contains(err.Error(), "sql: no rows in result set"){
-			RespondWithError(w, http.StatusNotFound, "User not found")
-			return
-		}
-
-		// Other errors
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to promote user: %v", err))
+		RespondWithServiceError(w, r, err, "Failed to send login link")
 		return
 	}
 
-	// promote user
-	updatedUser, err := h.userService.PromoteUserToSuperAdmin(r.Context(), user.ID)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to promote user to super admin status : %v", err))
-		return
+	// bind the link to this browser
+	if bindingSecret != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     magicLinkBindingCookie,
+			Value:    bindingSecret,
+			Path:     "/magic-link",
+			MaxAge:   900,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
 	}
 
-	// respond with promoted user
-	RespondWithJSON(w, http.StatusOK, updatedUser)
+	// respond with the same message whether or not the email has an account
+	RespondWithSuccess(w, http.StatusOK, "If the email has an account, a login link has been sent to it")
 }
 
-func (h *UserHandler) DemoteSuperAdminToAdmin(w http.ResponseWriter, r *http.Request){
-	// params
-	var params struct {
-		Email     string `json:"email"`
-	}
-
-	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
-		return
-	}
-
-	// get superadmin id
-	superAdmin, err := h.userService.GetUserByEmail(r.Context(), params.Email)
+func (h *UserHandler) MagicLink(w http.ResponseWriter, r *http.Request) {
+	r, span := tracing.StartRequest(r, "UserHandler.MagicLink")
+	defer span.End()
 
-	
-	if err != nil {
-		// For non-existing user
-		if strings.Contains(err.Error(), "sql: no rows in result set"){
-			RespondWithError(w, http.StatusNotFound, "User not found")
+	if r.Method == http.MethodGet {
+		// Serve a confirmation page so that link scanners fetching the link do not use it up
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			RespondWithError(w, r, http.StatusBadRequest, "Token is required")
 			return
 		}
 
-		// Other errors
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to promote user: %v", err))
-		return
-	}
-
-	// Check if user is a superadmin
-	if superAdmin.UserRole != "superadmin"{
-		RespondWithError(w, http.StatusBadRequest,"User is not a super administrator")
-		return
-	}
-
-	// demote superadmin to admin
-	admin, err := h.userService.DemoteSuperAdminToAdmin(r.Context(), superAdmin.ID)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to demote superadmin to admin status : %v", err))
-		return
-	}
-
-	// respond with promoted user
-	RespondWithJSON(w, http.StatusOK, admin)
-}
-
-func (h *UserHandler) DemoteSuperAdminToUser(w http.ResponseWriter, r *http.Request){
-	// params
-	var params struct {
-		Email     string `json:"email"`
-	}
+		w.Header().Set("Content-Type", "text/html")
+		tmpl := template.Must(template.ParseFiles("pkg/templates/magic-link.html"))
+		data := map[string]interface{}{
+			"Token":     token,
+			"CSRFToken": utils.GetCSRFTokenFromContext(r.Context()),
+		}
+		if err := tmpl.Execute(w, data); err != nil {
+			RespondWithServiceError(w, r, err, "Failed to open login page")
+			return
+		}
+	} else if r.Method == http.MethodPost {
+		// Handle form submission
+		err := r.ParseForm()
+		if err != nil {
+			RespondWithError(w, r, http.StatusBadRequest, "Invalid form data")
+			return
+		}
+		token := r.FormValue("token")
+		if token == "" {
+			RespondWithError(w, r, http.StatusBadRequest, "Token is required")
+			return
+		}
 
-	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
-		return
-	}
+		// the binding secret, if the link was asked for from this browser
+		var bindingSecret string
+		if cookie, err := r.Cookie(magicLinkBindingCookie); err == nil {
+			bindingSecret = cookie.Value
+		}
 
-	// get superadmin id
-	superAdmin, err := h.userService.GetUserByEmail(r.Context(), params.Email)
-	if err != nil {
-		// For non-existing user
-		if strings.Contains(err.Error(), "sql: no rows in result set"){
-			RespondWithError(w, http.StatusNotFound, "User not found")
+		// login user
+		loginResponse, err := h.userService.LoginWithMagicLink(r.Context(), token, bindingSecret)
+		if err != nil {
+			RespondWithServiceError(w, r, err, "Failed to login user")
 			return
 		}
 
-		// Other errors
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to promote user: %v", err))
-		return
-	}
+		// the binding secret is spent with the link
+		if bindingSecret != "" {
+			http.SetCookie(w, &http.Cookie{
+				Name:   magicLinkBindingCookie,
+				Path:   "/magic-link",
+				MaxAge: -1,
+			})
+		}
 
-	// Check if user is a superadmin
-	if superAdmin.UserRole != "superadmin"{
-		RespondWithError(w, http.StatusBadRequest,"User is not a super administrator")
-		return
+		// respond with user
+		RespondWithJSON(w, http.StatusOK, loginResponse)
 	}
+}
 
-	// demote superadmin to user
-	user, err := h.userService.DemoteSuperAdminToUser(r.Context(), superAdmin.ID)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to demote superadmin to user status : %v", err))
-		return
-	}
+// Admin accessible handlers
 
-	// respond with promoted user
-	RespondWithJSON(w, http.StatusOK, user)
-}
+// CreateUser creates a user with roles chosen by the administrator
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	r, span := tracing.StartRequest(r, "UserHandler.CreateUser")
+	defer span.End()
 
-func (h *UserHandler) DemoteAdminToUser(w http.ResponseWriter, r *http.Request){
 	// params
 	var params struct {
-		Email     string `json:"email"`
+		Email     string   `json:"email" validate:"required,email,max=50"`
+		Password  string   `json:"password" validate:"required,maxbytes=72"`
+		FirstName string   `json:"first_name" validate:"required,max=50"`
+		LastName  string   `json:"last_name" validate:"required,max=50"`
+		Roles     []string `json:"roles"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
-	// get admin id
-	admin, err := h.userService.GetUserByEmail(r.Context(), params.Email)
-	if err != nil {
-		// For non-existing user
-		if strings.Contains(err.Error(), "sql: no rows in result set"){
-			RespondWithError(w, http.StatusNotFound, "User not found")
-			return
-		}
-
-		// Other errors
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to promote user: %v", err))
-		return
-	}
-
-	// Check if user is a superadmin
-	if admin.UserRole != "admin"{
-		RespondWithError(w, http.StatusBadRequest,"User is not an administrator")
-		return
-	}
-
-	// demote admin to user
-	user, err := h.userService.DemoteAdminToUser(r.Context(), admin.ID)
+	// create user
+	user, err := h.userService.CreateUserWithRoles(r.Context(), params.Email,
+		params.Password, params.FirstName, params.LastName, params.Roles)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to demote admin to user status : %v", err))
+		// roles above the administrator's own, taken emails and usernames and other errors
+		RespondWithServiceError(w, r, err, "Failed to create user")
 		return
 	}
 
-	// respond with promoted user
-	RespondWithJSON(w, http.StatusOK, user)
+	RespondWithJSON(w, http.StatusCreated, user)
 }
 
-
 func (h *UserHandler) SuspendUser(w http.ResponseWriter, r *http.Request){
+	r, span := tracing.StartRequest(r, "UserHandler.SuspendUser")
+	defer span.End()
+
 	// params
 	var params struct {
-		Email     string `json:"email"`
+		Email     string `json:"email" validate:"required,email"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
 	// get user id
 	user, err := h.userService.GetUserByEmail(r.Context(), params.Email)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to fetch user details: %v", err))
-		return
-	}
-
-	// Check if user is suspended
-	if user.AccountStatus == "suspended"{
-		RespondWithError(w, http.StatusBadRequest,"User is already suspended")
+		RespondWithServiceError(w, r, err, "Failed to fetch user details")
 		return
 	}
 
@@ -502,14 +400,8 @@ func (h *UserHandler) SuspendUser(w http.ResponseWriter, r *http.Request){
 	suspendedUser, err := h.userService.SuspendUser(r.Context(), user.ID)
 
 	if err != nil {
-		// For non-existing user
-		if strings.Contains(err.Error(), "sql: no rows in result set"){
-			RespondWithError(w, http.StatusNotFound, "User not found")
-			return
-		}
-
-		// Other errors
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to suspend user: %v", err))
+		// users outside of the caller's organization, already suspended users and other errors
+		RespondWithServiceError(w, r, err, "Failed to suspend user")
 		return
 	}
 
@@ -520,41 +412,31 @@ func (h *UserHandler) SuspendUser(w http.ResponseWriter, r *http.Request){
 }
 
 func (h *UserHandler) RecoverUser(w http.ResponseWriter, r *http.Request){
+		r, span := tracing.StartRequest(r, "UserHandler.RecoverUser")
+		defer span.End()
+
 		// params
 		var params struct {
-			Email     string `json:"email"`
+			Email     string `json:"email" validate:"required,email"`
 		}
 	
 		// decode request body
-		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-			RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+		if !decodeRequest(w, r, &params) {
 			return
 		}
 	
 		// get user id
 		user, err := h.userService.GetUserByEmail(r.Context(), params.Email)
 		if err != nil {
-			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to fetch user details: %v", err))
-			return
-		}
-		
-		// Check if user is recovered
-		if user.AccountStatus == "active"{
-			RespondWithError(w, http.StatusBadRequest,"User account is already recovered")
+			RespondWithServiceError(w, r, err, "Failed to fetch user details")
 			return
 		}
 	
 		// recover user
 		recoveredUser, err := h.userService.RecoverUser(r.Context(), user.ID)
 		if err != nil {
-			// For non-existing user
-			if strings.Contains(err.Error(), "sql: no rows in result set"){
-				RespondWithError(w, http.StatusNotFound, "User not found")
-				return
-			}
-	
-			// Other errors
-			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to recover user: %v", err))
+			// users outside of the caller's organization, active users and other errors
+			RespondWithServiceError(w, r, err, "Failed to recover user")
 			return
 		}
 
@@ -564,41 +446,31 @@ func (h *UserHandler) RecoverUser(w http.ResponseWriter, r *http.Request){
 }
 
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request){
+	r, span := tracing.StartRequest(r, "UserHandler.DeleteUser")
+	defer span.End()
+
 	// params
 	var params struct {
-		Email     string `json:"email"`
+		Email     string `json:"email" validate:"required,email"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
 	// get user id
 	user, err := h.userService.GetUserByEmail(r.Context(), params.Email)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to fetch user details: %v", err))
-		return
-	}
-
-	// Check if user account is deadctivated
-	if user.AccountStatus == "deleted"{
-		RespondWithError(w, http.StatusBadRequest,"User account is already deleted.")
+		RespondWithServiceError(w, r, err, "Failed to fetch user details")
 		return
 	}
 
 	// deactivate user account
 	err = h.userService.DeleteUser(r.Context(), user.ID)
 	if err != nil {
-		// For non-existing user
-		if strings.Contains(err.Error(), "sql: no rows in result set"){
-			RespondWithError(w, http.StatusNotFound, "User not found")
-			return
-		}
-
-		// Other errors
-		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete user: %v", err))
+		// users outside of the caller's organization, deleted users and other errors
+		RespondWithServiceError(w, r, err, "Failed to delete user")
 		return
 	}
 
@@ -610,22 +482,24 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request){
 
 
 func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request){
+	r, span := tracing.StartRequest(r, "UserHandler.GetAllUsers")
+	defer span.End()
+
 
 	// params
 	var params struct {
-		Limit	    int32		`json:"limit"`
-		Offset		int32		`json:"offset"`
+		Limit	    int32		`json:"limit" validate:"min=0,max=1000"`
+		Offset		int32		`json:"offset" validate:"min=0"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
-	users, err := h.userService.GetAllUsers(r.Context(), params.Limit, params.Offset)
+	users, err := h.userService.GetAllUsers(r.Context(), pageLimit(params.Limit), params.Offset)
 	if err != nil{
-		RespondWithError(w, http.StatusInternalServerError, "Error fetching all users from the database.")
+		RespondWithServiceError(w, r, err, "Error fetching all users from the database.")
 		return
 	}
 	if len(users)==0 {
@@ -637,21 +511,23 @@ func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request){
 }
 
 func (h *UserHandler) GetAdminUsers(w http.ResponseWriter, r *http.Request){
+	r, span := tracing.StartRequest(r, "UserHandler.GetAdminUsers")
+	defer span.End()
+
 	// params
 	var params struct {
-		Limit	    int32		`json:"limit"`
-		Offset		int32		`json:"offset"`
+		Limit	    int32		`json:"limit" validate:"min=0,max=1000"`
+		Offset		int32		`json:"offset" validate:"min=0"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
-	admins, err := h.userService.GetAdminUsers(r.Context(), params.Limit, params.Offset)
+	admins, err := h.userService.GetAdminUsers(r.Context(), pageLimit(params.Limit), params.Offset)
 	if err != nil{
-		RespondWithError(w, http.StatusInternalServerError, "Error fetching all administrators from the database.")
+		RespondWithServiceError(w, r, err, "Error fetching all administrators from the database.")
 		return
 	}
 	if len(admins)==0 {
@@ -663,21 +539,23 @@ func (h *UserHandler) GetAdminUsers(w http.ResponseWriter, r *http.Request){
 }
 
 func (h *UserHandler) GetSuperAdminUsers(w http.ResponseWriter, r *http.Request){
+	r, span := tracing.StartRequest(r, "UserHandler.GetSuperAdminUsers")
+	defer span.End()
+
 	// params
 	var params struct {
-		Limit	    int32		`json:"limit"`
-		Offset		int32		`json:"offset"`
+		Limit	    int32		`json:"limit" validate:"min=0,max=1000"`
+		Offset		int32		`json:"offset" validate:"min=0"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
-	superAdmins, err := h.userService.GetSuperAdminUsers(r.Context(), params.Limit, params.Offset)
+	superAdmins, err := h.userService.GetSuperAdminUsers(r.Context(), pageLimit(params.Limit), params.Offset)
 	if err != nil{
-		RespondWithError(w, http.StatusInternalServerError, "Error fetching all super administrators from the database.")
+		RespondWithServiceError(w, r, err, "Error fetching all super administrators from the database.")
 		return
 	}
 	if len(superAdmins)==0 {
@@ -689,21 +567,23 @@ func (h *UserHandler) GetSuperAdminUsers(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *UserHandler) GetActiveUsers(w http.ResponseWriter, r *http.Request){
+	r, span := tracing.StartRequest(r, "UserHandler.GetActiveUsers")
+	defer span.End()
+
 	// params
 	var params struct {
-		Limit	    int32		`json:"limit"`
-		Offset		int32		`json:"offset"`
+		Limit	    int32		`json:"limit" validate:"min=0,max=1000"`
+		Offset		int32		`json:"offset" validate:"min=0"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
-	activeUsers, err := h.userService.GetActiveUsers(r.Context(), params.Limit, params.Offset)
+	activeUsers, err := h.userService.GetActiveUsers(r.Context(), pageLimit(params.Limit), params.Offset)
 	if err != nil{
-		RespondWithError(w, http.StatusInternalServerError, "Error fetching all active users from the database.")
+		RespondWithServiceError(w, r, err, "Error fetching all active users from the database.")
 		return
 	}
 	if len(activeUsers)==0 {
@@ -715,25 +595,26 @@ func (h *UserHandler) GetActiveUsers(w http.ResponseWriter, r *http.Request){
 }
 
 func (h *UserHandler) GetInactiveUsers(w http.ResponseWriter, r *http.Request){
+	r, span := tracing.StartRequest(r, "UserHandler.GetInactiveUsers")
+	defer span.End()
+
 	// params
 	var params struct {
-		Limit	    int32		`json:"limit"`
-		Offset		int32		`json:"offset"`
+		Limit	    int32		`json:"limit" validate:"min=0,max=1000"`
+		Offset		int32		`json:"offset" validate:"min=0"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
-	inactiveUsers, err := h.userService.GetInactiveUsers(r.Context(), params.Limit, params.Offset)
+	inactiveUsers, err := h.userService.GetInactiveUsers(r.Context(), pageLimit(params.Limit), params.Offset)
 	if err != nil{
-		RespondWithError(w, http.StatusInternalServerError, "Error fetching inactive users from the database.")
+		RespondWithServiceError(w, r, err, "Error fetching inactive users from the database.")
 		return
 	}
 	if len(inactiveUsers)==0 {
-		log.Println("Inactive users:", inactiveUsers)
 		RespondWithSuccess(w, http.StatusNotFound, "There are no inactive users in the database")
 		return
 	}
@@ -742,21 +623,23 @@ func (h *UserHandler) GetInactiveUsers(w http.ResponseWriter, r *http.Request){
 }
 
 func (h *UserHandler) GetSuspendedUsers(w http.ResponseWriter, r *http.Request){
+	r, span := tracing.StartRequest(r, "UserHandler.GetSuspendedUsers")
+	defer span.End()
+
 	// params
 	var params struct {
-		Limit	    int32		`json:"limit"`
-		Offset		int32		`json:"offset"`
+		Limit	    int32		`json:"limit" validate:"min=0,max=1000"`
+		Offset		int32		`json:"offset" validate:"min=0"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
-	suspendedUsers, err := h.userService.GetSuspendedUsers(r.Context(), params.Limit, params.Offset)
+	suspendedUsers, err := h.userService.GetSuspendedUsers(r.Context(), pageLimit(params.Limit), params.Offset)
 	if err != nil{
-		RespondWithError(w, http.StatusInternalServerError, "Error fetching suspended users from the database.")
+		RespondWithServiceError(w, r, err, "Error fetching suspended users from the database.")
 		return
 	}
 	if len(suspendedUsers)==0 {
@@ -768,21 +651,23 @@ func (h *UserHandler) GetSuspendedUsers(w http.ResponseWriter, r *http.Request){
 }
 
 func (h *UserHandler) GetDeletedUsers(w http.ResponseWriter, r *http.Request){
+	r, span := tracing.StartRequest(r, "UserHandler.GetDeletedUsers")
+	defer span.End()
+
 	// params
 	var params struct {
-		Limit	    int32		`json:"limit"`
-		Offset		int32		`json:"offset"`
+		Limit	    int32		`json:"limit" validate:"min=0,max=1000"`
+		Offset		int32		`json:"offset" validate:"min=0"`
 	}
 
 	// decode request body
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to decode request body: %v", err))
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
-	disabledUsers, err := h.userService.GetDeletedUsers(r.Context(), params.Limit, params.Offset)
+	disabledUsers, err := h.userService.GetDeletedUsers(r.Context(), pageLimit(params.Limit), params.Offset)
 	if err != nil{
-		RespondWithError(w, http.StatusInternalServerError, "Error fetching inactive users from the database.")
+		RespondWithServiceError(w, r, err, "Error fetching inactive users from the database.")
 		return
 	}
 	if len(disabledUsers)==0 {
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces the secrets removed from logs
+const redacted = "[REDACTED]"
+
+// secretKeys are the attribute keys, or key suffixes, whose values are never
+// logged, e.g. password, refresh_token and client_secret
+var secretKeys = []string{"password", "token", "secret", "authorization", "cookie", "api_key"}
+
+var (
+	// emailPattern matches email addresses, keeping their domain
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+	// jwtPattern matches JSON web tokens
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]*\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+	// apiKeyPattern matches API keys, keeping their public prefix
+	apiKeyPattern = regexp.MustCompile(`\b(ga_[0-9a-f]{12})_[A-Za-z0-9_\-]+`)
+	// bearerPattern matches the credentials of Authorization headers
+	bearerPattern = regexp.MustCompile(`(?i)\b(bearer|basic) [A-Za-z0-9._~+/\-]+=*`)
+	// queryPattern matches query parameters and form values carrying secrets, e.g. in links
+	queryPattern = regexp.MustCompile(`(?i)\b((?:access_|refresh_|id_)?token|password|secret|code|code_verifier|client_secret)=[^&\s"']+`)
+)
+
+// redactAttr removes secrets and email addresses from the message and
+// attributes of log records
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	if isSecretKey(attr.Key) {
+		return slog.String(attr.Key, redacted)
+	}
+
+	switch value := attr.Value.Any().(type) {
+	case string:
+		return slog.String(attr.Key, Redact(value))
+	case error:
+		return slog.String(attr.Key, Redact(value.Error()))
+	}
+
+	return attr
+}
+
+// isSecretKey reports whether the attribute key names a secret
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secretKey := range secretKeys {
+		if key == secretKey || strings.HasSuffix(key, "_"+secretKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redact removes the tokens, API keys and credentials from the text and masks
+// the email addresses in it, e.g. ***@example.com
+func Redact(text string) string {
+	text = jwtPattern.ReplaceAllString(text, redacted)
+	text = apiKeyPattern.ReplaceAllString(text, "${1}_"+redacted)
+	text = bearerPattern.ReplaceAllString(text, "${1} "+redacted)
+	text = queryPattern.ReplaceAllString(text, "${1}="+redacted)
+	text = emailPattern.ReplaceAllString(text, "***@${1}")
+
+	return text
+}
@@ -0,0 +1,137 @@
+// Package metrics exposes the metrics of the server in the Prometheus format
+package metrics
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the name of every metric
+const namespace = "go_auth"
+
+// Outcomes of operations. Failures are recorded by the code of their error.
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// Methods users log in with
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodMagicLink = "magic_link"
+	LoginMethodSocial    = "social"
+	LoginMethodOAuth     = "oauth"
+)
+
+// Stages of password resets
+const (
+	PasswordResetRequested = "requested"
+	PasswordResetCompleted = "completed"
+)
+
+// registry holds the metrics of the server along with those of the Go runtime and the process
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to respond to HTTP requests by method and route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Logins by method and outcome, the error code of failed ones.",
+	}, []string{"method", "outcome"})
+
+	tokenRefreshes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_refreshes_total",
+		Help:      "Access tokens refreshed by outcome, the error code of failed ones.",
+	}, []string{"outcome"})
+
+	passwordResets = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "password_resets_total",
+		Help:      "Password resets by stage, requested or completed, and outcome.",
+	}, []string{"stage", "outcome"})
+
+	emails = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "emails_total",
+		Help:      "Emails by kind and outcome, sent or failed.",
+	}, []string{"kind", "outcome"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		logins,
+		tokenRefreshes,
+		passwordResets,
+		emails,
+	)
+}
+
+// Handler serves the metrics. Metrics that fail to be collected are left out
+// rather than failing the whole scrape.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+		ErrorLog:      log.Default(),
+	})
+}
+
+// RecordHTTPRequest records a request answered with the status after the duration.
+// The route is the template of the route it matched, so paths with IDs share a route.
+func RecordHTTPRequest(method string, route string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpRequestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// RecordLogin records a login with the method and its outcome
+func RecordLogin(method string, outcome string) {
+	logins.WithLabelValues(method, outcome).Inc()
+}
+
+// RecordTokenRefresh records a refresh of an access token and its outcome
+func RecordTokenRefresh(outcome string) {
+	tokenRefreshes.WithLabelValues(outcome).Inc()
+}
+
+// RecordPasswordReset records a stage of a password reset and its outcome
+func RecordPasswordReset(stage string, outcome string) {
+	passwordResets.WithLabelValues(stage, outcome).Inc()
+}
+
+// RecordEmail records an email of the kind, failed when err is not nil
+func RecordEmail(kind string, err error) {
+	outcome := "sent"
+	if err != nil {
+		outcome = "failed"
+	}
+	emails.WithLabelValues(kind, outcome).Inc()
+}
+
+// RegisterDatabase exposes the connection pool statistics of the database
+func RegisterDatabase(db *sql.DB) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, "go_auth"))
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// sessionCountTimeout is how long counting the active sessions may take during a scrape
+const sessionCountTimeout = 5 * time.Second
+
+// SessionCounter counts the active sessions by kind, e.g. refresh_token
+type SessionCounter func(ctx context.Context) (map[string]int64, error)
+
+// sessionCollector counts the active sessions when metrics are scraped
+type sessionCollector struct {
+	desc  *prometheus.Desc
+	count SessionCounter
+}
+
+// RegisterSessionCounter exposes the active sessions counted by count on every scrape
+func RegisterSessionCounter(count SessionCounter) {
+	registry.MustRegister(&sessionCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "active_sessions"),
+			"Sessions that are neither expired nor revoked, by kind.",
+			[]string{"kind"}, nil,
+		),
+		count: count,
+	})
+}
+
+func (c *sessionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *sessionCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), sessionCountTimeout)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	for kind, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), kind)
+	}
+}
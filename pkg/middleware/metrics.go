@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/handlers"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/metrics"
+	"github.com/gorilla/mux"
+)
+
+// unmatchedRoute is the route of requests that matched none, so unknown paths
+// do not each get metrics of their own
+const unmatchedRoute = "unmatched"
+
+type routeKey struct{}
+
+// statusRecorder remembers the status code a handler responded with and the
+// size of the body it wrote
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(body []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(body)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Metrics records the count and duration of requests by route template. The
+// route is filled in by RouteTemplate once the router matched the request.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r, route := withRouteHolder(r)
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		metrics.RecordHTTPRequest(r.Method, *route, recorder.status, time.Since(start))
+	})
+}
+
+// withRouteHolder returns the request with a place for RouteTemplate to put
+// the template of the route it matches, reusing the one of an outer middleware
+func withRouteHolder(r *http.Request) (*http.Request, *string) {
+	if route, ok := r.Context().Value(routeKey{}).(*string); ok {
+		return r, route
+	}
+
+	route := unmatchedRoute
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, &route)), &route
+}
+
+// RouteTemplate tells Metrics, Tracing and AccessLog the template of the route the request matched.
+// It is used on the router, whose middleware only runs for matched routes.
+func RouteTemplate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route, ok := r.Context().Value(routeKey{}).(*string); ok {
+			if template, err := mux.CurrentRoute(r).GetPathTemplate(); err == nil {
+				*route = template
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireBearerToken only lets requests with the bearer token through, or
+// every request when the token is empty
+func RequireBearerToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			handlers.RespondWithError(w, r, http.StatusUnauthorized, "Missing or invalid bearer token")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
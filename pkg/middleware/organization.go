@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/handlers"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+)
+
+// OrganizationMiddleware guards tenant routes behind a role in the caller's
+// current organization. It must run after CorsAuth, which places the
+// organization from the token in the request context.
+type OrganizationMiddleware struct {
+	orgService *usecases.OrganizationService
+}
+
+func NewOrganizationMiddleware(orgService *usecases.OrganizationService) *OrganizationMiddleware {
+	return &OrganizationMiddleware{
+		orgService: orgService,
+	}
+}
+
+// RequireOrgRole only lets the request through when the caller holds the role in their current organization
+func (m *OrganizationMiddleware) RequireOrgRole(orgRole string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Getting the user and organization IDs set by the auth middleware
+		userId, ok := utils.GetUserIdFromContext(r.Context())
+		if !ok {
+			logging.FromContext(r.Context()).Warn("No user ID in request context")
+			handlers.RespondWithError(w, r, http.StatusUnauthorized, "Missing or invalid access token")
+			return
+		}
+
+		orgId, ok := utils.GetOrgIdFromContext(r.Context())
+		if !ok {
+			logging.FromContext(r.Context()).Info("User has no organization on their token", "user_id", userId)
+			handlers.RespondWithErrorCode(w, r, http.StatusForbidden, "no_organization", "Switch to an organization to use this route")
+			return
+		}
+
+		// Checking the user's membership
+		member, err := m.orgService.GetMembership(r.Context(), orgId, userId)
+		if errors.Is(err, usecases.ErrNotOrganizationMember) {
+			logging.FromContext(r.Context()).Info("User is not a member of the organization", "user_id", userId, "org_id", orgId)
+			handlers.RespondWithErrorCode(w, r, http.StatusForbidden, "not_organization_member", "You are not a member of this organization")
+			return
+		}
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Error checking membership of user in organization", "user_id", userId, "org_id", orgId, "error", err)
+			handlers.RespondWithError(w, r, http.StatusInternalServerError, "Error checking organization membership")
+			return
+		}
+		if member.OrgRole != orgRole {
+			logging.FromContext(r.Context()).Info("User lacks the organization role", "user_id", userId, "org_id", orgId, "org_role", orgRole)
+			handlers.RespondWithErrorCode(w, r, http.StatusForbidden, "organization_role_required", "Your organization role does not allow this action")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/handlers"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+)
+
+// PermissionMiddleware guards routes behind a required permission. It must
+// run after CorsAuth, which places the caller's user ID and the scopes of their
+// API key, if they used one, in the request context.
+type PermissionMiddleware struct {
+	roleService           *usecases.RoleService
+	serviceAccountService *usecases.ServiceAccountService
+}
+
+func NewPermissionMiddleware(roleService *usecases.RoleService, serviceAccountService *usecases.ServiceAccountService) *PermissionMiddleware {
+	return &PermissionMiddleware{
+		roleService:           roleService,
+		serviceAccountService: serviceAccountService,
+	}
+}
+
+// Require only lets the request through when one of the caller's roles grants
+// the permission, or the calling service account holds it
+func (m *PermissionMiddleware) Require(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Getting the user ID set by the auth middleware
+		userId, ok := utils.GetUserIdFromContext(r.Context())
+		if !ok {
+			logging.FromContext(r.Context()).Warn("No user ID in request context")
+			handlers.RespondWithError(w, r, http.StatusUnauthorized, "Missing or invalid access token")
+			return
+		}
+
+		// API keys with scopes are restricted to the permissions they were scoped to
+		if scopes, ok := utils.GetAPIKeyScopesFromContext(r.Context()); ok && len(scopes) > 0 && !slices.Contains(scopes, permission) {
+			logging.FromContext(r.Context()).Info("API key of user is not scoped to the permission", "user_id", userId, "permission", permission)
+			handlers.RespondWithErrorCode(w, r, http.StatusForbidden, "permission_denied", "You do not have permission to perform this action")
+			return
+		}
+
+		// Checking the user's permissions, or the service account's own
+		var granted bool
+		var err error
+		if utils.IsServiceAccountInContext(r.Context()) {
+			granted, err = m.serviceAccountService.HasPermission(r.Context(), userId, permission)
+		} else {
+			granted, err = m.roleService.HasPermission(r.Context(), userId, permission)
+		}
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Error checking permission for user", "permission", permission, "user_id", userId, "error", err)
+			handlers.RespondWithError(w, r, http.StatusInternalServerError, "Error checking permission")
+			return
+		}
+		if !granted {
+			logging.FromContext(r.Context()).Info("User lacks the permission", "user_id", userId, "permission", permission)
+			handlers.RespondWithErrorCode(w, r, http.StatusForbidden, "permission_denied", "You do not have permission to perform this action")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
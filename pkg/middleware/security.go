@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/config"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/handlers"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+)
+
+// SecurityHeaders sets the headers telling browsers to only reach the server
+// over HTTPS, not to frame its pages, sniff content types or leak the tokens
+// in its URLs through the Referer header, and what its pages may load
+func SecurityHeaders(cfg config.SecurityConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("Referrer-Policy", "no-referrer")
+		header.Set("Cross-Origin-Opener-Policy", "same-origin")
+		if cfg.ContentSecurityPolicy != "" {
+			header.Set("Content-Security-Policy", cfg.ContentSecurityPolicy)
+		}
+		if cfg.HSTSMaxAge > 0 {
+			header.Set("Strict-Transport-Security", "max-age="+strconv.FormatInt(int64(cfg.HSTSMaxAge.Seconds()), 10))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+const (
+	// csrfCookie keeps the CSRF token of the browser
+	csrfCookie = "csrf_token"
+	// csrfField is the form field forms post the CSRF token back in
+	csrfField = "csrf_token"
+)
+
+// CSRFMiddleware protects HTML forms with double-submit tokens
+type CSRFMiddleware struct {
+	secure bool
+}
+
+// NewCSRFMiddleware returns the CSRF middleware of a server browsers reach at
+// the public URL. Its cookie is only sent over HTTPS when the public URL is an
+// https one, as requests reaching the server through a TLS terminating proxy
+// are plain HTTP.
+func NewCSRFMiddleware(publicURL string) *CSRFMiddleware {
+	parsed, err := url.Parse(publicURL)
+	return &CSRFMiddleware{
+		secure: err == nil && parsed.Scheme == "https",
+	}
+}
+
+// Protect protects the HTML forms of a route with double-submit tokens. The
+// token is kept in a cookie, which other sites cannot read, and the form served
+// puts it in the csrf_token field; forms are only accepted when the two match.
+// Pages carrying the token are not cached.
+func (m *CSRFMiddleware) Protect(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		if cookie, err := r.Cookie(csrfCookie); err == nil {
+			token = cookie.Value
+		}
+
+		// Checking the token of submitted forms
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			presented := r.PostFormValue(csrfField)
+			if token == "" || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+				handlers.RespondWithErrorCode(w, r, http.StatusForbidden, "invalid_csrf_token", "The form has expired or was not sent from this site, reload the page and try again")
+				return
+			}
+		}
+
+		// Issuing a token to browsers without one
+		if token == "" {
+			var err error
+			token, err = utils.GenerateOpaqueToken(32)
+			if err != nil {
+				handlers.RespondWithError(w, r, http.StatusInternalServerError, "Error generating CSRF token")
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     csrfCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   m.secure || r.TLS != nil,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		next.ServeHTTP(w, r.WithContext(utils.SetCSRFTokenInContext(r.Context(), token)))
+	}
+}
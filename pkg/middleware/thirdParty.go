@@ -1,18 +1,26 @@
 package middleware
 
 import (
-	"log"
+	"context"
+	"errors"
 	"net/http"
 
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/config"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/handlers"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/tracing"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/usecases"
 	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+	"github.com/google/uuid"
 	"github.com/jub0bs/cors"
 )
 
-// CreateCORSMiddleware is a function that creates a CORS middleware with specified configurations
-func CreateCORSMiddleware() (*cors.Middleware, error) {
+// CreateCORSMiddleware is a function that creates a CORS middleware allowing the configured origins
+func CreateCORSMiddleware(corsConfig config.CORSConfig) (*cors.Middleware, error) {
     // Create CORS middleware with specified configurations
     corsMw, err := cors.NewMiddleware(cors.Config{
-        Origins:        []string{"http://localhost:5173"},
+        Origins:        corsConfig.Origins,
         Methods:        []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
         RequestHeaders: []string{"Authorization", "Content-Type"},
         ResponseHeaders: []string{"X-Response-Time"},
@@ -23,83 +31,156 @@ func CreateCORSMiddleware() (*cors.Middleware, error) {
         return nil, err
     }
 
-    corsMw.SetDebug(true) // turn debug mode on (optional)
+    corsMw.SetDebug(corsConfig.Debug)
 
     return corsMw, nil
 }
 
-// Admin middleware
-func CorsAdmin(next http.Handler) http.Handler {
+// AuthMiddleware authenticates requests with a JWT access token or a personal API key
+type AuthMiddleware struct {
+    apiKeyService        *usecases.APIKeyService
+    impersonationService *usecases.ImpersonationService
+}
+
+func NewAuthMiddleware(apiKeyService *usecases.APIKeyService, impersonationService *usecases.ImpersonationService) *AuthMiddleware {
+    return &AuthMiddleware{
+        apiKeyService:        apiKeyService,
+        impersonationService: impersonationService,
+    }
+}
+
+// Auth middleware
+func (m *AuthMiddleware) CorsAuth(next http.Handler) http.Handler {
     return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
         const bearerSchema = "Bearer "
 
         // Getting authorization header
         authHeader := r.Header.Get("Authorization")
         if authHeader == "" {
-            log.Println("Middleware error: Authorization header blank")
-            w.WriteHeader(http.StatusBadRequest)
+            handlers.RespondWithError(w, r, http.StatusUnauthorized, "Missing or invalid access token")
             return
         }
 
         // Checking if authorization header is valid
         if len(authHeader) < len(bearerSchema) || authHeader[:len(bearerSchema)] != bearerSchema {
-            log.Println("Middleware error: Authorization header is invalid")
-            w.WriteHeader(http.StatusUnauthorized)
+            handlers.RespondWithError(w, r, http.StatusUnauthorized, "Missing or invalid access token")
             return
         }
 
         // Getting token
         token := authHeader[len(bearerSchema):]
+
+        // API keys act as their user, restricted to the key's scopes
+        if utils.IsAPIKey(token) {
+            claims, apiKey, err := m.apiKeyService.Authenticate(r.Context(), token)
+            if err != nil {
+                if !errors.Is(err, usecases.ErrInvalidAPIKey) {
+                    logging.FromContext(r.Context()).Error("Error authenticating API key", "error", err)
+                }
+                handlers.RespondWithError(w, r, http.StatusUnauthorized, "Missing or invalid access token")
+                return
+            }
+
+            ctx := setClaimsInContext(r.Context(), claims)
+            ctx = utils.SetAPIKeyInContext(ctx, apiKey.ID, apiKey.Scopes)
+
+            next.ServeHTTP(w, r.WithContext(ctx))
+            return
+        }
+
         claims, err := utils.ParseToken(token, true)
         if err != nil {
-            log.Println("Middleware error: Error in parsing token:", err)
-            w.WriteHeader(http.StatusInternalServerError)
+            handlers.RespondWithError(w, r, http.StatusUnauthorized, "Missing or invalid access token")
+            return
+        }
+
+        // Client credentials tokens have no user to act as
+        if claims.UserID == uuid.Nil {
+            handlers.RespondWithError(w, r, http.StatusUnauthorized, "Missing or invalid access token")
             return
         }
 
-        // Checking the user role
-        if claims.Role != "admin" && claims.Role != "superadmin" {
-            log.Println("Middleware error: The claim isn't an administrator one: ", err)
-            w.WriteHeader(http.StatusUnauthorized)
+        // Tokens issued to OAuth clients only carry the scope the user granted the client,
+        // not the user's own access to the API
+        if claims.ClientID != "" && claims.PrincipalType != model.PrincipalServiceAccount {
+            handlers.RespondWithError(w, r, http.StatusUnauthorized, "Missing or invalid access token")
             return
         }
 
-        // Setting up user role in context
-        ctx := utils.SetUserRoleInContext(r.Context(), claims.Role)
+        // Every request made while impersonating a user is recorded
+        if claims.Act != nil {
+            err := m.impersonationService.RecordUse(r.Context(), claims, r.Method, r.URL.Path)
+            if err != nil {
+                if !errors.Is(err, usecases.ErrInvalidImpersonation) {
+                    logging.FromContext(r.Context()).Error("Error recording impersonation", "error", err)
+                }
+                handlers.RespondWithError(w, r, http.StatusUnauthorized, "Missing or invalid access token")
+                return
+            }
+        }
 
-        next.ServeHTTP(w, r.WithContext(ctx))
+        next.ServeHTTP(w, r.WithContext(setClaimsInContext(r.Context(), claims)))
     })
 }
 
-// Auth middleware
-func CorsAuth(next http.Handler) http.Handler {
-    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-        const bearerSchema = "Bearer "
+// setClaimsInContext sets up the user ID, role, organization, principal type and impersonator of the claims in the context,
+// and records the hashed user ID on the span of the request
+func setClaimsInContext(ctx context.Context, claims *utils.UserClaims) context.Context {
+    tracing.SetUser(ctx, claims.UserID)
+    ctx = utils.SetUserIdInContext(ctx, claims.UserID)
+    ctx = utils.SetUserRoleInContext(ctx, claims.Role)
+    if claims.OrgID != nil {
+        ctx = utils.SetOrgIdInContext(ctx, *claims.OrgID)
+    }
+    if claims.PrincipalType != "" {
+        ctx = utils.SetPrincipalTypeInContext(ctx, claims.PrincipalType)
+    }
+    if claims.Act != nil {
+        if actorId, err := uuid.Parse(claims.Act.Subject); err == nil {
+            ctx = utils.SetImpersonatorInContext(ctx, actorId)
+        }
+    }
 
-        // Getting authorization header
-        authHeader := r.Header.Get("Authorization")
-        if authHeader == "" {
-            w.WriteHeader(http.StatusUnauthorized)
+    return ctx
+}
+
+// RejectServiceAccounts keeps service accounts out of routes that act on the
+// caller's own account. It must run after CorsAuth.
+func RejectServiceAccounts(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if utils.IsServiceAccountInContext(r.Context()) {
+            handlers.RespondWithErrorCode(w, r, http.StatusForbidden, "service_account_refused", "Service accounts cannot use this route")
             return
         }
 
-        // Checking if authorization header is valid
-        if len(authHeader) < len(bearerSchema) || authHeader[:len(bearerSchema)] != bearerSchema {
-            w.WriteHeader(http.StatusUnauthorized)
+        next.ServeHTTP(w, r)
+    })
+}
+
+// RejectScopedAPIKeys keeps API keys with scopes out of routes that require no
+// permission, such as those acting on the caller's own account or organization,
+// as no scope covers them. Keys without scopes can do anything their user can.
+// It must run after CorsAuth.
+func RejectScopedAPIKeys(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if scopes, ok := utils.GetAPIKeyScopesFromContext(r.Context()); ok && len(scopes) > 0 {
+            handlers.RespondWithErrorCode(w, r, http.StatusForbidden, "permission_denied", "API keys with scopes cannot use this route")
             return
         }
 
-        // Getting token
-        token := authHeader[len(bearerSchema):]
-        claims, err := utils.ParseToken(token, true)
-        if err != nil {
-            w.WriteHeader(http.StatusUnauthorized)
+        next.ServeHTTP(w, r)
+    })
+}
+
+// RejectImpersonation keeps administrators impersonating a user out of
+// sensitive routes, such as changing the user's credentials. It must run after CorsAuth.
+func RejectImpersonation(next http.HandlerFunc) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if _, ok := utils.GetImpersonatorFromContext(r.Context()); ok {
+            handlers.RespondWithErrorCode(w, r, http.StatusForbidden, "impersonation_refused", "This route cannot be used while impersonating a user")
             return
         }
 
-        // Setting up user ID in context
-        ctx := utils.SetUserIdInContext(r.Context(), claims.UserID)
-
-        next.ServeHTTP(w, r.WithContext(ctx))
-    })
+        next(w, r)
+    }
 }
@@ -0,0 +1,108 @@
+package migrations
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration is a change to the schema of the database, read from a file
+// named after its version, e.g. 001_initial.sql
+type Migration struct {
+	Version int64
+	Name    string
+	// Up and Down are the statements applying and rolling back the migration
+	Up   string
+	Down string
+}
+
+// Load reads the migrations of the directory in version order. Files use the
+// goose annotations "-- +goose Up" and "-- +goose Down" to mark their sections.
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	versions := map[int64]string{}
+	for _, name := range names {
+		// version
+		prefix, _, ok := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil || version < 1 {
+			return nil, fmt.Errorf("migration %s must be named <version>_<description>.sql", name)
+		}
+		if other, ok := versions[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", other, name)
+		}
+		versions[version] = name
+
+		// sections
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		up, down, err := parseMigration(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", name, err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    path.Base(name),
+			Up:      up,
+			Down:    down,
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// parseMigration splits a migration file into its up and down sections
+func parseMigration(text string) (string, string, error) {
+	var up, down strings.Builder
+	var section *strings.Builder
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if annotation, ok := strings.CutPrefix(strings.TrimSpace(line), "-- +goose "); ok {
+			switch strings.TrimSpace(annotation) {
+			case "Up":
+				section = &up
+			case "Down":
+				section = &down
+			case "StatementBegin", "StatementEnd":
+				// statements are sent whole, so they need no delimiting
+			default:
+				return "", "", fmt.Errorf("unsupported annotation %q", line)
+			}
+			continue
+		}
+
+		if section != nil {
+			section.WriteString(line)
+			section.WriteString("\n")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+
+	if strings.TrimSpace(up.String()) == "" {
+		return "", "", fmt.Errorf("no -- +goose Up section")
+	}
+
+	return up.String(), down.String(), nil
+}
@@ -0,0 +1,284 @@
+// Package migrations applies the schema migrations of the database. Applied
+// versions are recorded in the goose_db_version table, the same way goose
+// does, so databases migrated with the goose command line keep working.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// lockKey identifies the advisory lock held while migrating, so instances
+// started together migrate one after the other
+const lockKey int64 = 0x676f2d61757468
+
+// ErrNothingToRollBack is returned when rolling back without any applied migration
+var ErrNothingToRollBack = errors.New("no migration has been applied")
+
+// Status is whether a migration has been applied, and when
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies the migrations to a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up applies every pending migration in version order and returns them
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			if status.Applied {
+				continue
+			}
+			if err := m.apply(ctx, conn, status.Migration, true); err != nil {
+				return err
+			}
+			applied = append(applied, status.Migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the latest applied migration and returns it
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	var rolledBack Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		latest, err := m.latestApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		rolledBack = latest
+		return m.apply(ctx, conn, latest, false)
+	})
+
+	return rolledBack, err
+}
+
+// Redo rolls back the latest applied migration and applies it again
+func (m *Migrator) Redo(ctx context.Context) (Migration, error) {
+	var redone Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		latest, err := m.latestApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		redone = latest
+		if err := m.apply(ctx, conn, latest, false); err != nil {
+			return err
+		}
+		return m.apply(ctx, conn, latest, true)
+	})
+
+	return redone, err
+}
+
+// Status returns whether each migration has been applied, in version order
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return m.status(ctx, conn)
+}
+
+// Pending returns the migrations that have not been applied
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status.Migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// withLock runs fn on a connection holding the migration lock, waiting for
+// other instances to release it first
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("acquiring migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	if err := createVersionTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// createVersionTable creates the table applied versions are recorded in, if
+// it does not exist yet
+func createVersionTable(ctx context.Context, conn *sql.Conn) error {
+	exists, err := versionTableExists(ctx, conn)
+	if err != nil || exists {
+		return err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `CREATE TABLE goose_db_version (
+		id serial PRIMARY KEY,
+		version_id bigint NOT NULL,
+		is_applied boolean NOT NULL,
+		tstamp timestamp NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("creating goose_db_version: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO goose_db_version (version_id, is_applied) VALUES (0, true)"); err != nil {
+		return fmt.Errorf("creating goose_db_version: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func versionTableExists(ctx context.Context, conn *sql.Conn) (bool, error) {
+	var exists bool
+	err := conn.QueryRowContext(ctx, "SELECT to_regclass('goose_db_version') IS NOT NULL").Scan(&exists)
+	return exists, err
+}
+
+// status reads the applied versions. The latest row of a version tells
+// whether it is applied, as goose also records rollbacks as rows.
+func (m *Migrator) status(ctx context.Context, conn *sql.Conn) ([]Status, error) {
+	applied := map[int64]time.Time{}
+
+	exists, err := versionTableExists(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		rows, err := conn.QueryContext(ctx, "SELECT version_id, is_applied, tstamp FROM goose_db_version ORDER BY id DESC")
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		seen := map[int64]bool{}
+		for rows.Next() {
+			var version int64
+			var isApplied bool
+			var appliedAt sql.NullTime
+			if err := rows.Scan(&version, &isApplied, &appliedAt); err != nil {
+				return nil, err
+			}
+			if seen[version] {
+				continue
+			}
+			seen[version] = true
+			if isApplied {
+				applied[version] = appliedAt.Time
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, Status{Migration: migration, Applied: ok, AppliedAt: appliedAt})
+	}
+
+	return statuses, nil
+}
+
+// latestApplied returns the applied migration with the highest version
+func (m *Migrator) latestApplied(ctx context.Context, conn *sql.Conn) (Migration, error) {
+	statuses, err := m.status(ctx, conn)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	for i := len(statuses) - 1; i >= 0; i-- {
+		if statuses[i].Applied {
+			return statuses[i].Migration, nil
+		}
+	}
+
+	return Migration{}, ErrNothingToRollBack
+}
+
+// apply runs the up or down section of the migration and records it, in a
+// single transaction
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	statements, direction := migration.Up, "up"
+	if !up {
+		statements, direction = migration.Down, "down"
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(statements) != "" {
+		if _, err := tx.ExecContext(ctx, statements); err != nil {
+			return fmt.Errorf("migrating %s %s: %w", direction, migration.Name, err)
+		}
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, "INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, true)", migration.Version)
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM goose_db_version WHERE version_id = $1", migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("recording %s: %w", migration.Name, err)
+	}
+
+	return tx.Commit()
+}
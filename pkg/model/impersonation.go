@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Impersonation is an administrator acting as a user through a short-lived
+// access token. The token carries the administrator in its act claim and
+// every request made with it is recorded as an event.
+type Impersonation struct {
+	ID            uuid.UUID            `json:"id"`
+	ActorID       *uuid.UUID           `json:"actor_id"`
+	ActorUsername string               `json:"actor_username"`
+	TargetID      uuid.UUID            `json:"target_id"`
+	OrgID         *uuid.UUID           `json:"org_id"`
+	Reason        string               `json:"reason"`
+	ExpiresAt     time.Time            `json:"expires_at"`
+	CreatedAt     time.Time            `json:"created_at"`
+	Events        []ImpersonationEvent `json:"events"`
+}
+
+// ImpersonationEvent is a request made with an impersonation token
+type ImpersonationEvent struct {
+	ID              uuid.UUID `json:"id"`
+	ImpersonationID uuid.UUID `json:"impersonation_id"`
+	Method          string    `json:"method"`
+	Path            string    `json:"path"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ImpersonationResponse is the access token issued to act as a user. There is
+// no refresh token; the administrator starts a new impersonation once it expires.
+type ImpersonationResponse struct {
+	AccessToken     string    `json:"access_token"`
+	TokenType       string    `json:"token_type"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	ImpersonationID uuid.UUID `json:"impersonation_id"`
+}
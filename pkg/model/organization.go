@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Per-organization roles. Organization admins manage the members of their
+// own organization only.
+const (
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OrganizationMember struct {
+	OrgID     uuid.UUID `json:"org_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	OrgRole   string    `json:"org_role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Invitation struct {
+	ID         uuid.UUID  `json:"id"`
+	OrgID      uuid.UUID  `json:"org_id"`
+	Email      string     `json:"email"`
+	OrgRole    string     `json:"org_role"`
+	InvitedBy  *uuid.UUID `json:"invited_by,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IsPending reports whether the invitation can still be accepted
+func (i Invitation) IsPending() bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && i.ExpiresAt.After(time.Now())
+}
+
+// InvitationPreview is what the holder of an invitation token is shown before accepting it
+type InvitationPreview struct {
+	OrganizationName string    `json:"organization_name"`
+	Email            string    `json:"email"`
+	OrgRole          string    `json:"org_role"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	HasAccount       bool      `json:"has_account"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// Built-in roles. Ranks are stored with the role; an actor can only act on
+// users ranked below them.
+const (
+	RoleUser       = "user"
+	RoleAdmin      = "admin"
+	RoleSuperAdmin = "superadmin"
+)
+
+// Permissions that can be attached to a role. Admin routes declare the
+// permission they require and one of the caller's roles must grant it.
+const (
+	PermissionUsersRead          = "users:read"
+	PermissionUsersSuspend       = "users:suspend"
+	PermissionUsersRecover       = "users:recover"
+	PermissionUsersDelete        = "users:delete"
+	PermissionRolesRead          = "roles:read"
+	PermissionRolesAssign        = "roles:assign"
+	PermissionRolesManage        = "roles:manage"
+	PermissionOrgsManage         = "orgs:manage"
+	PermissionOAuthClientsManage = "oauth_clients:manage"
+
+	PermissionServiceAccountsManage = "service_accounts:manage"
+	PermissionUsersImpersonate      = "users:impersonate"
+	PermissionUsersCreate           = "users:create"
+)
+
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Rank        int32     `json:"rank"`
+	BuiltIn     bool      `json:"built_in"`
+	CreatedAt   time.Time `json:"created_at"`
+	Permissions []string  `json:"permissions,omitempty"`
+	MemberCount int64     `json:"member_count"`
+}
+
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Principal types tell human users apart from service accounts in access tokens
+const (
+	PrincipalUser           = "user"
+	PrincipalServiceAccount = "service_account"
+)
+
+// RoleServiceAccount is the role on the tokens of service accounts. It is
+// reserved: no role of this name can be created and no user can hold it.
+const RoleServiceAccount = "service_account"
+
+// ServiceAccount is a non-human principal of an organization, or of the
+// platform when it has no organization. It has no password or email and holds
+// its permissions directly rather than through roles.
+type ServiceAccount struct {
+	ID          uuid.UUID  `json:"id"`
+	ClientID    string     `json:"client_id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	OrgID       *uuid.UUID `json:"org_id"`
+	Permissions []string   `json:"permissions"`
+	PublicKey   string     `json:"public_key,omitempty"`
+	HasSecret   bool       `json:"has_secret"`
+	CreatedBy   *uuid.UUID `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	SecretHash  string     `json:"-"`
+}
+
+// ServiceAccountCredentials is returned once when a service account is created
+// or its secret is rotated. The secret is not stored and cannot be shown again.
+type ServiceAccountCredentials struct {
+	ServiceAccount
+	ClientSecret string `json:"client_secret,omitempty"`
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type APIKeyRepository interface {
+	// create
+	CreateAPIKey(ctx context.Context, apiKey model.APIKey) (model.APIKey, error)
+
+	// update
+	TouchAPIKey(ctx context.Context, apiKeyId uuid.UUID) error
+
+	// delete
+	DeleteAPIKey(ctx context.Context, userId uuid.UUID, apiKeyId uuid.UUID) (bool, error)
+
+	// get
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (model.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userId uuid.UUID) ([]model.APIKey, error)
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a write conflicts with an existing record
+	ErrConflict = errors.New("record conflicts with an existing one")
+)
+
+// ConflictError is an ErrConflict naming the unique constraint the write violated
+type ConflictError struct {
+	Constraint string
+	Err        error
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrConflict, e.Constraint)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// IsConflict reports whether err is a conflict on the named unique constraint
+func IsConflict(err error, constraint string) bool {
+	var conflictErr *ConflictError
+	return errors.As(err, &conflictErr) && conflictErr.Constraint == constraint
+}
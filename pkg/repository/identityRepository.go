@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type IdentityRepository interface {
+	// create
+	CreateIdentity(ctx context.Context, userId uuid.UUID, identity model.ExternalIdentity) (model.UserIdentity, error)
+
+	// update
+	TouchIdentity(ctx context.Context, identityId uuid.UUID) error
+
+	// delete
+	DeleteIdentity(ctx context.Context, userId uuid.UUID, provider string) (bool, error)
+
+	// get
+	GetIdentity(ctx context.Context, provider string, subject string) (model.UserIdentity, error)
+	GetUserIdentities(ctx context.Context, userId uuid.UUID) ([]model.UserIdentity, error)
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type ImpersonationRepository interface {
+	// create
+	CreateImpersonation(ctx context.Context, impersonation model.Impersonation) (model.Impersonation, error)
+	CreateImpersonationEvent(ctx context.Context, event model.ImpersonationEvent) error
+
+	// get
+	GetImpersonationById(ctx context.Context, impersonationId uuid.UUID) (model.Impersonation, error)
+	GetUserImpersonations(ctx context.Context, targetId uuid.UUID) ([]model.Impersonation, error)
+	GetUserImpersonationEvents(ctx context.Context, targetId uuid.UUID) ([]model.ImpersonationEvent, error)
+	CountActiveImpersonations(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type InvitationRepository interface {
+	// create
+	CreateInvitation(ctx context.Context, invitation model.Invitation) (model.Invitation, error)
+
+	// update
+	AcceptInvitation(ctx context.Context, invitationId uuid.UUID) (bool, error)
+	RevokeInvitation(ctx context.Context, invitationId uuid.UUID, orgId uuid.UUID) (bool, error)
+	RevokePendingInvitations(ctx context.Context, orgId uuid.UUID, email string) error
+
+	// get
+	GetInvitationById(ctx context.Context, invitationId uuid.UUID) (model.Invitation, error)
+	ListPendingInvitations(ctx context.Context, orgId uuid.UUID) ([]model.Invitation, error)
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type OAuthRepository interface {
+	// create
+	CreateClient(ctx context.Context, client model.OAuthClient) (model.OAuthClient, error)
+	CreateAuthorizationCode(ctx context.Context, code model.AuthorizationCode) error
+	StoreClientRefreshToken(ctx context.Context, userId uuid.UUID, clientId string, scope string, refreshToken string, expiresAt time.Time) (model.RefreshToken, error)
+
+	// update
+	ConsumeAuthorizationCode(ctx context.Context, codeHash string) (model.AuthorizationCode, error)
+	UpsertConsent(ctx context.Context, userId uuid.UUID, clientId string, scope string) error
+	RevokeClientRefreshTokens(ctx context.Context, userId uuid.UUID, clientId string) error
+
+	// delete
+	DeleteClient(ctx context.Context, clientId string) error
+
+	// get
+	GetClientById(ctx context.Context, clientId string) (model.OAuthClient, error)
+	ListClients(ctx context.Context) ([]model.OAuthClient, error)
+	GetConsentedScope(ctx context.Context, userId uuid.UUID, clientId string) (string, error)
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type OrganizationRepository interface {
+	// create
+	CreateOrganization(ctx context.Context, organization model.Organization) (model.Organization, error)
+
+	// update
+	UpsertOrganizationMember(ctx context.Context, orgId uuid.UUID, userId uuid.UUID, orgRole string) (model.OrganizationMember, error)
+
+	// delete
+	DeleteOrganizationMember(ctx context.Context, orgId uuid.UUID, userId uuid.UUID) error
+
+	// get
+	GetOrganizationById(ctx context.Context, orgId uuid.UUID) (model.Organization, error)
+	ListOrganizations(ctx context.Context, limit, offset int32) ([]model.Organization, error)
+	GetOrganizationMember(ctx context.Context, orgId uuid.UUID, userId uuid.UUID) (model.OrganizationMember, error)
+	GetUserOrganizationMemberships(ctx context.Context, userId uuid.UUID) ([]model.OrganizationMember, error)
+	GetOrganizationUsers(ctx context.Context, orgId uuid.UUID, limit, offset int32) ([]database.User, error)
+}
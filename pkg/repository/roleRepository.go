@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type RoleRepository interface {
+	// create
+	CreateRole(ctx context.Context, role model.Role) (model.Role, error)
+	AssignUserRole(ctx context.Context, userId uuid.UUID, role string) error
+
+	// update
+	RenameRole(ctx context.Context, name string, newName string, description string) (model.Role, error)
+	SetRolePermissions(ctx context.Context, role string, permissions []string) error
+	SyncUserPrimaryRole(ctx context.Context, userId uuid.UUID) (model.User, error)
+
+	// delete
+	DeleteRole(ctx context.Context, name string) error
+	RevokeUserRole(ctx context.Context, userId uuid.UUID, role string) error
+	RevokeUserRoleUnlessLast(ctx context.Context, userId uuid.UUID, role string) (bool, error)
+
+	// get
+	GetRoleByName(ctx context.Context, name string) (model.Role, error)
+	GetRolePermissions(ctx context.Context, role string) ([]string, error)
+	ListRoles(ctx context.Context) ([]model.Role, error)
+	ListPermissions(ctx context.Context) ([]model.Permission, error)
+	CountRoleMembers(ctx context.Context, role string) (int64, error)
+	CountActiveRoleMembers(ctx context.Context, role string) (int64, error)
+	GetUserRoles(ctx context.Context, userId uuid.UUID) ([]model.Role, error)
+	UserHasPermission(ctx context.Context, userId uuid.UUID, permission string) (bool, error)
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type ServiceAccountRepository interface {
+	// create
+	CreateServiceAccount(ctx context.Context, serviceAccount model.ServiceAccount) (model.ServiceAccount, error)
+
+	// update
+	UpdateServiceAccount(ctx context.Context, serviceAccount model.ServiceAccount) (model.ServiceAccount, error)
+	UpdateServiceAccountSecret(ctx context.Context, serviceAccountId uuid.UUID, secretHash string) error
+	TouchServiceAccount(ctx context.Context, serviceAccountId uuid.UUID) error
+
+	// delete
+	DeleteServiceAccount(ctx context.Context, serviceAccountId uuid.UUID) (bool, error)
+
+	// get
+	GetServiceAccountById(ctx context.Context, serviceAccountId uuid.UUID) (model.ServiceAccount, error)
+	GetServiceAccountByClientId(ctx context.Context, clientId string) (model.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context, orgId uuid.NullUUID) ([]model.ServiceAccount, error)
+}
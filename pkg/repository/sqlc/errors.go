@@ -0,0 +1,29 @@
+package sqlc
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the Postgres error code of unique constraint violations
+const uniqueViolation = "23505"
+
+// toRepositoryError translates database errors into repository errors. Missing
+// rows become repository.ErrNotFound and unique violations a
+// repository.ConflictError; both still wrap the original error.
+func toRepositoryError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", repository.ErrNotFound, err)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return &repository.ConflictError{Constraint: pqErr.Constraint, Err: err}
+	}
+
+	return err
+}
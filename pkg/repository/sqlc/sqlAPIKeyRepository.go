@@ -0,0 +1,124 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type SQLAPIKeyRepository struct {
+	DB *database.Queries
+}
+
+func NewSQLAPIKeyRepository(db *database.Queries) *SQLAPIKeyRepository {
+	return &SQLAPIKeyRepository{
+		DB: db,
+	}
+}
+
+// toModelAPIKey converts a database API key to a model API key
+func toModelAPIKey(apiKey database.ApiKey) model.APIKey {
+	modelAPIKey := model.APIKey{
+		ID:        apiKey.ID,
+		UserID:    apiKey.UserID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		KeyHash:   apiKey.KeyHash,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: apiKey.CreatedAt,
+	}
+	if modelAPIKey.Scopes == nil {
+		modelAPIKey.Scopes = []string{}
+	}
+	if apiKey.ExpiresAt.Valid {
+		modelAPIKey.ExpiresAt = &apiKey.ExpiresAt.Time
+	}
+	if apiKey.LastUsedAt.Valid {
+		modelAPIKey.LastUsedAt = &apiKey.LastUsedAt.Time
+	}
+
+	return modelAPIKey
+}
+
+// CreateAPIKey stores a hashed API key for its user
+func (r *SQLAPIKeyRepository) CreateAPIKey(ctx context.Context, apiKey model.APIKey) (model.APIKey, error) {
+	logging.FromContext(ctx).Info("Creating API key for user", "api_key_name", apiKey.Name, "user_id", apiKey.UserID)
+
+	params := database.CreateAPIKeyParams{
+		ID:      uuid.New(),
+		UserID:  apiKey.UserID,
+		Name:    apiKey.Name,
+		Prefix:  apiKey.Prefix,
+		KeyHash: apiKey.KeyHash,
+		Scopes:  apiKey.Scopes,
+	}
+	if params.Scopes == nil {
+		params.Scopes = []string{}
+	}
+	if apiKey.ExpiresAt != nil {
+		params.ExpiresAt = sql.NullTime{Time: *apiKey.ExpiresAt, Valid: true}
+	}
+
+	createdAPIKey, err := r.DB.CreateAPIKey(ctx, params)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error creating API key for user", "api_key_name", apiKey.Name, "user_id", apiKey.UserID, "error", err)
+		return model.APIKey{}, toRepositoryError(err)
+	}
+
+	return toModelAPIKey(createdAPIKey), nil
+}
+
+// TouchAPIKey records a use of the API key
+func (r *SQLAPIKeyRepository) TouchAPIKey(ctx context.Context, apiKeyId uuid.UUID) error {
+	err := r.DB.TouchAPIKey(ctx, apiKeyId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error recording use of API key", "api_key_id", apiKeyId, "error", err)
+	}
+	return err
+}
+
+// DeleteAPIKey revokes the user's API key and reports whether there was one
+func (r *SQLAPIKeyRepository) DeleteAPIKey(ctx context.Context, userId uuid.UUID, apiKeyId uuid.UUID) (bool, error) {
+	logging.FromContext(ctx).Info("Revoking API key of user", "api_key_id", apiKeyId, "user_id", userId)
+
+	deleted, err := r.DB.DeleteAPIKey(ctx, database.DeleteAPIKeyParams{
+		ID:     apiKeyId,
+		UserID: userId,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error revoking API key of user", "api_key_id", apiKeyId, "user_id", userId, "error", err)
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
+
+// GetAPIKeyByPrefix returns the API key with the lookup prefix
+func (r *SQLAPIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (model.APIKey, error) {
+	apiKey, err := r.DB.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return model.APIKey{}, err
+	}
+
+	return toModelAPIKey(apiKey), nil
+}
+
+// GetUserAPIKeys lists the user's API keys
+func (r *SQLAPIKeyRepository) GetUserAPIKeys(ctx context.Context, userId uuid.UUID) ([]model.APIKey, error) {
+	apiKeys, err := r.DB.ListUserAPIKeys(ctx, userId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error listing API keys of user", "user_id", userId, "error", err)
+		return nil, err
+	}
+
+	modelAPIKeys := make([]model.APIKey, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		modelAPIKeys = append(modelAPIKeys, toModelAPIKey(apiKey))
+	}
+
+	return modelAPIKeys, nil
+}
@@ -0,0 +1,111 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type SQLIdentityRepository struct {
+	DB *database.Queries
+}
+
+func NewSQLIdentityRepository(db *database.Queries) *SQLIdentityRepository {
+	return &SQLIdentityRepository{
+		DB: db,
+	}
+}
+
+// toModelIdentity converts a database identity to a model identity
+func toModelIdentity(identity database.UserIdentity) model.UserIdentity {
+	modelIdentity := model.UserIdentity{
+		ID:        identity.ID,
+		UserID:    identity.UserID,
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email.String,
+		CreatedAt: identity.CreatedAt,
+	}
+	if identity.LastLoginAt.Valid {
+		modelIdentity.LastLoginAt = &identity.LastLoginAt.Time
+	}
+
+	return modelIdentity
+}
+
+// CreateIdentity links an external identity to the user
+func (r *SQLIdentityRepository) CreateIdentity(ctx context.Context, userId uuid.UUID, identity model.ExternalIdentity) (model.UserIdentity, error) {
+	logging.FromContext(ctx).Info("Linking identity to user", "provider", identity.Provider, "user_id", userId)
+
+	createdIdentity, err := r.DB.CreateUserIdentity(ctx, database.CreateUserIdentityParams{
+		ID:       uuid.New(),
+		UserID:   userId,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    sql.NullString{String: identity.Email, Valid: identity.Email != ""},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error linking identity to user", "provider", identity.Provider, "user_id", userId, "error", err)
+		return model.UserIdentity{}, err
+	}
+
+	return toModelIdentity(createdIdentity), nil
+}
+
+// TouchIdentity records a sign in with the identity
+func (r *SQLIdentityRepository) TouchIdentity(ctx context.Context, identityId uuid.UUID) error {
+	err := r.DB.TouchUserIdentity(ctx, identityId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error recording sign in with identity", "identity_id", identityId, "error", err)
+	}
+	return err
+}
+
+// DeleteIdentity unlinks the user's identity at the provider and reports whether there was one
+func (r *SQLIdentityRepository) DeleteIdentity(ctx context.Context, userId uuid.UUID, provider string) (bool, error) {
+	logging.FromContext(ctx).Info("Unlinking identity from user", "provider", provider, "user_id", userId)
+
+	deleted, err := r.DB.DeleteUserIdentity(ctx, database.DeleteUserIdentityParams{
+		UserID:   userId,
+		Provider: provider,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error unlinking identity from user", "provider", provider, "user_id", userId, "error", err)
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
+
+// GetIdentity returns the identity with the subject at the provider
+func (r *SQLIdentityRepository) GetIdentity(ctx context.Context, provider string, subject string) (model.UserIdentity, error) {
+	identity, err := r.DB.GetUserIdentity(ctx, database.GetUserIdentityParams{
+		Provider: provider,
+		Subject:  subject,
+	})
+	if err != nil {
+		return model.UserIdentity{}, err
+	}
+
+	return toModelIdentity(identity), nil
+}
+
+// GetUserIdentities lists the identities linked to the user
+func (r *SQLIdentityRepository) GetUserIdentities(ctx context.Context, userId uuid.UUID) ([]model.UserIdentity, error) {
+	identities, err := r.DB.ListUserIdentities(ctx, userId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error listing identities of user", "user_id", userId, "error", err)
+		return nil, err
+	}
+
+	modelIdentities := make([]model.UserIdentity, 0, len(identities))
+	for _, identity := range identities {
+		modelIdentities = append(modelIdentities, toModelIdentity(identity))
+	}
+
+	return modelIdentities, nil
+}
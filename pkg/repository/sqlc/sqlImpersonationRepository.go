@@ -0,0 +1,129 @@
+package sqlc
+
+import (
+	"context"
+
+	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type SQLImpersonationRepository struct {
+	DB *database.Queries
+}
+
+func NewSQLImpersonationRepository(db *database.Queries) *SQLImpersonationRepository {
+	return &SQLImpersonationRepository{
+		DB: db,
+	}
+}
+
+// toModelImpersonation converts a database impersonation to a model impersonation
+func toModelImpersonation(impersonation database.Impersonation) model.Impersonation {
+	modelImpersonation := model.Impersonation{
+		ID:            impersonation.ID,
+		ActorUsername: impersonation.ActorUsername,
+		TargetID:      impersonation.TargetID,
+		Reason:        impersonation.Reason,
+		ExpiresAt:     impersonation.ExpiresAt,
+		CreatedAt:     impersonation.CreatedAt,
+		Events:        []model.ImpersonationEvent{},
+	}
+	if impersonation.ActorID.Valid {
+		modelImpersonation.ActorID = &impersonation.ActorID.UUID
+	}
+	if impersonation.OrgID.Valid {
+		modelImpersonation.OrgID = &impersonation.OrgID.UUID
+	}
+
+	return modelImpersonation
+}
+
+// CreateImpersonation records the start of an impersonation
+func (r *SQLImpersonationRepository) CreateImpersonation(ctx context.Context, impersonation model.Impersonation) (model.Impersonation, error) {
+	logging.FromContext(ctx).Info("Recording impersonation of user", "user_id", impersonation.TargetID, "actor", impersonation.ActorUsername)
+
+	createdImpersonation, err := r.DB.CreateImpersonation(ctx, database.CreateImpersonationParams{
+		ID:            uuid.New(),
+		ActorID:       toNullUUID(impersonation.ActorID),
+		ActorUsername: impersonation.ActorUsername,
+		TargetID:      impersonation.TargetID,
+		OrgID:         toNullUUID(impersonation.OrgID),
+		Reason:        impersonation.Reason,
+		ExpiresAt:     impersonation.ExpiresAt,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error recording impersonation of user", "user_id", impersonation.TargetID, "error", err)
+		return model.Impersonation{}, err
+	}
+
+	return toModelImpersonation(createdImpersonation), nil
+}
+
+// CreateImpersonationEvent records a request made with an impersonation token
+func (r *SQLImpersonationRepository) CreateImpersonationEvent(ctx context.Context, event model.ImpersonationEvent) error {
+	err := r.DB.CreateImpersonationEvent(ctx, database.CreateImpersonationEventParams{
+		ID:              uuid.New(),
+		ImpersonationID: event.ImpersonationID,
+		Method:          event.Method,
+		Path:            event.Path,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error recording use of impersonation", "impersonation_id", event.ImpersonationID, "error", err)
+	}
+	return err
+}
+
+// GetImpersonationById returns the impersonation with the id
+func (r *SQLImpersonationRepository) GetImpersonationById(ctx context.Context, impersonationId uuid.UUID) (model.Impersonation, error) {
+	impersonation, err := r.DB.GetImpersonationByID(ctx, impersonationId)
+	if err != nil {
+		return model.Impersonation{}, err
+	}
+
+	return toModelImpersonation(impersonation), nil
+}
+
+// GetUserImpersonations lists the impersonations of the user, newest first
+func (r *SQLImpersonationRepository) GetUserImpersonations(ctx context.Context, targetId uuid.UUID) ([]model.Impersonation, error) {
+	impersonations, err := r.DB.ListUserImpersonations(ctx, targetId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error listing impersonations of user", "user_id", targetId, "error", err)
+		return nil, err
+	}
+
+	modelImpersonations := make([]model.Impersonation, 0, len(impersonations))
+	for _, impersonation := range impersonations {
+		modelImpersonations = append(modelImpersonations, toModelImpersonation(impersonation))
+	}
+
+	return modelImpersonations, nil
+}
+
+// GetUserImpersonationEvents lists the requests made while impersonating the user, oldest first
+func (r *SQLImpersonationRepository) GetUserImpersonationEvents(ctx context.Context, targetId uuid.UUID) ([]model.ImpersonationEvent, error) {
+	events, err := r.DB.ListUserImpersonationEvents(ctx, targetId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error listing impersonation events of user", "user_id", targetId, "error", err)
+		return nil, err
+	}
+
+	modelEvents := make([]model.ImpersonationEvent, 0, len(events))
+	for _, event := range events {
+		modelEvents = append(modelEvents, model.ImpersonationEvent{
+			ID:              event.ID,
+			ImpersonationID: event.ImpersonationID,
+			Method:          event.Method,
+			Path:            event.Path,
+			CreatedAt:       event.CreatedAt,
+		})
+	}
+
+	return modelEvents, nil
+}
+
+// CountActiveImpersonations counts the impersonations that have not expired
+func (r *SQLImpersonationRepository) CountActiveImpersonations(ctx context.Context) (int64, error) {
+	return r.DB.CountActiveImpersonations(ctx)
+}
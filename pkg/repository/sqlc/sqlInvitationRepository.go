@@ -0,0 +1,144 @@
+package sqlc
+
+import (
+	"context"
+
+	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type SQLInvitationRepository struct {
+	DB *database.Queries
+}
+
+func NewSQLInvitationRepository(db *database.Queries) *SQLInvitationRepository {
+	return &SQLInvitationRepository{
+		DB: db,
+	}
+}
+
+// toModelInvitation converts a database invitation to a model invitation
+func toModelInvitation(invitation database.OrganizationInvitation) model.Invitation {
+	modelInvitation := model.Invitation{
+		ID:        invitation.ID,
+		OrgID:     invitation.OrgID,
+		Email:     invitation.Email,
+		OrgRole:   invitation.OrgRole,
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+	if invitation.InvitedBy.Valid {
+		modelInvitation.InvitedBy = &invitation.InvitedBy.UUID
+	}
+	if invitation.AcceptedAt.Valid {
+		modelInvitation.AcceptedAt = &invitation.AcceptedAt.Time
+	}
+	if invitation.RevokedAt.Valid {
+		modelInvitation.RevokedAt = &invitation.RevokedAt.Time
+	}
+
+	return modelInvitation
+}
+
+// CreateInvitation creates a new invitation
+func (r *SQLInvitationRepository) CreateInvitation(ctx context.Context, invitation model.Invitation) (model.Invitation, error) {
+	logging.FromContext(ctx).Info("Creating invitation to organization", "invitation_id", invitation.ID, "org_id", invitation.OrgID)
+
+	invitedBy := uuid.NullUUID{}
+	if invitation.InvitedBy != nil {
+		invitedBy = uuid.NullUUID{UUID: *invitation.InvitedBy, Valid: true}
+	}
+
+	createdInvitation, err := r.DB.CreateInvitation(ctx, database.CreateInvitationParams{
+		ID:        invitation.ID,
+		OrgID:     invitation.OrgID,
+		Email:     invitation.Email,
+		OrgRole:   invitation.OrgRole,
+		InvitedBy: invitedBy,
+		ExpiresAt: invitation.ExpiresAt,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error creating invitation", "invitation_id", invitation.ID, "error", err)
+		return model.Invitation{}, err
+	}
+
+	return toModelInvitation(createdInvitation), nil
+}
+
+// AcceptInvitation marks a pending invitation as accepted and reports whether it was pending
+func (r *SQLInvitationRepository) AcceptInvitation(ctx context.Context, invitationId uuid.UUID) (bool, error) {
+	logging.FromContext(ctx).Info("Accepting invitation", "invitation_id", invitationId)
+
+	accepted, err := r.DB.AcceptInvitation(ctx, invitationId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error accepting invitation", "invitation_id", invitationId, "error", err)
+		return false, err
+	}
+
+	return accepted > 0, nil
+}
+
+// RevokeInvitation revokes a pending invitation of the organization and reports whether it was pending
+func (r *SQLInvitationRepository) RevokeInvitation(ctx context.Context, invitationId uuid.UUID, orgId uuid.UUID) (bool, error) {
+	logging.FromContext(ctx).Info("Revoking invitation to organization", "invitation_id", invitationId, "org_id", orgId)
+
+	revoked, err := r.DB.RevokeInvitation(ctx, database.RevokeInvitationParams{
+		ID:    invitationId,
+		OrgID: orgId,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error revoking invitation", "invitation_id", invitationId, "error", err)
+		return false, err
+	}
+
+	return revoked > 0, nil
+}
+
+// RevokePendingInvitations revokes the pending invitations of an email address to the organization
+func (r *SQLInvitationRepository) RevokePendingInvitations(ctx context.Context, orgId uuid.UUID, email string) error {
+	logging.FromContext(ctx).Info("Revoking pending invitations to organization", "org_id", orgId)
+
+	err := r.DB.RevokePendingInvitations(ctx, database.RevokePendingInvitationsParams{
+		OrgID: orgId,
+		Email: email,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error revoking pending invitations to organization", "org_id", orgId, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetInvitationById gets an invitation by id
+func (r *SQLInvitationRepository) GetInvitationById(ctx context.Context, invitationId uuid.UUID) (model.Invitation, error) {
+	logging.FromContext(ctx).Debug("Getting invitation", "invitation_id", invitationId)
+
+	invitation, err := r.DB.GetInvitationByID(ctx, invitationId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error getting invitation", "invitation_id", invitationId, "error", err)
+		return model.Invitation{}, err
+	}
+
+	return toModelInvitation(invitation), nil
+}
+
+// ListPendingInvitations lists the pending invitations of the organization
+func (r *SQLInvitationRepository) ListPendingInvitations(ctx context.Context, orgId uuid.UUID) ([]model.Invitation, error) {
+	logging.FromContext(ctx).Debug("Listing pending invitations to organization", "org_id", orgId)
+
+	invitations, err := r.DB.ListPendingInvitations(ctx, orgId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error listing pending invitations to organization", "org_id", orgId, "error", err)
+		return nil, err
+	}
+
+	modelInvitations := make([]model.Invitation, 0, len(invitations))
+	for _, invitation := range invitations {
+		modelInvitations = append(modelInvitations, toModelInvitation(invitation))
+	}
+
+	return modelInvitations, nil
+}
@@ -0,0 +1,203 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type SQLOAuthRepository struct {
+	DB *database.Queries
+}
+
+func NewSQLOAuthRepository(db *database.Queries) *SQLOAuthRepository {
+	return &SQLOAuthRepository{
+		DB: db,
+	}
+}
+
+// toModelOAuthClient converts a database client to a model client
+func toModelOAuthClient(client database.OauthClient) model.OAuthClient {
+	return model.OAuthClient{
+		ID:           client.ID,
+		Name:         client.Name,
+		Public:       !client.SecretHash.Valid,
+		RedirectURIs: client.RedirectUris,
+		GrantTypes:   client.GrantTypes,
+		Scopes:       client.Scopes,
+		CreatedAt:    client.CreatedAt,
+		SecretHash:   client.SecretHash.String,
+
+		PostLogoutRedirectURIs: client.PostLogoutRedirectUris,
+	}
+}
+
+// CreateClient registers a new client. Public clients have no secret.
+func (r *SQLOAuthRepository) CreateClient(ctx context.Context, client model.OAuthClient) (model.OAuthClient, error) {
+	logging.FromContext(ctx).Info("Registering OAuth client", "client_id", client.ID)
+
+	createdClient, err := r.DB.CreateOAuthClient(ctx, database.CreateOAuthClientParams{
+		ID:                     client.ID,
+		Name:                   client.Name,
+		SecretHash:             sql.NullString{String: client.SecretHash, Valid: !client.Public},
+		RedirectUris:           client.RedirectURIs,
+		GrantTypes:             client.GrantTypes,
+		Scopes:                 client.Scopes,
+		PostLogoutRedirectUris: client.PostLogoutRedirectURIs,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error registering OAuth client", "client_id", client.ID, "error", err)
+		return model.OAuthClient{}, err
+	}
+
+	return toModelOAuthClient(createdClient), nil
+}
+
+// CreateAuthorizationCode stores an issued authorization code by its hash
+func (r *SQLOAuthRepository) CreateAuthorizationCode(ctx context.Context, code model.AuthorizationCode) error {
+	logging.FromContext(ctx).Debug("Storing authorization code for client and user", "client_id", code.ClientID, "user_id", code.UserID)
+
+	err := r.DB.CreateAuthorizationCode(ctx, database.CreateAuthorizationCodeParams{
+		CodeHash:            code.CodeHash,
+		ClientID:            code.ClientID,
+		UserID:              code.UserID,
+		RedirectUri:         code.RedirectURI,
+		Scope:               code.Scope,
+		CodeChallenge:       code.CodeChallenge,
+		CodeChallengeMethod: code.CodeChallengeMethod,
+		ExpiresAt:           code.ExpiresAt,
+		Nonce:               code.Nonce,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error storing authorization code for client", "client_id", code.ClientID, "error", err)
+	}
+	return err
+}
+
+// StoreClientRefreshToken stores a refresh token issued to a client
+func (r *SQLOAuthRepository) StoreClientRefreshToken(ctx context.Context, userId uuid.UUID, clientId string, scope string, refreshToken string, expiresAt time.Time) (model.RefreshToken, error) {
+	logging.FromContext(ctx).Debug("Storing refresh token for client and user", "client_id", clientId, "user_id", userId)
+
+	createdRefreshToken, err := r.DB.StoreClientRefreshToken(ctx, database.StoreClientRefreshTokenParams{
+		ID:        uuid.New(),
+		UserID:    userId,
+		Token:     refreshToken,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+		ClientID:  sql.NullString{String: clientId, Valid: true},
+		Scope:     sql.NullString{String: scope, Valid: true},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error storing refresh token for client", "client_id", clientId, "error", err)
+		return model.RefreshToken{}, err
+	}
+
+	return toModelRefreshToken(createdRefreshToken), nil
+}
+
+// ConsumeAuthorizationCode marks an unused authorization code as used and returns it
+func (r *SQLOAuthRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (model.AuthorizationCode, error) {
+	code, err := r.DB.ConsumeAuthorizationCode(ctx, codeHash)
+	if err != nil {
+		return model.AuthorizationCode{}, err
+	}
+
+	return model.AuthorizationCode{
+		CodeHash:            code.CodeHash,
+		ClientID:            code.ClientID,
+		UserID:              code.UserID,
+		RedirectURI:         code.RedirectUri,
+		Scope:               code.Scope,
+		CodeChallenge:       code.CodeChallenge,
+		CodeChallengeMethod: code.CodeChallengeMethod,
+		Nonce:               code.Nonce,
+		ExpiresAt:           code.ExpiresAt,
+	}, nil
+}
+
+// UpsertConsent records the scope the user consented to grant the client
+func (r *SQLOAuthRepository) UpsertConsent(ctx context.Context, userId uuid.UUID, clientId string, scope string) error {
+	logging.FromContext(ctx).Info("Recording consent of user for client", "user_id", userId, "client_id", clientId)
+
+	err := r.DB.UpsertOAuthConsent(ctx, database.UpsertOAuthConsentParams{
+		UserID:   userId,
+		ClientID: clientId,
+		Scope:    scope,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error recording consent of user for client", "user_id", userId, "client_id", clientId, "error", err)
+	}
+	return err
+}
+
+// RevokeClientRefreshTokens revokes the refresh tokens issued to the client for the user
+func (r *SQLOAuthRepository) RevokeClientRefreshTokens(ctx context.Context, userId uuid.UUID, clientId string) error {
+	logging.FromContext(ctx).Info("Revoking refresh tokens of client for user", "client_id", clientId, "user_id", userId)
+
+	err := r.DB.RevokeClientRefreshTokens(ctx, database.RevokeClientRefreshTokensParams{
+		UserID:   userId,
+		ClientID: sql.NullString{String: clientId, Valid: true},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error revoking refresh tokens of client for user", "client_id", clientId, "user_id", userId, "error", err)
+	}
+	return err
+}
+
+// DeleteClient removes a client with its codes, consents and refresh tokens
+func (r *SQLOAuthRepository) DeleteClient(ctx context.Context, clientId string) error {
+	logging.FromContext(ctx).Info("Deleting OAuth client", "client_id", clientId)
+
+	err := r.DB.DeleteOAuthClient(ctx, clientId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error deleting OAuth client", "client_id", clientId, "error", err)
+	}
+	return err
+}
+
+// GetClientById returns the client with the given id
+func (r *SQLOAuthRepository) GetClientById(ctx context.Context, clientId string) (model.OAuthClient, error) {
+	client, err := r.DB.GetOAuthClientByID(ctx, clientId)
+	if err != nil {
+		return model.OAuthClient{}, err
+	}
+
+	return toModelOAuthClient(client), nil
+}
+
+// ListClients lists the registered clients
+func (r *SQLOAuthRepository) ListClients(ctx context.Context) ([]model.OAuthClient, error) {
+	clients, err := r.DB.ListOAuthClients(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error listing OAuth clients", "error", err)
+		return nil, err
+	}
+
+	modelClients := make([]model.OAuthClient, 0, len(clients))
+	for _, client := range clients {
+		modelClients = append(modelClients, toModelOAuthClient(client))
+	}
+
+	return modelClients, nil
+}
+
+// GetConsentedScope returns the scope the user consented to grant the client, empty if none
+func (r *SQLOAuthRepository) GetConsentedScope(ctx context.Context, userId uuid.UUID, clientId string) (string, error) {
+	consent, err := r.DB.GetOAuthConsent(ctx, database.GetOAuthConsentParams{
+		UserID:   userId,
+		ClientID: clientId,
+	})
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return consent.Scope, nil
+}
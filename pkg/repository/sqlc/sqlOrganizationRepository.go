@@ -0,0 +1,166 @@
+package sqlc
+
+import (
+	"context"
+
+	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type SQLOrganizationRepository struct {
+	DB *database.Queries
+}
+
+func NewSQLOrganizationRepository(db *database.Queries) *SQLOrganizationRepository {
+	return &SQLOrganizationRepository{
+		DB: db,
+	}
+}
+
+// toModelOrganizationMember converts a database membership to a model membership
+func toModelOrganizationMember(member database.OrganizationMember) model.OrganizationMember {
+	return model.OrganizationMember{
+		OrgID:     member.OrgID,
+		UserID:    member.UserID,
+		OrgRole:   member.OrgRole,
+		CreatedAt: member.CreatedAt,
+	}
+}
+
+// CreateOrganization creates a new organization
+func (r *SQLOrganizationRepository) CreateOrganization(ctx context.Context, organization model.Organization) (model.Organization, error) {
+	logging.FromContext(ctx).Info("Creating organization", "org_slug", organization.Slug)
+
+	createdOrganization, err := r.DB.CreateOrganization(ctx, database.CreateOrganizationParams{
+		ID:   organization.ID,
+		Name: organization.Name,
+		Slug: organization.Slug,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error creating organization", "org_slug", organization.Slug, "error", err)
+		return model.Organization{}, toRepositoryError(err)
+	}
+
+	return model.Organization{
+		ID:        createdOrganization.ID,
+		Name:      createdOrganization.Name,
+		Slug:      createdOrganization.Slug,
+		CreatedAt: createdOrganization.CreatedAt,
+	}, nil
+}
+
+// UpsertOrganizationMember adds the user to the organization or updates their role in it
+func (r *SQLOrganizationRepository) UpsertOrganizationMember(ctx context.Context, orgId uuid.UUID, userId uuid.UUID, orgRole string) (model.OrganizationMember, error) {
+	logging.FromContext(ctx).Info("Setting the role of user in organization", "user_id", userId, "org_id", orgId, "org_role", orgRole)
+
+	member, err := r.DB.UpsertOrganizationMember(ctx, database.UpsertOrganizationMemberParams{
+		OrgID:   orgId,
+		UserID:  userId,
+		OrgRole: orgRole,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error setting the role of user in organization", "user_id", userId, "org_id", orgId, "error", err)
+		return model.OrganizationMember{}, err
+	}
+
+	return toModelOrganizationMember(member), nil
+}
+
+// DeleteOrganizationMember removes the user from the organization
+func (r *SQLOrganizationRepository) DeleteOrganizationMember(ctx context.Context, orgId uuid.UUID, userId uuid.UUID) error {
+	logging.FromContext(ctx).Info("Removing user from organization", "user_id", userId, "org_id", orgId)
+
+	err := r.DB.DeleteOrganizationMember(ctx, database.DeleteOrganizationMemberParams{
+		OrgID:  orgId,
+		UserID: userId,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error removing user from organization", "user_id", userId, "org_id", orgId, "error", err)
+	}
+	return err
+}
+
+// GetOrganizationById returns the organization with the given id
+func (r *SQLOrganizationRepository) GetOrganizationById(ctx context.Context, orgId uuid.UUID) (model.Organization, error) {
+	organization, err := r.DB.GetOrganizationByID(ctx, orgId)
+	if err != nil {
+		return model.Organization{}, err
+	}
+
+	return model.Organization{
+		ID:        organization.ID,
+		Name:      organization.Name,
+		Slug:      organization.Slug,
+		CreatedAt: organization.CreatedAt,
+	}, nil
+}
+
+// ListOrganizations returns a page of organizations ordered by name
+func (r *SQLOrganizationRepository) ListOrganizations(ctx context.Context, limit, offset int32) ([]model.Organization, error) {
+	rows, err := r.DB.ListOrganizations(ctx, database.ListOrganizationsParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching organizations from database", "error", err)
+		return []model.Organization{}, err
+	}
+
+	organizations := make([]model.Organization, 0, len(rows))
+	for _, row := range rows {
+		organizations = append(organizations, model.Organization{
+			ID:        row.ID,
+			Name:      row.Name,
+			Slug:      row.Slug,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+
+	return organizations, nil
+}
+
+// GetOrganizationMember returns the user's membership of the organization
+func (r *SQLOrganizationRepository) GetOrganizationMember(ctx context.Context, orgId uuid.UUID, userId uuid.UUID) (model.OrganizationMember, error) {
+	member, err := r.DB.GetOrganizationMember(ctx, database.GetOrganizationMemberParams{
+		OrgID:  orgId,
+		UserID: userId,
+	})
+	if err != nil {
+		return model.OrganizationMember{}, err
+	}
+
+	return toModelOrganizationMember(member), nil
+}
+
+// GetUserOrganizationMemberships returns the user's memberships, oldest first
+func (r *SQLOrganizationRepository) GetUserOrganizationMemberships(ctx context.Context, userId uuid.UUID) ([]model.OrganizationMember, error) {
+	rows, err := r.DB.GetUserOrganizationMemberships(ctx, userId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching organizations of user", "user_id", userId, "error", err)
+		return []model.OrganizationMember{}, err
+	}
+
+	members := make([]model.OrganizationMember, 0, len(rows))
+	for _, row := range rows {
+		members = append(members, toModelOrganizationMember(row))
+	}
+
+	return members, nil
+}
+
+// GetOrganizationUsers returns a page of the organization's members
+func (r *SQLOrganizationRepository) GetOrganizationUsers(ctx context.Context, orgId uuid.UUID, limit, offset int32) ([]database.User, error) {
+	users, err := r.DB.GetOrganizationUsers(ctx, database.GetOrganizationUsersParams{
+		OrgID:  orgId,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching members of organization", "org_id", orgId, "error", err)
+		return []database.User{}, err
+	}
+
+	return users, nil
+}
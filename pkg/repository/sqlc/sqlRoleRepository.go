@@ -0,0 +1,291 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/tracing"
+	"github.com/google/uuid"
+)
+
+// SQLRoleRepository stores roles through DB. Conn runs the changes spanning
+// several statements in a transaction.
+type SQLRoleRepository struct {
+	DB   *database.Queries
+	Conn *sql.DB
+}
+
+func NewSQLRoleRepository(db *database.Queries, conn *sql.DB) *SQLRoleRepository {
+	return &SQLRoleRepository{
+		DB:   db,
+		Conn: conn,
+	}
+}
+
+// toModelRole converts a database role to a model role
+func toModelRole(role database.Role) model.Role {
+	return model.Role{
+		Name:        role.Name,
+		Description: role.Description,
+		Rank:        role.Rank,
+		BuiltIn:     role.BuiltIn,
+		CreatedAt:   role.CreatedAt,
+	}
+}
+
+// CreateRole creates a new custom role
+func (r *SQLRoleRepository) CreateRole(ctx context.Context, role model.Role) (model.Role, error) {
+	logging.FromContext(ctx).Info("Creating role", "role", role.Name)
+
+	createdRole, err := r.DB.CreateRole(ctx, database.CreateRoleParams{
+		Name:        role.Name,
+		Description: role.Description,
+		Rank:        role.Rank,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error creating role", "role", role.Name, "error", err)
+		return model.Role{}, err
+	}
+
+	return toModelRole(createdRole), nil
+}
+
+// AssignUserRole adds the user to the given role
+func (r *SQLRoleRepository) AssignUserRole(ctx context.Context, userId uuid.UUID, role string) error {
+	logging.FromContext(ctx).Info("Assigning role to user", "role", role, "user_id", userId)
+
+	err := r.DB.AssignUserRole(ctx, database.AssignUserRoleParams{
+		UserID:   userId,
+		RoleName: role,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error assigning role to user", "role", role, "user_id", userId, "error", err)
+	}
+	return err
+}
+
+// RenameRole renames a role and updates its description
+func (r *SQLRoleRepository) RenameRole(ctx context.Context, name string, newName string, description string) (model.Role, error) {
+	logging.FromContext(ctx).Info("Renaming role", "role", name, "new_role", newName)
+
+	role, err := r.DB.RenameRole(ctx, database.RenameRoleParams{
+		NewName:     newName,
+		Description: description,
+		Name:        name,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error renaming role", "role", name, "new_role", newName, "error", err)
+		return model.Role{}, err
+	}
+
+	return toModelRole(role), nil
+}
+
+// SetRolePermissions replaces the permissions granted by the role. Both
+// statements run in one transaction holding a lock on the role, so a failed
+// insert does not leave the role without permissions and concurrent callers
+// do not interleave.
+func (r *SQLRoleRepository) SetRolePermissions(ctx context.Context, role string, permissions []string) error {
+	logging.FromContext(ctx).Info("Setting permissions of role", "role", role)
+
+	tx, err := r.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error starting transaction", "role", role, "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := database.New(tracing.WrapDB(tx))
+	if err := queries.LockRole(ctx, role); err != nil {
+		logging.FromContext(ctx).Error("Error locking role", "role", role, "error", err)
+		return err
+	}
+
+	// drop permissions that are no longer granted
+	err = queries.SetRolePermissions(ctx, database.SetRolePermissionsParams{
+		RoleName:    role,
+		Permissions: permissions,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error removing permissions of role", "role", role, "error", err)
+		return err
+	}
+
+	// add the new ones
+	err = queries.AddRolePermissions(ctx, database.AddRolePermissionsParams{
+		RoleName:    role,
+		Permissions: permissions,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error adding permissions to role", "role", role, "error", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SyncUserPrimaryRole sets the user's role to their highest ranked role
+func (r *SQLRoleRepository) SyncUserPrimaryRole(ctx context.Context, userId uuid.UUID) (model.User, error) {
+	user, err := r.DB.SyncUserPrimaryRole(ctx, userId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error updating the role of user", "user_id", userId, "error", err)
+		return model.User{}, err
+	}
+
+	return model.User{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		LastLogin:     user.LastLogin,
+		AccountStatus: user.AccountStatus,
+		UserRole:      user.UserRole,
+	}, nil
+}
+
+// DeleteRole deletes a role
+func (r *SQLRoleRepository) DeleteRole(ctx context.Context, name string) error {
+	logging.FromContext(ctx).Info("Deleting role", "role", name)
+
+	err := r.DB.DeleteRole(ctx, name)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error deleting role", "role", name, "error", err)
+	}
+	return err
+}
+
+// RevokeUserRole removes the user from the given role
+func (r *SQLRoleRepository) RevokeUserRole(ctx context.Context, userId uuid.UUID, role string) error {
+	logging.FromContext(ctx).Info("Revoking role from user", "role", role, "user_id", userId)
+
+	err := r.DB.RevokeUserRole(ctx, database.RevokeUserRoleParams{
+		UserID:   userId,
+		RoleName: role,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error revoking role from user", "role", role, "user_id", userId, "error", err)
+	}
+	return err
+}
+
+// RevokeUserRoleUnlessLast removes the user from the given role unless they are
+// its last member, reporting whether they were removed. Concurrent revocations
+// are serialized, so they cannot remove the last two members together.
+func (r *SQLRoleRepository) RevokeUserRoleUnlessLast(ctx context.Context, userId uuid.UUID, role string) (bool, error) {
+	logging.FromContext(ctx).Info("Revoking role from user unless they are its last member", "role", role, "user_id", userId)
+
+	revoked, err := r.DB.RevokeUserRoleUnlessLast(ctx, database.RevokeUserRoleUnlessLastParams{
+		UserID:   userId,
+		RoleName: role,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error revoking role from user", "role", role, "user_id", userId, "error", err)
+		return false, err
+	}
+	return revoked > 0, nil
+}
+
+// GetRoleByName returns the role with the given name
+func (r *SQLRoleRepository) GetRoleByName(ctx context.Context, name string) (model.Role, error) {
+	role, err := r.DB.GetRoleByName(ctx, name)
+	if err != nil {
+		return model.Role{}, err
+	}
+
+	return toModelRole(role), nil
+}
+
+// GetRolePermissions returns the permissions granted by the given role
+func (r *SQLRoleRepository) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	permissions, err := r.DB.GetRolePermissions(ctx, role)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching permissions for role", "role", role, "error", err)
+		return []string{}, err
+	}
+
+	return permissions, nil
+}
+
+// ListRoles returns all roles along with their number of members
+func (r *SQLRoleRepository) ListRoles(ctx context.Context) ([]model.Role, error) {
+	rows, err := r.DB.ListRolesWithMemberCount(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching roles from database", "error", err)
+		return []model.Role{}, err
+	}
+
+	roles := make([]model.Role, 0, len(rows))
+	for _, row := range rows {
+		roles = append(roles, model.Role{
+			Name:        row.Name,
+			Description: row.Description,
+			Rank:        row.Rank,
+			BuiltIn:     row.BuiltIn,
+			CreatedAt:   row.CreatedAt,
+			MemberCount: row.MemberCount,
+		})
+	}
+
+	return roles, nil
+}
+
+// ListPermissions returns every permission that can be attached to a role
+func (r *SQLRoleRepository) ListPermissions(ctx context.Context) ([]model.Permission, error) {
+	rows, err := r.DB.ListPermissions(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching permissions from database", "error", err)
+		return []model.Permission{}, err
+	}
+
+	permissions := make([]model.Permission, 0, len(rows))
+	for _, row := range rows {
+		permissions = append(permissions, model.Permission{
+			Name:        row.Name,
+			Description: row.Description,
+		})
+	}
+
+	return permissions, nil
+}
+
+// CountRoleMembers returns the number of users holding the given role
+func (r *SQLRoleRepository) CountRoleMembers(ctx context.Context, role string) (int64, error) {
+	return r.DB.CountRoleMembers(ctx, role)
+}
+
+// CountActiveRoleMembers returns the number of active users holding the given role
+func (r *SQLRoleRepository) CountActiveRoleMembers(ctx context.Context, role string) (int64, error) {
+	return r.DB.CountActiveRoleMembers(ctx, role)
+}
+
+// GetUserRoles returns the roles held by the user, highest ranked first
+func (r *SQLRoleRepository) GetUserRoles(ctx context.Context, userId uuid.UUID) ([]model.Role, error) {
+	rows, err := r.DB.GetUserRoles(ctx, userId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error fetching roles of user", "user_id", userId, "error", err)
+		return []model.Role{}, err
+	}
+
+	roles := make([]model.Role, 0, len(rows))
+	for _, row := range rows {
+		roles = append(roles, toModelRole(row))
+	}
+
+	return roles, nil
+}
+
+// UserHasPermission reports whether any of the user's roles grants the given permission
+func (r *SQLRoleRepository) UserHasPermission(ctx context.Context, userId uuid.UUID, permission string) (bool, error) {
+	granted, err := r.DB.UserHasPermission(ctx, database.UserHasPermissionParams{
+		UserID:         userId,
+		PermissionName: permission,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error checking permission for user", "permission", permission, "user_id", userId, "error", err)
+		return false, err
+	}
+
+	return granted, nil
+}
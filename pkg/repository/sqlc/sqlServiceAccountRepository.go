@@ -0,0 +1,187 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/google/uuid"
+)
+
+type SQLServiceAccountRepository struct {
+	DB *database.Queries
+}
+
+func NewSQLServiceAccountRepository(db *database.Queries) *SQLServiceAccountRepository {
+	return &SQLServiceAccountRepository{
+		DB: db,
+	}
+}
+
+// toModelServiceAccount converts a database service account to a model service account
+func toModelServiceAccount(serviceAccount database.ServiceAccount) model.ServiceAccount {
+	modelServiceAccount := model.ServiceAccount{
+		ID:          serviceAccount.ID,
+		ClientID:    serviceAccount.ClientID,
+		Name:        serviceAccount.Name,
+		Description: serviceAccount.Description,
+		Permissions: serviceAccount.Permissions,
+		PublicKey:   serviceAccount.PublicKey.String,
+		HasSecret:   serviceAccount.SecretHash.Valid,
+		CreatedAt:   serviceAccount.CreatedAt,
+		SecretHash:  serviceAccount.SecretHash.String,
+	}
+	if modelServiceAccount.Permissions == nil {
+		modelServiceAccount.Permissions = []string{}
+	}
+	if serviceAccount.OrgID.Valid {
+		modelServiceAccount.OrgID = &serviceAccount.OrgID.UUID
+	}
+	if serviceAccount.CreatedBy.Valid {
+		modelServiceAccount.CreatedBy = &serviceAccount.CreatedBy.UUID
+	}
+	if serviceAccount.LastUsedAt.Valid {
+		modelServiceAccount.LastUsedAt = &serviceAccount.LastUsedAt.Time
+	}
+
+	return modelServiceAccount
+}
+
+func toNullUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+// CreateServiceAccount stores a service account
+func (r *SQLServiceAccountRepository) CreateServiceAccount(ctx context.Context, serviceAccount model.ServiceAccount) (model.ServiceAccount, error) {
+	logging.FromContext(ctx).Info("Creating service account", "service_account", serviceAccount.Name)
+
+	permissions := serviceAccount.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	createdServiceAccount, err := r.DB.CreateServiceAccount(ctx, database.CreateServiceAccountParams{
+		ID:          uuid.New(),
+		ClientID:    serviceAccount.ClientID,
+		Name:        serviceAccount.Name,
+		Description: serviceAccount.Description,
+		OrgID:       toNullUUID(serviceAccount.OrgID),
+		SecretHash:  sql.NullString{String: serviceAccount.SecretHash, Valid: serviceAccount.SecretHash != ""},
+		PublicKey:   sql.NullString{String: serviceAccount.PublicKey, Valid: serviceAccount.PublicKey != ""},
+		Permissions: permissions,
+		CreatedBy:   toNullUUID(serviceAccount.CreatedBy),
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error creating service account", "service_account", serviceAccount.Name, "error", err)
+		return model.ServiceAccount{}, err
+	}
+
+	return toModelServiceAccount(createdServiceAccount), nil
+}
+
+// UpdateServiceAccount updates the name, description, public key and permissions of a service account
+func (r *SQLServiceAccountRepository) UpdateServiceAccount(ctx context.Context, serviceAccount model.ServiceAccount) (model.ServiceAccount, error) {
+	logging.FromContext(ctx).Info("Updating service account", "service_account_id", serviceAccount.ID)
+
+	permissions := serviceAccount.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	updatedServiceAccount, err := r.DB.UpdateServiceAccount(ctx, database.UpdateServiceAccountParams{
+		ID:          serviceAccount.ID,
+		Name:        serviceAccount.Name,
+		Description: serviceAccount.Description,
+		PublicKey:   sql.NullString{String: serviceAccount.PublicKey, Valid: serviceAccount.PublicKey != ""},
+		Permissions: permissions,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error updating service account", "service_account_id", serviceAccount.ID, "error", err)
+		return model.ServiceAccount{}, err
+	}
+
+	return toModelServiceAccount(updatedServiceAccount), nil
+}
+
+// UpdateServiceAccountSecret replaces the secret hash of a service account, removing it if empty
+func (r *SQLServiceAccountRepository) UpdateServiceAccountSecret(ctx context.Context, serviceAccountId uuid.UUID, secretHash string) error {
+	logging.FromContext(ctx).Info("Rotating the secret of service account", "service_account_id", serviceAccountId)
+
+	err := r.DB.UpdateServiceAccountSecret(ctx, database.UpdateServiceAccountSecretParams{
+		ID:         serviceAccountId,
+		SecretHash: sql.NullString{String: secretHash, Valid: secretHash != ""},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error rotating the secret of service account", "service_account_id", serviceAccountId, "error", err)
+	}
+	return err
+}
+
+// TouchServiceAccount records that the service account authenticated
+func (r *SQLServiceAccountRepository) TouchServiceAccount(ctx context.Context, serviceAccountId uuid.UUID) error {
+	err := r.DB.TouchServiceAccount(ctx, serviceAccountId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error recording use of service account", "service_account_id", serviceAccountId, "error", err)
+	}
+	return err
+}
+
+// DeleteServiceAccount deletes a service account and reports whether there was one
+func (r *SQLServiceAccountRepository) DeleteServiceAccount(ctx context.Context, serviceAccountId uuid.UUID) (bool, error) {
+	logging.FromContext(ctx).Info("Deleting service account", "service_account_id", serviceAccountId)
+
+	deleted, err := r.DB.DeleteServiceAccount(ctx, serviceAccountId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error deleting service account", "service_account_id", serviceAccountId, "error", err)
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
+
+// GetServiceAccountById returns the service account with the id
+func (r *SQLServiceAccountRepository) GetServiceAccountById(ctx context.Context, serviceAccountId uuid.UUID) (model.ServiceAccount, error) {
+	serviceAccount, err := r.DB.GetServiceAccountByID(ctx, serviceAccountId)
+	if err != nil {
+		return model.ServiceAccount{}, err
+	}
+
+	return toModelServiceAccount(serviceAccount), nil
+}
+
+// GetServiceAccountByClientId returns the service account with the client id
+func (r *SQLServiceAccountRepository) GetServiceAccountByClientId(ctx context.Context, clientId string) (model.ServiceAccount, error) {
+	serviceAccount, err := r.DB.GetServiceAccountByClientID(ctx, clientId)
+	if err != nil {
+		return model.ServiceAccount{}, err
+	}
+
+	return toModelServiceAccount(serviceAccount), nil
+}
+
+// ListServiceAccounts lists the service accounts of the organization, or every service account if orgId is not valid
+func (r *SQLServiceAccountRepository) ListServiceAccounts(ctx context.Context, orgId uuid.NullUUID) ([]model.ServiceAccount, error) {
+	var serviceAccounts []database.ServiceAccount
+	var err error
+	if orgId.Valid {
+		serviceAccounts, err = r.DB.ListOrganizationServiceAccounts(ctx, orgId)
+	} else {
+		serviceAccounts, err = r.DB.ListServiceAccounts(ctx)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error listing service accounts", "error", err)
+		return nil, err
+	}
+
+	modelServiceAccounts := make([]model.ServiceAccount, 0, len(serviceAccounts))
+	for _, serviceAccount := range serviceAccounts {
+		modelServiceAccounts = append(modelServiceAccounts, toModelServiceAccount(serviceAccount))
+	}
+
+	return modelServiceAccounts, nil
+}
@@ -4,11 +4,12 @@ import (
 	"context"
 	"database/sql"
 
-	"log"
 	"time"
 
 	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
 	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/tracing"
 	"github.com/google/uuid"
 )
 
@@ -23,7 +24,11 @@ func NewSQLUserRepository(db *database.Queries) *SQLUserRepository {
 }
 
 // CreateUser creates a new user
-func (r *SQLUserRepository) CreateUser(ctx context.Context, user model.UserRegister) (model.User, error) {
+func (r *SQLUserRepository) CreateUser(ctx context.Context, user model.UserRegister) (_ model.User, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.CreateUser")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	// insert user into database
 	createdUser, err := r.DB.CreateUser(ctx, database.CreateUserParams{
 		ID:             user.ID,
@@ -35,7 +40,7 @@ func (r *SQLUserRepository) CreateUser(ctx context.Context, user model.UserRegis
 		UserRole:       user.UserRole,
 	})
 	if err != nil {
-		return model.User{}, err
+		return model.User{}, toRepositoryError(err)
 	}
 
 	// return created user
@@ -53,16 +58,24 @@ func (r *SQLUserRepository) CreateUser(ctx context.Context, user model.UserRegis
 }
 
 // CountAllUsersByUsername returns the number of users with the given username
-func (r *SQLUserRepository) CountAllUsersByUsername(ctx context.Context, username string) (int64, error) {
+func (r *SQLUserRepository) CountAllUsersByUsername(ctx context.Context, username string) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.CountAllUsersByUsername")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	return r.DB.CountAllUsersByUsername(ctx, username)
 }
 
 // GetUserByEmail returns the user with the given email
-func (r *SQLUserRepository) GetUserByEmail(ctx context.Context, email string) (model.User, error) {
+func (r *SQLUserRepository) GetUserByEmail(ctx context.Context, email string) (_ model.User, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.GetUserByEmail")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	// get user from database
 	user, err := r.DB.FindUserByEmail(ctx, email)
 	if err != nil {
-		return model.User{}, err
+		return model.User{}, toRepositoryError(err)
 	}
 
 	// return user
@@ -86,8 +99,12 @@ func (r *SQLUserRepository) GetUserByEmail(ctx context.Context, email string) (m
 }
 
 // StoreRefreshToken stores the refresh token in the database
-func (r *SQLUserRepository) StoreRefreshToken(ctx context.Context, userId uuid.UUID, refreshToken string, expiresAt time.Time) (model.RefreshToken, error) {
-	log.Printf("Storing refresh token for user with id %s", userId.String())
+func (r *SQLUserRepository) StoreRefreshToken(ctx context.Context, userId uuid.UUID, refreshToken string, expiresAt time.Time) (_ model.RefreshToken, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.StoreRefreshToken")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	logging.FromContext(ctx).Debug("Storing refresh token for user", "user_id", userId)
 	// insert refresh token into database
 	createdRefreshToken, err := r.DB.StoreRefreshToken(ctx, database.StoreRefreshTokenParams{
 		ID:        uuid.New(),
@@ -97,42 +114,188 @@ func (r *SQLUserRepository) StoreRefreshToken(ctx context.Context, userId uuid.U
 		ExpiresAt: expiresAt,
 	})
 	if err != nil {
-		log.Printf("Error storing refresh token for user with id %s: %s", userId.String(), err.Error())
-		return model.RefreshToken{}, err
+		logging.FromContext(ctx).Error("Error storing refresh token for user", "user_id", userId, "error", err)
+		return model.RefreshToken{}, toRepositoryError(err)
 	}
 
 	// return refresh token
-	log.Printf("Successfully stored refresh token for user with id %s", userId.String())
+	logging.FromContext(ctx).Debug("Successfully stored refresh token for user", "user_id", userId)
+	return toModelRefreshToken(createdRefreshToken), nil
+}
+
+// toModelRefreshToken converts a database refresh token to a model refresh token
+func toModelRefreshToken(refreshToken database.RefreshToken) model.RefreshToken {
 	return model.RefreshToken{
-		ID:        createdRefreshToken.ID,
-		UserID:    createdRefreshToken.UserID,
-		Token:     createdRefreshToken.Token,
-		CreatedAt: createdRefreshToken.CreatedAt,
-		ExpiresAt: createdRefreshToken.ExpiresAt,
-		RevokedAt: createdRefreshToken.RevokedAt,
-	}, nil
+		ID:        refreshToken.ID,
+		UserID:    refreshToken.UserID,
+		Token:     refreshToken.Token,
+		CreatedAt: refreshToken.CreatedAt,
+		ExpiresAt: refreshToken.ExpiresAt,
+		RevokedAt: refreshToken.RevokedAt,
+		ClientID:  refreshToken.ClientID,
+		Scope:     refreshToken.Scope,
+	}
+}
+
+// GetRefreshToken returns the stored refresh token
+func (r *SQLUserRepository) GetRefreshToken(ctx context.Context, refreshToken string) (_ model.RefreshToken, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.GetRefreshToken")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	storedRefreshToken, err := r.DB.GetRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return model.RefreshToken{}, toRepositoryError(err)
+	}
+
+	return toModelRefreshToken(storedRefreshToken), nil
+}
+
+// RevokeRefreshToken revokes the refresh token
+func (r *SQLUserRepository) RevokeRefreshToken(ctx context.Context, refreshTokenId uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.RevokeRefreshToken")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	logging.FromContext(ctx).Info("Revoking refresh token", "refresh_token_id", refreshTokenId)
+
+	err = r.DB.RevokeRefreshToken(ctx, refreshTokenId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error revoking refresh token", "refresh_token_id", refreshTokenId, "error", err)
+	}
+	return err
+}
+
+// RevokeUserRefreshTokens revokes every refresh token of the user and returns how many were revoked
+func (r *SQLUserRepository) RevokeUserRefreshTokens(ctx context.Context, userId uuid.UUID) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.RevokeUserRefreshTokens")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	logging.FromContext(ctx).Info("Revoking refresh tokens of user", "user_id", userId)
+
+	revoked, err := r.DB.RevokeUserRefreshTokens(ctx, userId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error revoking refresh tokens of user", "user_id", userId, "error", err)
+	}
+	return revoked, err
+}
+
+// RevokeAllRefreshTokens revokes the refresh tokens of every user and returns how many were revoked
+func (r *SQLUserRepository) RevokeAllRefreshTokens(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.RevokeAllRefreshTokens")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	logging.FromContext(ctx).Info("Revoking all refresh tokens")
+
+	revoked, err := r.DB.RevokeAllRefreshTokens(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error revoking all refresh tokens", "error", err)
+	}
+	return revoked, err
+}
+
+// toModelMagicLink converts a database magic link to a model magic link
+func toModelMagicLink(magicLink database.MagicLink) model.MagicLink {
+	return model.MagicLink{
+		ID:        magicLink.ID,
+		UserID:    magicLink.UserID,
+		ExpiresAt: magicLink.ExpiresAt,
+		UsedAt:    magicLink.UsedAt,
+		CreatedAt: magicLink.CreatedAt,
+	}
+}
+
+// CreateMagicLink stores a login link for the user, bound to a browser if the binding hash is not empty
+func (r *SQLUserRepository) CreateMagicLink(ctx context.Context, userId uuid.UUID, browserBindingHash string, expiresAt time.Time) (_ model.MagicLink, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.CreateMagicLink")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	logging.FromContext(ctx).Debug("Storing magic link for user", "user_id", userId)
+
+	createdMagicLink, err := r.DB.CreateMagicLink(ctx, database.CreateMagicLinkParams{
+		ID:                 uuid.New(),
+		UserID:             userId,
+		BrowserBindingHash: sql.NullString{String: browserBindingHash, Valid: browserBindingHash != ""},
+		ExpiresAt:          expiresAt,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error storing magic link for user", "user_id", userId, "error", err)
+		return model.MagicLink{}, toRepositoryError(err)
+	}
+
+	return toModelMagicLink(createdMagicLink), nil
+}
+
+// ConsumeMagicLink marks an unused, unexpired magic link as used and returns it.
+// Links bound to a browser are only consumed with the matching binding hash.
+func (r *SQLUserRepository) ConsumeMagicLink(ctx context.Context, magicLinkId uuid.UUID, browserBindingHash string) (_ model.MagicLink, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.ConsumeMagicLink")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	magicLink, err := r.DB.ConsumeMagicLink(ctx, database.ConsumeMagicLinkParams{
+		ID:                 magicLinkId,
+		BrowserBindingHash: sql.NullString{String: browserBindingHash, Valid: browserBindingHash != ""},
+	})
+	if err != nil {
+		return model.MagicLink{}, toRepositoryError(err)
+	}
+
+	return toModelMagicLink(magicLink), nil
+}
+
+// CountRecentMagicLinks counts the magic links issued to the user since the given time
+func (r *SQLUserRepository) CountRecentMagicLinks(ctx context.Context, userId uuid.UUID, since time.Time) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.CountRecentMagicLinks")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	return r.DB.CountRecentMagicLinks(ctx, database.CountRecentMagicLinksParams{
+		UserID:    userId,
+		CreatedAt: since,
+	})
+}
+
+// CountActiveRefreshTokens counts the refresh tokens that are neither revoked nor expired
+func (r *SQLUserRepository) CountActiveRefreshTokens(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.CountActiveRefreshTokens")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	return r.DB.CountActiveRefreshTokens(ctx)
 }
 
 // UpdateUserLastLogin updates the last login of the user
-func (r *SQLUserRepository) UpdateUserLastLogin(ctx context.Context, userId uuid.UUID) error {
-	log.Printf("Updating last login of user with id %s", userId.String())
+func (r *SQLUserRepository) UpdateUserLastLogin(ctx context.Context, userId uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.UpdateUserLastLogin")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
 
-	err := r.DB.UpdateUserLastLogin(ctx, database.UpdateUserLastLoginParams{
+	logging.FromContext(ctx).Debug("Updating last login of user", "user_id", userId)
+
+	err = r.DB.UpdateUserLastLogin(ctx, database.UpdateUserLastLoginParams{
 		ID:        userId,
 		LastLogin: sql.NullTime{Time: time.Now().UTC(), Valid: true},
 	})
 	if err != nil {
-		log.Printf("Error updating last login of user with id %s: %s", userId.String(), err.Error())
+		logging.FromContext(ctx).Error("Error updating last login of user", "user_id", userId, "error", err)
 	}
 	return err
 }
 
 // GetUserById returns the user with the given id
-func (r *SQLUserRepository) GetUserById(ctx context.Context, userId uuid.UUID) (model.User, error) {
+func (r *SQLUserRepository) GetUserById(ctx context.Context, userId uuid.UUID) (_ model.User, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.GetUserById")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	// get user from database
 	user, err := r.DB.FindUserByID(ctx, userId)
 	if err != nil {
-		return model.User{}, err
+		return model.User{}, toRepositoryError(err)
 	}
 
 	// return user
@@ -156,8 +319,12 @@ func (r *SQLUserRepository) GetUserById(ctx context.Context, userId uuid.UUID) (
 }
 
 // UpdateUser updates a user
-func (r *SQLUserRepository) UpdateUser(ctx context.Context, user model.User) (model.User, error) {
-	log.Printf("Updating user with id %s", user.ID.String())
+func (r *SQLUserRepository) UpdateUser(ctx context.Context, user model.User) (_ model.User, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.UpdateUser")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	logging.FromContext(ctx).Info("Updating user", "user_id", user.ID)
 
 	// update user
 	updatedUser, err := r.DB.UpdateUser(ctx, database.UpdateUserParams{
@@ -171,8 +338,8 @@ func (r *SQLUserRepository) UpdateUser(ctx context.Context, user model.User) (mo
 		Gender:      user.Gender,
 	})
 	if err != nil {
-		log.Printf("Error updating user with id %s: %s", user.ID.String(), err.Error())
-		return model.User{}, err
+		logging.FromContext(ctx).Error("Error updating user", "user_id", user.ID, "error", err)
+		return model.User{}, toRepositoryError(err)
 	}
 
 	// return updated user
@@ -195,8 +362,12 @@ func (r *SQLUserRepository) UpdateUser(ctx context.Context, user model.User) (mo
 }
 
 // UpdateUserProfilePicture updates the profile picture of a user
-func (r *SQLUserRepository) UpdateUserProfilePicture(ctx context.Context, user model.User) (model.User, error) {
-	log.Printf("Updating profile picture of user with id %s", user.ID.String())
+func (r *SQLUserRepository) UpdateUserProfilePicture(ctx context.Context, user model.User) (_ model.User, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.UpdateUserProfilePicture")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	logging.FromContext(ctx).Info("Updating profile picture of user", "user_id", user.ID)
 
 	// update user
 	updatedUser, err := r.DB.UpdateUserProfilePicture(ctx, database.UpdateUserProfilePictureParams{
@@ -204,8 +375,8 @@ func (r *SQLUserRepository) UpdateUserProfilePicture(ctx context.Context, user m
 		ProfilePicture: user.ProfilePicture,
 	})
 	if err != nil {
-		log.Printf("Error updating profile picture of user with id %s: %s", user.ID.String(), err.Error())
-		return model.User{}, err
+		logging.FromContext(ctx).Error("Error updating profile picture of user", "user_id", user.ID, "error", err)
+		return model.User{}, toRepositoryError(err)
 	}
 
 	// return updated user
@@ -218,88 +389,39 @@ func (r *SQLUserRepository) UpdateUserProfilePicture(ctx context.Context, user m
 }
 
 // UpdateUserPassword updates the password of a user
-func (r *SQLUserRepository) UpdateUserPassword(ctx context.Context, userId uuid.UUID, newPassword string) error {
-	log.Printf("Updating password of user with id %s", userId.String())
+func (r *SQLUserRepository) UpdateUserPassword(ctx context.Context, userId uuid.UUID, newPassword string) (err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.UpdateUserPassword")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	logging.FromContext(ctx).Info("Updating password of user", "user_id", userId)
 
 	// update user
-	_, err := r.DB.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+	_, err = r.DB.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
 		ID:             userId,
 		HashedPassword: newPassword,
 	})
 	if err != nil {
-		log.Printf("Error updating password of user with id %s: %s", userId.String(), err.Error())
+		logging.FromContext(ctx).Error("Error updating password of user", "user_id", userId, "error", err)
 	}
-	return err
+	return toRepositoryError(err)
 }
 
-// PromoteUserToAdmin promotes a regular user to an admin
-func (r *SQLUserRepository) PromoteUserToAdmin(ctx context.Context, userId uuid.UUID) (model.User, error){
-	log.Printf("Updating the role of user with id %s to admin status", userId.String())
-
-	// update user role
-	user, err := r.DB.PromoteUserToAdmin(ctx, userId)
-	if err != nil {
-		log.Printf("Error updating the role of user with id %s to admin status: %s", userId.String(), err.Error())
-		return model.User{}, err
-	}
-	// Return updated user
-	return model.User{
-		Username:       user.Username,
-		Email:          user.Email,
-		LastLogin:      user.LastLogin,
-		AccountStatus:  user.AccountStatus,
-		UserRole:       user.UserRole,
-	}, nil
-}
-
-// PromoteUserToSuperAdmin promotes a regular user to a super admin
-func (r *SQLUserRepository) PromoteUserToSuperAdmin(ctx context.Context, userId uuid.UUID) (model.User, error){
-	log.Printf("Updating the role of user with id %s to super admin status", userId.String())
-
-	// update user
-	user, err := r.DB.PromoteUserToSuperAdmin(ctx, userId)
-	if err != nil {
-		log.Printf("Error updating the role of user with id %s to super admin status: %s", userId.String(), err.Error())
-		return model.User{}, err
-	}
-	return model.User{
-		Username:       user.Username,
-		Email:          user.Email,
-		LastLogin:      user.LastLogin,
-		AccountStatus:  user.AccountStatus,
-		UserRole:       user.UserRole,
-	}, nil
-}
+// SuspendUser suspendds an active user account
+func (r *SQLUserRepository) SuspendUser(ctx context.Context, userId uuid.UUID) (_ model.User, err error){
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.SuspendUser")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
 
-// DemoteSuperAdminToAdmin demotes a superadmin to an admin
-func (r *SQLUserRepository) DemoteSuperAdminToAdmin(ctx context.Context, userId uuid.UUID)(model.User, error){
-	log.Printf("Updating the role of super admin with id %s to admin status", userId.String())
+	logging.FromContext(ctx).Info("Suspending user account", "user_id", userId)
 
-	// update user
-	admin, err := r.DB.DemoteSuperAdminToAdmin(ctx, userId)
+	// suspending user
+	user, err := r.DB.SuspendUser(ctx, userId)
 	if err != nil{
-		log.Printf("Error updating the role of super admin with id %s to admin status: %s", userId.String(), err.Error())
-		return model.User{}, err
+		logging.FromContext(ctx).Error("Error suspending user account", "user_id", userId, "error", err)
+		return model.User{}, toRepositoryError(err)
 	}
-	return model.User{
-		Username:       admin.Username,
-		Email:          admin.Email,
-		LastLogin:      admin.LastLogin,
-		AccountStatus:  admin.AccountStatus,
-		UserRole:       admin.UserRole,
-	}, nil
-}
-
-// DemoteSuperAdminToUser demotes a superadmin to a regular user
-func (r *SQLUserRepository) DemoteSuperAdminToUser(ctx context.Context, userId uuid.UUID)(model.User, error){
-	log.Printf("Updating the role of super admin with id %s to user status", userId.String())
 
-	// update user
-	user, err := r.DB.DemoteSuperAdminToUser(ctx, userId)
-	if err != nil{
-		log.Printf("Error updating the role of super admin with id %s to user status: %s", userId.String(), err.Error())
-		return model.User{}, err
-	}
 	return model.User{
 		Username:       user.Username,
 		Email:          user.Email,
@@ -309,15 +431,18 @@ func (r *SQLUserRepository) DemoteSuperAdminToUser(ctx context.Context, userId u
 	}, nil
 }
 
-// DemoteAdminToUser demotes an admin to a regular user
-func (r *SQLUserRepository) DemoteAdminToUser(ctx context.Context, userId uuid.UUID)(model.User, error){
-	log.Printf("Updating the role of super admin with id %s to user status", userId.String())
+// RecoverUser returns a a suspended user to an active user
+func (r *SQLUserRepository) RecoverUser(ctx context.Context, userId uuid.UUID) (_ model.User, err error){
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.RecoverUser")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
 
+	logging.FromContext(ctx).Info("Recovering user to an active one", "user_id", userId)
 	// update user
-	user, err := r.DB.DemoteAdminToUser(ctx, userId)
-	if err != nil{
-		log.Printf("Error updating the role of admin with id %s to user status: %s", userId.String(), err.Error())
-		return model.User{}, err
+	user, err := r.DB.RecoverUser(ctx, userId)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error recovering user to an active one", "user_id", userId, "error", err)
+		return model.User{}, toRepositoryError(err)
 	}
 	return model.User{
 		Username:       user.Username,
@@ -328,51 +453,39 @@ func (r *SQLUserRepository) DemoteAdminToUser(ctx context.Context, userId uuid.U
 	}, nil
 }
 
-// SuspendUser suspendds an active user account
-func (r *SQLUserRepository) SuspendUser(ctx context.Context, userId uuid.UUID) (model.User, error){
-	log.Printf("Suspending user account with id %s :", userId.String())
+// SetAccountStatusUnlessLastSuperAdmin sets the account status of the user
+// unless they would be the last active super administrator to lose it,
+// reporting whether it was set. Concurrent changes are serialized, so they
+// cannot deactivate the last two super administrators together.
+func (r *SQLUserRepository) SetAccountStatusUnlessLastSuperAdmin(ctx context.Context, userId uuid.UUID, status string) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.SetAccountStatusUnlessLastSuperAdmin")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
 
-	// suspending user
-	user, err := r.DB.SuspendUser(ctx, userId)
-	if err != nil{
-		log.Printf("Error suspending user account with id %s: %s", userId.String(), err.Error())
-		return model.User{}, err
-	}
+	logging.FromContext(ctx).Info("Setting the account status of user unless they are the last active super administrator", "user_id", userId, "account_status", status)
 
-	return model.User{
-		Username:       user.Username,
-		Email:          user.Email,
-		LastLogin:      user.LastLogin,
-		AccountStatus:  user.AccountStatus,
-		UserRole:       user.UserRole,
-	}, nil
-}
-
-// RecoverUser returns a a suspended user to an active user
-func (r *SQLUserRepository) RecoverUser(ctx context.Context, userId uuid.UUID) (model.User, error){
-	log.Printf("Recovering user with id %s to an active one:", userId.String())
-	// update user
-	user, err := r.DB.RecoverUser(ctx, userId)
+	updated, err := r.DB.SetAccountStatusUnlessLastSuperAdmin(ctx, database.SetAccountStatusUnlessLastSuperAdminParams{
+		AccountStatus: status,
+		ID:            userId,
+	})
 	if err != nil {
-		log.Printf("Error recovering user with id %s to an active one: %s", userId.String(), err.Error())
-		return model.User{}, err
+		logging.FromContext(ctx).Error("Error setting the account status of user", "user_id", userId, "error", err)
+		return false, toRepositoryError(err)
 	}
-	return model.User{
-		Username:       user.Username,
-		Email:          user.Email,
-		LastLogin:      user.LastLogin,
-		AccountStatus:  user.AccountStatus,
-		UserRole:       user.UserRole,
-	}, nil
+	return updated > 0, nil
 }
 
-func (r *SQLUserRepository) DeleteUser(ctx context.Context, userId uuid.UUID) error{
-	log.Printf("Changing the account status of user with id %s to inactive:", userId.String())
+func (r *SQLUserRepository) DeleteUser(ctx context.Context, userId uuid.UUID) (err error){
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.DeleteUser")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	logging.FromContext(ctx).Info("Changing the account status of user to inactive", "user_id", userId)
 
 	// deleting the user
-	err := r.DB.DeleteUser(ctx, userId)
+	err = r.DB.DeleteUser(ctx, userId)
 	if err != nil{
-		log.Printf("Error deleting user with id %s : %s", userId.String(), err.Error())
+		logging.FromContext(ctx).Error("Error deleting user", "user_id", userId, "error", err)
 		return err
 	}
 	return nil
@@ -380,14 +493,19 @@ func (r *SQLUserRepository) DeleteUser(ctx context.Context, userId uuid.UUID) er
 
 
 // GetAllUsers returns a list of all accounts ever registered
-func (r *SQLUserRepository) GetAllUsers(ctx context.Context, limit, offset int32) ([]database.User, error){
+func (r *SQLUserRepository) GetAllUsers(ctx context.Context, orgId uuid.NullUUID, limit, offset int32) (_ []database.User, err error){
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.GetAllUsers")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	// Fetching all users from the database
 	users, err := r.DB.GetAllUsers(ctx, database.GetAllUsersParams{
+		OrgID: orgId,
 		Limit: limit,
 		Offset: offset,
 	})
 	if err != nil{
-		log.Printf("Error fetching all users from database: %s", err)
+		logging.FromContext(ctx).Error("Error fetching all users from database", "error", err)
 		return []database.User{}, err
 	}
 	
@@ -395,15 +513,20 @@ func (r *SQLUserRepository) GetAllUsers(ctx context.Context, limit, offset int32
 }
 
 // GetAdminUsers returns a list of admin users
-func (r *SQLUserRepository) GetAdminUsers(ctx context.Context, limit, offset int32) ([]database.User, error){
+func (r *SQLUserRepository) GetAdminUsers(ctx context.Context, orgId uuid.NullUUID, limit, offset int32) (_ []database.User, err error){
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.GetAdminUsers")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	// Festching all administrators from the database
 	admins, err := r.DB.GetAdminUsers(ctx, database.GetAdminUsersParams{
+		OrgID: orgId,
 		Limit: limit,
 		Offset: offset,
 	})
 
 	if err != nil{
-		log.Printf("Error fetching administrators from database %s", err)
+		logging.FromContext(ctx).Error("Error fetching administrators from database", "error", err)
 		return []database.User{}, err
 	}
 
@@ -411,15 +534,20 @@ func (r *SQLUserRepository) GetAdminUsers(ctx context.Context, limit, offset int
 }
 
 // GetSuperAdminUsers returns a list of super admin users
-func (r *SQLUserRepository) GetSuperAdminUsers(ctx context.Context, limit, offset int32) ([]database.User, error){
+func (r *SQLUserRepository) GetSuperAdminUsers(ctx context.Context, orgId uuid.NullUUID, limit, offset int32) (_ []database.User, err error){
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.GetSuperAdminUsers")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	// Festching all administrators from the database
 	superAdmins, err := r.DB.GetSuperAdminUsers(ctx, database.GetSuperAdminUsersParams{
+		OrgID: orgId,
 		Limit: limit,
 		Offset: offset,
 	})
 
 	if err != nil{
-		log.Printf("Error fetching super administrators from database %s", err)
+		logging.FromContext(ctx).Error("Error fetching super administrators from database", "error", err)
 		return []database.User{}, err
 	}
 
@@ -427,15 +555,20 @@ func (r *SQLUserRepository) GetSuperAdminUsers(ctx context.Context, limit, offse
 }
 
 // GetActiveUsers returns a list of active users
-func (r *SQLUserRepository) GetActiveUsers(ctx context.Context, limit, offset int32) ([]database.User, error){
+func (r *SQLUserRepository) GetActiveUsers(ctx context.Context, orgId uuid.NullUUID, limit, offset int32) (_ []database.User, err error){
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.GetActiveUsers")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	// Fetching active users from the database
 	activeUsers, err := r.DB.GetActiveUsers(ctx, database.GetActiveUsersParams{
+		OrgID: orgId,
 		Offset: offset,
 		Limit: limit,
 	})
 
 	if err != nil{
-		log.Printf("Error fetching active users from the database %s", err)
+		logging.FromContext(ctx).Error("Error fetching active users from the database", "error", err)
 		return []database.User{}, err
 	}
 
@@ -443,15 +576,20 @@ func (r *SQLUserRepository) GetActiveUsers(ctx context.Context, limit, offset in
 }
 
 // GetInactiveUsers returns a list of inactive users
-func (r *SQLUserRepository) GetInactiveUsers(ctx context.Context, limit, offset int32) ([]database.User, error){
+func (r *SQLUserRepository) GetInactiveUsers(ctx context.Context, orgId uuid.NullUUID, limit, offset int32) (_ []database.User, err error){
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.GetInactiveUsers")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	// Fetching inactive users from the database
 	inactiveUsers, err := r.DB.GetInactiveUsers(ctx, database.GetInactiveUsersParams{
+		OrgID: orgId,
 		Limit: limit,
 		Offset: offset,
 	})
 
 	if err != nil{
-		log.Printf("Error fetching inactive users from the database: %s", err)
+		logging.FromContext(ctx).Error("Error fetching inactive users from the database", "error", err)
 		return []database.User{}, err
 	}
 
@@ -459,15 +597,20 @@ func (r *SQLUserRepository) GetInactiveUsers(ctx context.Context, limit, offset
 }
 
 // GetSuspendedUsers returns a list of suspended users
-func (r *SQLUserRepository) GetSuspendedUsers(ctx context.Context, limit, offset int32) ([]database.User, error){
+func (r *SQLUserRepository) GetSuspendedUsers(ctx context.Context, orgId uuid.NullUUID, limit, offset int32) (_ []database.User, err error){
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.GetSuspendedUsers")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	// Fetching suspended users from the database
 	suspendedUsers, err := r.DB.GetSuspendedUsers(ctx, database.GetSuspendedUsersParams{
+		OrgID: orgId,
 		Limit: limit,
 		Offset: offset,
 	})
 
 	if err != nil{
-		log.Printf("Error fetching suspended users from the database: %s", err)
+		logging.FromContext(ctx).Error("Error fetching suspended users from the database", "error", err)
 		return []database.User{}, err
 	}
 
@@ -475,15 +618,20 @@ func (r *SQLUserRepository) GetSuspendedUsers(ctx context.Context, limit, offset
 }
 
 // GetDeletedUsers returns a list of users with account status disabled
-func (r *SQLUserRepository) GetDeletedUsers(ctx context.Context, limit, offset int32) ([]database.User, error){
+func (r *SQLUserRepository) GetDeletedUsers(ctx context.Context, orgId uuid.NullUUID, limit, offset int32) (_ []database.User, err error){
+	ctx, span := tracing.Start(ctx, "SQLUserRepository.GetDeletedUsers")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	// Fetching disabled users from the database
 	disabledUsers, err := r.DB.GetDeletedUsers(ctx, database.GetDeletedUsersParams{
+		OrgID: orgId,
 		Limit: limit,
 		Offset: offset,
 	})
 
 	if err != nil{
-		log.Printf("Error fetching disabled users from the database: %s", err)
+		logging.FromContext(ctx).Error("Error fetching disabled users from the database", "error", err)
 		return []database.User{}, err
 	}
 
@@ -13,21 +13,22 @@ import (
 type UserRepository interface {
 	// create
 	CreateUser(ctx context.Context, user model.UserRegister) (model.User, error)
+	CreateMagicLink(ctx context.Context, userId uuid.UUID, browserBindingHash string, expiresAt time.Time) (model.MagicLink, error)
 
 	// update
 	StoreRefreshToken(ctx context.Context, userId uuid.UUID, refreshToken string, expiresAt time.Time) (model.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, refreshTokenId uuid.UUID) error
+	RevokeUserRefreshTokens(ctx context.Context, userId uuid.UUID) (int64, error)
+	RevokeAllRefreshTokens(ctx context.Context) (int64, error)
+	ConsumeMagicLink(ctx context.Context, magicLinkId uuid.UUID, browserBindingHash string) (model.MagicLink, error)
 	UpdateUserLastLogin(ctx context.Context, userId uuid.UUID) error
 	UpdateUser(ctx context.Context, user model.User) (model.User, error)
 	UpdateUserProfilePicture(ctx context.Context, user model.User) (model.User, error)
 	UpdateUserPassword(ctx context.Context, userId uuid.UUID, newPassword string) error
 
-	PromoteUserToAdmin(ctx context.Context, userId uuid.UUID) (model.User, error)
-	PromoteUserToSuperAdmin(ctx context.Context, userId uuid.UUID) (model.User, error)
-	DemoteSuperAdminToAdmin(ctx context.Context, userId uuid.UUID)(model.User, error)
-	DemoteSuperAdminToUser(ctx context.Context, userId uuid.UUID)(model.User, error)
-	DemoteAdminToUser(ctx context.Context, userId uuid.UUID)(model.User, error)
 	SuspendUser(ctx context.Context, userId uuid.UUID) (model.User, error)
 	RecoverUser(ctx context.Context, userId uuid.UUID) (model.User, error)
+	SetAccountStatusUnlessLastSuperAdmin(ctx context.Context, userId uuid.UUID, status string) (bool, error)
 
 	// delete
 	DeleteUser(ctx context.Context, userId uuid.UUID) error
@@ -36,13 +37,16 @@ type UserRepository interface {
 	CountAllUsersByUsername(ctx context.Context, username string) (int64, error)
 	GetUserById(ctx context.Context, userId uuid.UUID) (model.User, error)
 	GetUserByEmail(ctx context.Context, email string) (model.User, error)
-
-	GetAllUsers(ctx context.Context, limit, offset int32) ([]database.User, error)
-	GetAdminUsers(ctx context.Context, limit, offset int32) ([]database.User, error)
-	GetSuperAdminUsers(ctx context.Context, limit, offset int32) ([]database.User, error)
-	GetActiveUsers(ctx context.Context, limit, offset int32) ([]database.User, error)
-	GetInactiveUsers(ctx context.Context, limit, offset int32) ([]database.User, error)
-	GetSuspendedUsers(ctx context.Context, limit, offset int32) ([]database.User, error)
-	GetDeletedUsers(ctx context.Context, limit, offset int32) ([]database.User, error)
+	GetRefreshToken(ctx context.Context, refreshToken string) (model.RefreshToken, error)
+	CountRecentMagicLinks(ctx context.Context, userId uuid.UUID, since time.Time) (int64, error)
+	CountActiveRefreshTokens(ctx context.Context) (int64, error)
+
+	GetAllUsers(ctx context.Context, orgId uuid.NullUUID, limit, offset int32) ([]database.User, error)
+	GetAdminUsers(ctx context.Context, orgId uuid.NullUUID, limit, offset int32) ([]database.User, error)
+	GetSuperAdminUsers(ctx context.Context, orgId uuid.NullUUID, limit, offset int32) ([]database.User, error)
+	GetActiveUsers(ctx context.Context, orgId uuid.NullUUID, limit, offset int32) ([]database.User, error)
+	GetInactiveUsers(ctx context.Context, orgId uuid.NullUUID, limit, offset int32) ([]database.User, error)
+	GetSuspendedUsers(ctx context.Context, orgId uuid.NullUUID, limit, offset int32) ([]database.User, error)
+	GetDeletedUsers(ctx context.Context, orgId uuid.NullUUID, limit, offset int32) ([]database.User, error)
 	
 }
@@ -0,0 +1,188 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// keystoreVersion is the version of the keystore file format
+const keystoreVersion = 1
+
+// scrypt parameters the encryption key is derived from the master key with
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrWrongMasterKey is returned when a keystore cannot be decrypted with the master key
+var ErrWrongMasterKey = errors.New("wrong master key or corrupted keystore")
+
+// keystoreFile is the format of keystore files. The secrets are encrypted
+// together as a JSON object with AES-256-GCM, under a key derived from the
+// master key with scrypt.
+type keystoreFile struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Keystore is a local file of named secrets encrypted with a master key
+type Keystore struct {
+	path      string
+	masterKey string
+	secrets   map[string]string
+}
+
+// OpenKeystore decrypts the keystore file with the master key. A keystore that
+// does not exist yet is empty, and is created when saved.
+func OpenKeystore(path string, masterKey string) (*Keystore, error) {
+	if masterKey == "" {
+		return nil, errors.New("a master key is required to open the keystore")
+	}
+
+	keystore := &Keystore{path: path, masterKey: masterKey, secrets: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return keystore, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading keystore: %w", err)
+	}
+
+	// decrypt
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing keystore %s: %w", path, err)
+	}
+	if file.Version != keystoreVersion {
+		return nil, fmt.Errorf("keystore %s has unsupported version %d", path, file.Version)
+	}
+	aead, err := newAEAD(masterKey, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongMasterKey
+	}
+	if err := json.Unmarshal(plaintext, &keystore.secrets); err != nil {
+		return nil, ErrWrongMasterKey
+	}
+
+	return keystore, nil
+}
+
+// Get returns the secret with the name
+func (k *Keystore) Get(name string) (string, error) {
+	secret, ok := k.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s is not in the keystore", ErrNotFound, name)
+	}
+
+	return secret, nil
+}
+
+// Set adds or replaces the secret with the name, until the keystore is saved
+func (k *Keystore) Set(name string, secret string) {
+	k.secrets[name] = secret
+}
+
+// Delete removes the secret with the name, until the keystore is saved
+func (k *Keystore) Delete(name string) error {
+	if _, ok := k.secrets[name]; !ok {
+		return fmt.Errorf("%w: %s is not in the keystore", ErrNotFound, name)
+	}
+	delete(k.secrets, name)
+
+	return nil
+}
+
+// Names returns the names of the secrets in alphabetical order
+func (k *Keystore) Names() []string {
+	names := make([]string, 0, len(k.secrets))
+	for name := range k.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Save encrypts the secrets with a fresh salt and nonce and replaces the
+// keystore file, which only its owner can read
+func (k *Keystore) Save() error {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := newAEAD(k.masterKey, salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	// encrypt
+	plaintext, err := json.Marshal(k.secrets)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(keystoreFile{
+		Version:    keystoreVersion,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file, created readable by its owner only, so a failed
+	// write keeps the previous keystore
+	temp, err := os.CreateTemp(filepath.Dir(k.path), ".keystore-*")
+	if err != nil {
+		return fmt.Errorf("writing keystore: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return fmt.Errorf("writing keystore: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("writing keystore: %w", err)
+	}
+	if err := os.Rename(temp.Name(), k.path); err != nil {
+		return fmt.Errorf("writing keystore: %w", err)
+	}
+
+	return nil
+}
+
+// newAEAD returns the cipher of the key derived from the master key and salt
+func newAEAD(masterKey string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(masterKey), salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
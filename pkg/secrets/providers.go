@@ -0,0 +1,36 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FileProvider reads secrets from files, e.g. Docker or Kubernetes secrets.
+// A trailing newline is not part of the secret.
+type FileProvider struct{}
+
+func (FileProvider) Get(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// EnvProvider reads secrets from environment variables
+type EnvProvider struct{}
+
+func (EnvProvider) Get(name string) (string, error) {
+	secret, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("%w: environment variable %s is not set", ErrNotFound, name)
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,85 @@
+// Package secrets resolves references to sensitive values, so they can be
+// kept out of config files and the environment of the server. A reference is
+// a value starting with a scheme:
+//
+//	file:///run/secrets/jwt   the contents of a file
+//	env:JWT_SECRET            an environment variable
+//	keystore:jwt              an entry of the encrypted keystore
+//
+// Values without a scheme are used as they are.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Reference schemes
+const (
+	FileScheme     = "file://"
+	EnvScheme      = "env:"
+	KeystoreScheme = "keystore:"
+)
+
+// ErrNotFound is returned when a reference points to a secret that does not exist
+var ErrNotFound = errors.New("secret not found")
+
+// Provider returns the secrets of a scheme
+type Provider interface {
+	// Get returns the secret named by a reference without its scheme
+	Get(name string) (string, error)
+}
+
+// Resolver resolves references using the provider of their scheme
+type Resolver struct {
+	providers map[string]Provider
+}
+
+// NewResolver returns a resolver of file and env references, and of keystore
+// references when a keystore file is given. The master key the keystore is
+// encrypted with can itself be a file or env reference.
+func NewResolver(keystoreFile string, masterKey string) (*Resolver, error) {
+	resolver := &Resolver{
+		providers: map[string]Provider{
+			FileScheme: FileProvider{},
+			EnvScheme:  EnvProvider{},
+		},
+	}
+
+	if keystoreFile == "" {
+		return resolver, nil
+	}
+
+	// open keystore
+	key, err := resolver.Resolve(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("resolving master key: %w", err)
+	}
+	keystore, err := OpenKeystore(keystoreFile, key)
+	if err != nil {
+		return nil, err
+	}
+	resolver.providers[KeystoreScheme] = keystore
+
+	return resolver, nil
+}
+
+// Resolve returns the secret the value refers to, or the value itself when it is not a reference
+func (r *Resolver) Resolve(value string) (string, error) {
+	for scheme, provider := range r.providers {
+		if name, ok := strings.CutPrefix(value, scheme); ok {
+			secret, err := provider.Get(name)
+			if err != nil {
+				return "", fmt.Errorf("resolving %s: %w", value, err)
+			}
+			return secret, nil
+		}
+	}
+
+	if strings.HasPrefix(value, KeystoreScheme) {
+		return "", fmt.Errorf("resolving %s: no keystore file is configured", value)
+	}
+
+	return value, nil
+}
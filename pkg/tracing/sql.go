@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StatementNameKey is the attribute of the name of a sqlc query, e.g. GetUserById
+const StatementNameKey = attribute.Key("db.statement.name")
+
+// unnamedStatement is the name of queries sqlc did not generate
+const unnamedStatement = "query"
+
+// tracedDB starts a span for every query run through sqlc, named after the query
+type tracedDB struct {
+	db database.DBTX
+}
+
+// WrapDB traces the queries run on db
+func WrapDB(db database.DBTX) database.DBTX {
+	return &tracedDB{db: db}
+}
+
+func (t *tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+
+	result, err := t.db.ExecContext(ctx, query, args...)
+	RecordError(span, err)
+	return result, err
+}
+
+func (t *tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+
+	stmt, err := t.db.PrepareContext(ctx, query)
+	RecordError(span, err)
+	return stmt, err
+}
+
+func (t *tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	RecordError(span, err)
+	return rows, err
+}
+
+// QueryRowContext traces running the query; errors only surface when the row is scanned
+func (t *tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+
+	return t.db.QueryRowContext(ctx, query, args...)
+}
+
+// startQuery starts the span of a query named after its sqlc name. The text
+// and arguments of queries are left out as they can hold secrets.
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := statementName(query)
+	return Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			StatementNameKey.String(name),
+		),
+	)
+}
+
+// statementName reads the name sqlc gives a query from its first line, e.g.
+// GetUserById from "-- name: GetUserById :one"
+func statementName(query string) string {
+	line, _, _ := strings.Cut(query, "\n")
+	fields := strings.Fields(strings.TrimPrefix(line, "-- name:"))
+	if !strings.HasPrefix(line, "-- name:") || len(fields) == 0 {
+		return unnamedStatement
+	}
+
+	return fields[0]
+}
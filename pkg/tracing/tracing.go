@@ -0,0 +1,128 @@
+// Package tracing records OpenTelemetry traces of requests as they go through
+// the handlers, services, repositories and database queries
+package tracing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/config"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer spans of the server are started with
+const instrumentationName = "github.com/StaphoneWizzoh/Go_Auth"
+
+// UserHashKey is the attribute of the hashed ID of the user a request is made by
+const UserHashKey = attribute.Key("user.hash")
+
+// Setup sets the global tracer provider to export traces as configured and
+// propagates W3C trace context. The returned function flushes the traces
+// still buffered and stops exporting them. Without an exporter spans are not
+// recorded, but trace context is still propagated.
+func Setup(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var file io.Closer
+	var err error
+	switch cfg.Exporter {
+	case config.TracingExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case config.TracingExporterOTLP:
+		var options []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	case config.TracingExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case config.TracingExporterFile:
+		var traceFile *os.File
+		traceFile, err = os.OpenFile(cfg.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("opening trace file: %w", err)
+		}
+		file = traceFile
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(traceFile))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	serviceResource, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(serviceResource),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// Start starts a span named after the operation, e.g. UserService.LoginUser,
+// as a child of the span in the context
+func Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, options...)
+}
+
+// RecordError marks the span as failed with err, if there is one
+func RecordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// SetUser records the hashed ID of the user a request is made by on the span
+// in the context. The ID is hashed so traces can be correlated by user
+// without identifying them.
+func SetUser(ctx context.Context, userID uuid.UUID) {
+	trace.SpanFromContext(ctx).SetAttributes(UserHashKey.String(HashUserID(userID)))
+}
+
+// HashUserID hashes the ID of a user for traces
+func HashUserID(userID uuid.UUID) string {
+	sum := sha256.Sum256(userID[:])
+	return hex.EncodeToString(sum[:16])
+}
+
+// StartRequest starts a span as a child of the span of the request and
+// returns the request carrying it
+func StartRequest(r *http.Request, name string) (*http.Request, trace.Span) {
+	ctx, span := Start(r.Context(), name)
+	return r.WithContext(ctx), span
+}
@@ -0,0 +1,194 @@
+package usecases
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidAPIKey is returned for API keys that are unknown, revoked, expired or whose user is not active
+	ErrInvalidAPIKey = &Error{Kind: ErrUnauthorized, Code: "invalid_api_key", Message: "API key is invalid, expired or has been revoked"}
+	// ErrAPIKeyNotFound is returned when revoking an API key the user does not have
+	ErrAPIKeyNotFound = &Error{Kind: ErrNotFound, Code: "api_key_not_found", Message: "API key not found"}
+	// ErrInvalidAPIKeyName is returned for names that are empty or longer than 50 characters
+	ErrInvalidAPIKeyName = &Error{Kind: ErrValidation, Code: "invalid_api_key_name", Message: "API key names must be 1-50 characters"}
+	// ErrInvalidAPIKeyExpiry is returned for expiry times that are not in the future
+	ErrInvalidAPIKeyExpiry = &Error{Kind: ErrValidation, Code: "invalid_api_key_expiry", Message: "API key expiry must be in the future"}
+	// ErrAPIKeyScopeNotHeld is returned when scoping an API key to a permission the user does not hold
+	ErrAPIKeyScopeNotHeld = &Error{Kind: ErrForbidden, Code: "api_key_scope_not_held", Message: "You can only scope an API key to permissions you hold"}
+	// ErrAPIKeyManagedByAPIKey is returned when an API key is used to create API keys
+	ErrAPIKeyManagedByAPIKey = &Error{Kind: ErrForbidden, Code: "api_key_managed_by_api_key", Message: "API keys cannot create API keys, log in to create one"}
+	// ErrAPIKeyNameTaken is returned when the user already has an API key with the name
+	ErrAPIKeyNameTaken = &Error{Kind: ErrConflict, Code: "api_key_name_taken", Message: "You already have an API key with this name"}
+)
+
+// apiKeyTouchInterval is how often the last use of an API key is recorded
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyService struct {
+	apiKeyRepo  repository.APIKeyRepository
+	userRepo    repository.UserRepository
+	roleRepo    repository.RoleRepository
+	userService *UserService
+}
+
+func NewAPIKeyService(
+	apiKeyRepo repository.APIKeyRepository,
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	userService *UserService,
+) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo:  apiKeyRepo,
+		userRepo:    userRepo,
+		roleRepo:    roleRepo,
+		userService: userService,
+	}
+}
+
+// CreateAPIKey creates an API key for the user in the context. The key is only
+// returned here; only its hash is stored.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (model.CreatedAPIKey, error) {
+	userId := ctx.Value("userId").(uuid.UUID)
+
+	if _, ok := utils.GetAPIKeyScopesFromContext(ctx); ok {
+		return model.CreatedAPIKey{}, ErrAPIKeyManagedByAPIKey
+	}
+
+	// validate name and expiry
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 50 {
+		return model.CreatedAPIKey{}, ErrInvalidAPIKeyName
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return model.CreatedAPIKey{}, ErrInvalidAPIKeyExpiry
+	}
+
+	// scopes are permissions the user holds
+	uniqueScopes := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if slices.Contains(uniqueScopes, scope) {
+			continue
+		}
+
+		held, err := s.roleRepo.UserHasPermission(ctx, userId, scope)
+		if err != nil {
+			return model.CreatedAPIKey{}, err
+		}
+		if !held {
+			return model.CreatedAPIKey{}, ErrAPIKeyScopeNotHeld
+		}
+		uniqueScopes = append(uniqueScopes, scope)
+	}
+
+	// generate key
+	key, prefix, err := utils.GenerateAPIKey()
+	if err != nil {
+		return model.CreatedAPIKey{}, err
+	}
+
+	// store key
+	apiKey, err := s.apiKeyRepo.CreateAPIKey(ctx, model.APIKey{
+		UserID:    userId,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   utils.HashToken(key),
+		Scopes:    uniqueScopes,
+		ExpiresAt: expiresAt,
+	})
+	if repository.IsConflict(err, "api_keys_user_id_name_key") {
+		return model.CreatedAPIKey{}, ErrAPIKeyNameTaken
+	}
+	if err != nil {
+		return model.CreatedAPIKey{}, err
+	}
+
+	return model.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+// GetAPIKeys lists the API keys of the user in the context
+func (s *APIKeyService) GetAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	userId := ctx.Value("userId").(uuid.UUID)
+	return s.apiKeyRepo.GetUserAPIKeys(ctx, userId)
+}
+
+// RevokeAPIKey revokes an API key of the user in the context
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, apiKeyId uuid.UUID) error {
+	userId := ctx.Value("userId").(uuid.UUID)
+
+	deleted, err := s.apiKeyRepo.DeleteAPIKey(ctx, userId, apiKeyId)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// Authenticate checks an API key and returns the claims of its user, acting in
+// their oldest organization like a login would, along with the key
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*utils.UserClaims, model.APIKey, error) {
+	// find key
+	prefix, ok := utils.ParseAPIKey(key)
+	if !ok {
+		return nil, model.APIKey{}, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.apiKeyRepo.GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.APIKey{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, model.APIKey{}, err
+	}
+
+	// check key
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(key)), []byte(apiKey.KeyHash)) != 1 {
+		return nil, model.APIKey{}, ErrInvalidAPIKey
+	}
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now()) {
+		return nil, model.APIKey{}, ErrInvalidAPIKey
+	}
+
+	// check user
+	user, err := s.userRepo.GetUserById(ctx, apiKey.UserID)
+	if err != nil {
+		return nil, model.APIKey{}, err
+	}
+	if user.AccountStatus != "active" {
+		return nil, model.APIKey{}, ErrInvalidAPIKey
+	}
+
+	orgId, err := s.userService.DefaultOrganization(ctx, user.ID)
+	if err != nil {
+		return nil, model.APIKey{}, err
+	}
+
+	// record use
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.apiKeyRepo.TouchAPIKey(ctx, apiKey.ID); err != nil {
+			logging.FromContext(ctx).Error("Failed to record use of API key", "api_key_id", apiKey.ID, "error", err)
+		}
+	}
+
+	return &utils.UserClaims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     user.UserRole,
+		OrgID:    orgId,
+	}, apiKey, nil
+}
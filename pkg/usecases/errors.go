@@ -0,0 +1,150 @@
+package usecases
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/metrics"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+)
+
+// Kinds of domain errors. Handlers respond to every error of a kind with the
+// same HTTP status.
+var (
+	// ErrNotFound is the kind of errors for records that do not exist
+	ErrNotFound = repository.ErrNotFound
+	// ErrConflict is the kind of errors for changes that conflict with existing records or their state
+	ErrConflict = repository.ErrConflict
+	// ErrUnauthorized is the kind of errors for credentials that were not accepted
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is the kind of errors for operations the caller is not allowed to perform
+	ErrForbidden = errors.New("forbidden")
+	// ErrValidation is the kind of errors for requests with invalid fields
+	ErrValidation = errors.New("validation failed")
+	// ErrUpstream is the kind of errors for services the server relies on that failed, such as identity providers
+	ErrUpstream = errors.New("upstream service failed")
+	// ErrGone is the kind of errors for records that can no longer be used, such as accepted invitations
+	ErrGone = errors.New("gone")
+	// ErrRateLimited is the kind of errors for callers who made too many requests
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// Error is a domain error of a kind with a stable code clients can rely on
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// FieldError describes why a field of a request is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError is an ErrValidation listing every invalid field of a request
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+	return "invalid request: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// Add records that the field is invalid
+func (e *ValidationError) Add(field string, code string, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Err returns the validation error, or nil when every field was valid
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+var (
+	// ErrUserNotFound is returned when the user does not exist
+	ErrUserNotFound = &Error{Kind: ErrNotFound, Code: "user_not_found", Message: "User not found"}
+	// ErrEmailTaken is returned when another user already has the email
+	ErrEmailTaken = &Error{Kind: ErrConflict, Code: "email_taken", Message: "User email already exists"}
+	// ErrUsernameTaken is returned when another user already has the username
+	ErrUsernameTaken = &Error{Kind: ErrConflict, Code: "username_taken", Message: "User firstname and lastname combination already exists"}
+	// ErrInvalidCredentials is returned when the email or password is wrong. The
+	// two are not told apart so logins do not reveal who has an account.
+	ErrInvalidCredentials = &Error{Kind: ErrUnauthorized, Code: "invalid_credentials", Message: "Invalid email or password"}
+	// ErrAccountNotActive is returned when a suspended or deleted user signs in, or signs in to an OAuth client
+	ErrAccountNotActive = &Error{Kind: ErrForbidden, Code: "account_not_active", Message: "The account is not active"}
+	// ErrInvalidResetToken is returned for reset password links that are invalid or expired
+	ErrInvalidResetToken = &Error{Kind: ErrUnauthorized, Code: "invalid_reset_token", Message: "Reset password link is invalid or has expired"}
+	// ErrUserAlreadySuspended is returned when suspending a suspended user
+	ErrUserAlreadySuspended = &Error{Kind: ErrConflict, Code: "user_already_suspended", Message: "User is already suspended"}
+	// ErrUserAlreadyActive is returned when recovering an active user
+	ErrUserAlreadyActive = &Error{Kind: ErrConflict, Code: "user_already_active", Message: "User account is already recovered"}
+	// ErrUserAlreadyDeleted is returned when deleting a deleted user
+	ErrUserAlreadyDeleted = &Error{Kind: ErrConflict, Code: "user_already_deleted", Message: "User account is already deleted"}
+	// ErrOperatorOnly is returned for operations only operators on the command line can perform
+	ErrOperatorOnly = &Error{Kind: ErrForbidden, Code: "operator_only", Message: "Only operators can perform this operation"}
+)
+
+// userConflictErrors maps the unique constraints of users to the errors their violations mean
+var userConflictErrors = map[string]error{
+	"users_email_key":    ErrEmailTaken,
+	"users_username_key": ErrUsernameTaken,
+}
+
+// toUserError translates repository errors about a user into user errors
+func toUserError(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return ErrUserNotFound
+	}
+
+	var conflictErr *repository.ConflictError
+	if errors.As(err, &conflictErr) {
+		if userErr, ok := userConflictErrors[conflictErr.Constraint]; ok {
+			return userErr
+		}
+	}
+
+	return err
+}
+
+// outcomeOf is the outcome of an operation recorded in metrics: success, the
+// code of the error it failed with, or error for unexpected errors
+func outcomeOf(err error) string {
+	if err == nil {
+		return metrics.OutcomeSuccess
+	}
+
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Code
+	}
+	var oauthErr *OAuthError
+	if errors.As(err, &oauthErr) {
+		return oauthErr.Code
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return "validation_failed"
+	}
+	return metrics.OutcomeError
+}
@@ -0,0 +1,112 @@
+package usecases
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Statuses of health checks and reports
+const (
+	HealthStatusOK           = "ok"
+	HealthStatusFailing      = "failing"
+	HealthStatusShuttingDown = "shutting_down"
+)
+
+// healthCheckTimeout is how long a health check may take before it fails
+const healthCheckTimeout = 3 * time.Second
+
+// HealthCheck is a dependency the server needs to serve requests. Check
+// returns an error describing why the dependency is not usable.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthCheckResult is the outcome of a health check
+type HealthCheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HealthReport is the readiness of the server along with the outcome of each check
+type HealthReport struct {
+	Status string              `json:"status"`
+	Checks []HealthCheckResult `json:"checks"`
+}
+
+// Ready reports whether the server can serve requests
+func (r HealthReport) Ready() bool {
+	return r.Status == HealthStatusOK
+}
+
+type HealthService struct {
+	checks       []HealthCheck
+	shuttingDown atomic.Bool
+}
+
+func NewHealthService(checks ...HealthCheck) *HealthService {
+	return &HealthService{
+		checks: checks,
+	}
+}
+
+// ShutDown marks the server as shutting down, after which it is no longer ready
+func (s *HealthService) ShutDown() {
+	s.shuttingDown.Store(true)
+}
+
+// Readiness runs every check at once and reports the server ready when they
+// all pass. Checks are not run once the server is shutting down.
+func (s *HealthService) Readiness(ctx context.Context) HealthReport {
+	if s.shuttingDown.Load() {
+		return HealthReport{Status: HealthStatusShuttingDown, Checks: []HealthCheckResult{}}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	report := HealthReport{
+		Status: HealthStatusOK,
+		Checks: make([]HealthCheckResult, len(s.checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = runHealthCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != HealthStatusOK {
+			report.Status = HealthStatusFailing
+		}
+	}
+
+	return report
+}
+
+// runHealthCheck runs the check and times it
+func runHealthCheck(ctx context.Context, check HealthCheck) HealthCheckResult {
+	start := time.Now()
+	err := check.Check(ctx)
+
+	result := HealthCheckResult{
+		Name:      check.Name,
+		Status:    HealthStatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = HealthStatusFailing
+		result.Error = err.Error()
+	}
+
+	return result
+}
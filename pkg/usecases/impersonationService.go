@@ -0,0 +1,245 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidImpersonation is returned when an impersonation token was not issued by an impersonation on record
+	ErrInvalidImpersonation = &Error{Kind: ErrUnauthorized, Code: "invalid_impersonation", Message: "Impersonation is invalid or has ended"}
+	// ErrImpersonationNotAllowed is returned when impersonating while impersonating, or with an API key or service account
+	ErrImpersonationNotAllowed = &Error{Kind: ErrForbidden, Code: "impersonation_not_allowed", Message: "Impersonation must be started by an administrator who is logged in as themselves"}
+	// ErrCannotImpersonateSelf is returned when an administrator tries to impersonate themselves
+	ErrCannotImpersonateSelf = &Error{Kind: ErrValidation, Code: "cannot_impersonate_self", Message: "You cannot impersonate yourself"}
+	// ErrCannotImpersonateSuperAdmin is returned when the target is a super administrator
+	ErrCannotImpersonateSuperAdmin = &Error{Kind: ErrForbidden, Code: "cannot_impersonate_superadmin", Message: "Super administrators cannot be impersonated"}
+	// ErrImpersonationTargetNotSubordinate is returned when the target is the actor's peer or superior
+	ErrImpersonationTargetNotSubordinate = &Error{Kind: ErrForbidden, Code: "impersonation_target_not_subordinate", Message: "You can only impersonate users ranked below you"}
+	// ErrImpersonationTargetInactive is returned when the target's account is not active
+	ErrImpersonationTargetInactive = &Error{Kind: ErrConflict, Code: "impersonation_target_inactive", Message: "Only active users can be impersonated"}
+	// ErrInvalidImpersonationReason is returned for reasons that are empty or longer than 255 characters
+	ErrInvalidImpersonationReason = &Error{Kind: ErrValidation, Code: "invalid_impersonation_reason", Message: "A reason of at most 255 characters is required to impersonate a user"}
+)
+
+// impersonationEventPathLength is the longest request path recorded for an impersonation event
+const impersonationEventPathLength = 255
+
+type ImpersonationService struct {
+	impersonationRepo repository.ImpersonationRepository
+	userRepo          repository.UserRepository
+	roleRepo          repository.RoleRepository
+	userService       *UserService
+}
+
+func NewImpersonationService(
+	impersonationRepo repository.ImpersonationRepository,
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	userService *UserService,
+) *ImpersonationService {
+	return &ImpersonationService{
+		impersonationRepo: impersonationRepo,
+		userRepo:          userRepo,
+		roleRepo:          roleRepo,
+		userService:       userService,
+	}
+}
+
+// Impersonate issues a short-lived access token acting as the target user on
+// behalf of the administrator in the context and records why. Super
+// administrators, and users ranked at or above the administrator, cannot be
+// impersonated. Confined administrators act in their own organization.
+func (s *ImpersonationService) Impersonate(ctx context.Context, targetId uuid.UUID, reason string) (model.ImpersonationResponse, error) {
+	actorId := ctx.Value("userId").(uuid.UUID)
+
+	// only administrators acting as themselves can impersonate
+	if _, ok := utils.GetImpersonatorFromContext(ctx); ok {
+		return model.ImpersonationResponse{}, ErrImpersonationNotAllowed
+	}
+	if _, ok := utils.GetAPIKeyScopesFromContext(ctx); ok {
+		return model.ImpersonationResponse{}, ErrImpersonationNotAllowed
+	}
+	if utils.IsServiceAccountInContext(ctx) {
+		return model.ImpersonationResponse{}, ErrImpersonationNotAllowed
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > 255 {
+		return model.ImpersonationResponse{}, ErrInvalidImpersonationReason
+	}
+
+	if targetId == actorId {
+		return model.ImpersonationResponse{}, ErrCannotImpersonateSelf
+	}
+
+	// confined administrators can only impersonate members of their organization
+	if err := s.userService.checkTenantAccess(ctx, targetId); err != nil {
+		return model.ImpersonationResponse{}, err
+	}
+
+	actor, err := s.userRepo.GetUserById(ctx, actorId)
+	if err != nil {
+		return model.ImpersonationResponse{}, err
+	}
+	target, err := s.userRepo.GetUserById(ctx, targetId)
+	if err != nil {
+		return model.ImpersonationResponse{}, err
+	}
+
+	if target.AccountStatus != "active" {
+		return model.ImpersonationResponse{}, ErrImpersonationTargetInactive
+	}
+
+	// check the role hierarchy
+	actorRole, err := s.highestRole(ctx, actorId)
+	if err != nil {
+		return model.ImpersonationResponse{}, err
+	}
+	targetRole, err := s.highestRole(ctx, targetId)
+	if err != nil {
+		return model.ImpersonationResponse{}, err
+	}
+	if targetRole.Name == model.RoleSuperAdmin || target.UserRole == model.RoleSuperAdmin {
+		return model.ImpersonationResponse{}, ErrCannotImpersonateSuperAdmin
+	}
+	if targetRole.Rank >= actorRole.Rank {
+		return model.ImpersonationResponse{}, ErrImpersonationTargetNotSubordinate
+	}
+
+	// act in the administrator's organization, or the target's oldest one
+	orgId, err := s.impersonationOrganization(ctx, targetId)
+	if err != nil {
+		return model.ImpersonationResponse{}, err
+	}
+
+	// record the impersonation before issuing its token
+	impersonation, err := s.impersonationRepo.CreateImpersonation(ctx, model.Impersonation{
+		ActorID:       &actorId,
+		ActorUsername: actor.Username,
+		TargetID:      targetId,
+		OrgID:         orgId,
+		Reason:        reason,
+		ExpiresAt:     time.Now().Add(utils.ImpersonationTokenLifetime),
+	})
+	if err != nil {
+		return model.ImpersonationResponse{}, err
+	}
+
+	accessToken, expireTime, err := utils.GenerateImpersonationToken(utils.UserClaims{
+		UserID:   target.ID,
+		Username: target.Username,
+		Email:    target.Email,
+		Role:     target.UserRole,
+		OrgID:    orgId,
+	}, actorId, actor.Username, impersonation.ID)
+	if err != nil {
+		return model.ImpersonationResponse{}, err
+	}
+
+	logging.FromContext(ctx).Info("User started impersonating another user", "actor_id", actorId, "user_id", targetId, "reason", reason)
+
+	return model.ImpersonationResponse{
+		AccessToken:     accessToken,
+		TokenType:       "Bearer",
+		ExpiresAt:       expireTime,
+		ImpersonationID: impersonation.ID,
+	}, nil
+}
+
+// RecordUse records a request made with an impersonation token. Tokens whose
+// impersonation is not on record are refused, so every use is audited.
+func (s *ImpersonationService) RecordUse(ctx context.Context, claims *utils.UserClaims, method string, path string) error {
+	impersonationId, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return ErrInvalidImpersonation
+	}
+
+	impersonation, err := s.impersonationRepo.GetImpersonationById(ctx, impersonationId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvalidImpersonation
+	}
+	if err != nil {
+		return err
+	}
+
+	// the token must match the impersonation, whose actor must still exist
+	if impersonation.TargetID != claims.UserID || impersonation.ActorID == nil || claims.Act.Subject != impersonation.ActorID.String() {
+		return ErrInvalidImpersonation
+	}
+
+	if len(path) > impersonationEventPathLength {
+		path = path[:impersonationEventPathLength]
+	}
+
+	return s.impersonationRepo.CreateImpersonationEvent(ctx, model.ImpersonationEvent{
+		ImpersonationID: impersonationId,
+		Method:          method,
+		Path:            path,
+	})
+}
+
+// GetMyImpersonations lists the impersonations of the user in the context,
+// newest first, each with the requests made while impersonating them
+func (s *ImpersonationService) GetMyImpersonations(ctx context.Context) ([]model.Impersonation, error) {
+	userId := ctx.Value("userId").(uuid.UUID)
+
+	impersonations, err := s.impersonationRepo.GetUserImpersonations(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.impersonationRepo.GetUserImpersonationEvents(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// group the events by impersonation
+	byImpersonation := make(map[uuid.UUID]int, len(impersonations))
+	for i, impersonation := range impersonations {
+		byImpersonation[impersonation.ID] = i
+	}
+	for _, event := range events {
+		if i, ok := byImpersonation[event.ImpersonationID]; ok {
+			impersonations[i].Events = append(impersonations[i].Events, event)
+		}
+	}
+
+	return impersonations, nil
+}
+
+// highestRole returns the highest ranked role the user holds
+func (s *ImpersonationService) highestRole(ctx context.Context, userId uuid.UUID) (model.Role, error) {
+	roles, err := s.roleRepo.GetUserRoles(ctx, userId)
+	if err != nil {
+		return model.Role{}, err
+	}
+	if len(roles) == 0 {
+		return model.Role{Name: model.RoleUser}, nil
+	}
+
+	return roles[0], nil
+}
+
+// impersonationOrganization returns the organization an impersonation acts in:
+// the one a confined administrator is confined to, otherwise the target's oldest
+func (s *ImpersonationService) impersonationOrganization(ctx context.Context, targetId uuid.UUID) (*uuid.UUID, error) {
+	orgId, err := s.userService.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if orgId.Valid {
+		return &orgId.UUID, nil
+	}
+
+	return s.userService.DefaultOrganization(ctx, targetId)
+}
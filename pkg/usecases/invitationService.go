@@ -0,0 +1,239 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+	"github.com/google/uuid"
+)
+
+// invitationTTL is how long an invitation can be accepted for
+const invitationTTL = 7 * 24 * time.Hour
+
+var (
+	// ErrInvitationNotPending is returned for invitations that were accepted, revoked, have expired or do not exist
+	ErrInvitationNotPending = &Error{Kind: ErrGone, Code: "invitation_not_pending", Message: "Invitation is no longer valid"}
+	// ErrAlreadyMember is returned when inviting a user who is already a member of the organization
+	ErrAlreadyMember = &Error{Kind: ErrConflict, Code: "already_member", Message: "User is already a member of the organization"}
+	// ErrAccountDetailsRequired is returned when accepting an invitation without an account and without the details to create one
+	ErrAccountDetailsRequired = &Error{Kind: ErrValidation, Code: "account_details_required", Message: "Password, first_name and last_name are required to create an account"}
+)
+
+type InvitationService struct {
+	invitationRepo repository.InvitationRepository
+	orgRepo        repository.OrganizationRepository
+	userRepo       repository.UserRepository
+	userService    *UserService
+}
+
+func NewInvitationService(
+	invitationRepo repository.InvitationRepository,
+	orgRepo repository.OrganizationRepository,
+	userRepo repository.UserRepository,
+	userService *UserService,
+) *InvitationService {
+	return &InvitationService{
+		invitationRepo: invitationRepo,
+		orgRepo:        orgRepo,
+		userRepo:       userRepo,
+		userService:    userService,
+	}
+}
+
+// InviteMember invites an email address to the caller's organization and emails them the invitation.
+// Inviting an address again replaces its pending invitation.
+func (s *InvitationService) InviteMember(ctx context.Context, email string, orgRole string) (model.Invitation, error) {
+	if orgRole != model.OrgRoleAdmin && orgRole != model.OrgRoleMember {
+		return model.Invitation{}, ErrInvalidOrgRole
+	}
+
+	// get caller and organization
+	userId := ctx.Value("userId").(uuid.UUID)
+	orgId, ok := utils.GetOrgIdFromContext(ctx)
+	if !ok {
+		return model.Invitation{}, ErrNoOrganization
+	}
+
+	org, err := s.orgRepo.GetOrganizationById(ctx, orgId)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+
+	// check the address is not already a member
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return model.Invitation{}, err
+	}
+	if err == nil {
+		_, err = s.orgRepo.GetOrganizationMember(ctx, orgId, user.ID)
+		if err == nil {
+			return model.Invitation{}, ErrAlreadyMember
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return model.Invitation{}, err
+		}
+	}
+
+	// replace any pending invitation
+	err = s.invitationRepo.RevokePendingInvitations(ctx, orgId, email)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+
+	// create invitation
+	invitation, err := s.invitationRepo.CreateInvitation(ctx, model.Invitation{
+		ID:        uuid.New(),
+		OrgID:     orgId,
+		Email:     email,
+		OrgRole:   orgRole,
+		InvitedBy: &userId,
+		ExpiresAt: time.Now().Add(invitationTTL),
+	})
+	if err != nil {
+		return model.Invitation{}, err
+	}
+
+	// send invitation email
+	token, err := utils.GenerateInvitationToken(invitation.ID, orgId, email, orgRole, invitation.ExpiresAt)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+
+	err = utils.SendInvitationEmail(email, org.Name, token)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+
+	return invitation, nil
+}
+
+// ListPendingInvitations lists the pending invitations of the caller's organization
+func (s *InvitationService) ListPendingInvitations(ctx context.Context) ([]model.Invitation, error) {
+	orgId, ok := utils.GetOrgIdFromContext(ctx)
+	if !ok {
+		return nil, ErrNoOrganization
+	}
+
+	return s.invitationRepo.ListPendingInvitations(ctx, orgId)
+}
+
+// RevokeInvitation revokes a pending invitation of the caller's organization
+func (s *InvitationService) RevokeInvitation(ctx context.Context, invitationId uuid.UUID) error {
+	orgId, ok := utils.GetOrgIdFromContext(ctx)
+	if !ok {
+		return ErrNoOrganization
+	}
+
+	revoked, err := s.invitationRepo.RevokeInvitation(ctx, invitationId, orgId)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrInvitationNotPending
+	}
+
+	return nil
+}
+
+// PreviewInvitation returns what an invitation token invites its holder to
+func (s *InvitationService) PreviewInvitation(ctx context.Context, token string) (model.InvitationPreview, error) {
+	invitation, err := s.getPendingInvitation(ctx, token)
+	if err != nil {
+		return model.InvitationPreview{}, err
+	}
+
+	org, err := s.orgRepo.GetOrganizationById(ctx, invitation.OrgID)
+	if err != nil {
+		return model.InvitationPreview{}, err
+	}
+
+	hasAccount, err := s.hasAccount(ctx, invitation.Email)
+	if err != nil {
+		return model.InvitationPreview{}, err
+	}
+
+	return model.InvitationPreview{
+		OrganizationName: org.Name,
+		Email:            invitation.Email,
+		OrgRole:          invitation.OrgRole,
+		ExpiresAt:        invitation.ExpiresAt,
+		HasAccount:       hasAccount,
+	}, nil
+}
+
+// AcceptInvitation adds the invited address to the organization with the
+// invitation's role. An account is created for addresses without one.
+func (s *InvitationService) AcceptInvitation(
+	ctx context.Context,
+	token string,
+	password string,
+	firstName string,
+	lastName string,
+) (model.OrganizationMember, error) {
+	invitation, err := s.getPendingInvitation(ctx, token)
+	if err != nil {
+		return model.OrganizationMember{}, err
+	}
+
+	// link or create the account
+	user, err := s.userRepo.GetUserByEmail(ctx, invitation.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		if password == "" || firstName == "" || lastName == "" {
+			return model.OrganizationMember{}, ErrAccountDetailsRequired
+		}
+
+		user, err = s.userService.RegisterInvitedUser(ctx, invitation.Email, password, firstName, lastName)
+	}
+	if err != nil {
+		return model.OrganizationMember{}, err
+	}
+
+	// use up the invitation
+	accepted, err := s.invitationRepo.AcceptInvitation(ctx, invitation.ID)
+	if err != nil {
+		return model.OrganizationMember{}, err
+	}
+	if !accepted {
+		return model.OrganizationMember{}, ErrInvitationNotPending
+	}
+
+	return s.orgRepo.UpsertOrganizationMember(ctx, invitation.OrgID, user.ID, invitation.OrgRole)
+}
+
+// getPendingInvitation verifies the token and returns its invitation if it can still be accepted
+func (s *InvitationService) getPendingInvitation(ctx context.Context, token string) (model.Invitation, error) {
+	claims, err := utils.VerifyInvitationToken(token)
+	if err != nil {
+		return model.Invitation{}, ErrInvitationNotPending
+	}
+
+	invitation, err := s.invitationRepo.GetInvitationById(ctx, claims.InvitationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Invitation{}, ErrInvitationNotPending
+	}
+	if err != nil {
+		return model.Invitation{}, err
+	}
+
+	// the token must match the stored invitation, which must still be open
+	if invitation.Email != claims.Email || invitation.OrgID != claims.OrgID || !invitation.IsPending() {
+		return model.Invitation{}, ErrInvitationNotPending
+	}
+
+	return invitation, nil
+}
+
+// hasAccount reports whether an account exists for the email address
+func (s *InvitationService) hasAccount(ctx context.Context, email string) (bool, error) {
+	_, err := s.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
@@ -0,0 +1,814 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/metrics"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// authorizationCodeLifetime is how long an authorization code can be exchanged for tokens
+const authorizationCodeLifetime = 5 * time.Minute
+
+// OAuthError is an RFC 6749 error response
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func oauthError(code string, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// ErrInvalidClientMetadata is returned when registering a client with inconsistent metadata
+var ErrInvalidClientMetadata = errors.New("invalid client metadata")
+
+type OAuthService struct {
+	oauthRepo   repository.OAuthRepository
+	userRepo    repository.UserRepository
+	userService *UserService
+
+	// issuer is the base URL of the server, used as the iss claim of ID tokens
+	issuer string
+}
+
+func NewOAuthService(oauthRepo repository.OAuthRepository, userRepo repository.UserRepository, userService *UserService, issuer string) *OAuthService {
+	return &OAuthService{
+		oauthRepo:   oauthRepo,
+		userRepo:    userRepo,
+		userService: userService,
+		issuer:      strings.TrimSuffix(issuer, "/"),
+	}
+}
+
+// Client registry
+
+// CreateClient registers a client. Confidential clients get a secret that is only returned here.
+func (s *OAuthService) CreateClient(
+	ctx context.Context,
+	name string,
+	public bool,
+	redirectURIs []string,
+	grantTypes []string,
+	scopes []string,
+	postLogoutRedirectURIs []string,
+) (model.OAuthClientRegistration, error) {
+	// validate metadata
+	client := model.OAuthClient{
+		Name:         name,
+		Public:       public,
+		RedirectURIs: redirectURIs,
+		GrantTypes:   grantTypes,
+		Scopes:       scopes,
+
+		PostLogoutRedirectURIs: postLogoutRedirectURIs,
+	}
+	if err := validateClientMetadata(client); err != nil {
+		return model.OAuthClientRegistration{}, err
+	}
+
+	// generate client id and secret; the service account prefix is left to service accounts
+	var clientId string
+	var err error
+	for clientId == "" || utils.IsServiceAccountClientID(clientId) {
+		clientId, err = utils.GenerateOpaqueToken(16)
+		if err != nil {
+			return model.OAuthClientRegistration{}, err
+		}
+	}
+	client.ID = clientId
+
+	var clientSecret string
+	if !public {
+		clientSecret, err = utils.GenerateOpaqueToken(32)
+		if err != nil {
+			return model.OAuthClientRegistration{}, err
+		}
+
+		hashedSecret, err := bcrypt.GenerateFromPassword([]byte(clientSecret), bcrypt.DefaultCost)
+		if err != nil {
+			return model.OAuthClientRegistration{}, err
+		}
+		client.SecretHash = string(hashedSecret)
+	}
+
+	// create client
+	createdClient, err := s.oauthRepo.CreateClient(ctx, client)
+	if err != nil {
+		return model.OAuthClientRegistration{}, err
+	}
+
+	return model.OAuthClientRegistration{
+		OAuthClient:  createdClient,
+		ClientSecret: clientSecret,
+	}, nil
+}
+
+// validateClientMetadata checks the grant types and redirect URIs of a client fit together
+func validateClientMetadata(client model.OAuthClient) error {
+	if client.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidClientMetadata)
+	}
+	if len(client.GrantTypes) == 0 {
+		return fmt.Errorf("%w: at least one grant type is required", ErrInvalidClientMetadata)
+	}
+
+	for _, grantType := range client.GrantTypes {
+		switch grantType {
+		case model.GrantTypeAuthorizationCode, model.GrantTypeRefreshToken:
+		case model.GrantTypeClientCredentials:
+			if client.Public {
+				return fmt.Errorf("%w: public clients cannot use the client_credentials grant", ErrInvalidClientMetadata)
+			}
+		default:
+			return fmt.Errorf("%w: unsupported grant type %q", ErrInvalidClientMetadata, grantType)
+		}
+	}
+
+	if client.AllowsGrant(model.GrantTypeAuthorizationCode) && len(client.RedirectURIs) == 0 {
+		return fmt.Errorf("%w: the authorization_code grant requires a redirect URI", ErrInvalidClientMetadata)
+	}
+
+	for _, redirectURI := range client.RedirectURIs {
+		if !isValidRedirectURI(redirectURI) {
+			return fmt.Errorf("%w: redirect URI %q must be absolute and have no fragment", ErrInvalidClientMetadata, redirectURI)
+		}
+	}
+	for _, redirectURI := range client.PostLogoutRedirectURIs {
+		if !isValidRedirectURI(redirectURI) {
+			return fmt.Errorf("%w: post logout redirect URI %q must be absolute and have no fragment", ErrInvalidClientMetadata, redirectURI)
+		}
+	}
+
+	return nil
+}
+
+// isValidRedirectURI reports whether the URI is absolute and has no fragment
+func isValidRedirectURI(redirectURI string) bool {
+	parsed, err := url.Parse(redirectURI)
+	return err == nil && parsed.IsAbs() && parsed.Fragment == ""
+}
+
+// ListClients lists the registered clients
+func (s *OAuthService) ListClients(ctx context.Context) ([]model.OAuthClient, error) {
+	return s.oauthRepo.ListClients(ctx)
+}
+
+// DeleteClient removes a client and everything issued to it
+func (s *OAuthService) DeleteClient(ctx context.Context, clientId string) error {
+	return s.oauthRepo.DeleteClient(ctx, clientId)
+}
+
+// AuthenticateClient checks the credentials of a client calling the token,
+// revocation or introspection endpoints. Public clients only identify themselves.
+func (s *OAuthService) AuthenticateClient(ctx context.Context, clientId string, clientSecret string) (model.OAuthClient, error) {
+	client, err := s.oauthRepo.GetClientById(ctx, clientId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.OAuthClient{}, oauthError("invalid_client", "Client authentication failed")
+	}
+	if err != nil {
+		return model.OAuthClient{}, err
+	}
+
+	if !client.Public {
+		err = bcrypt.CompareHashAndPassword([]byte(client.SecretHash), []byte(clientSecret))
+		if err != nil {
+			return model.OAuthClient{}, oauthError("invalid_client", "Client authentication failed")
+		}
+	}
+
+	return client, nil
+}
+
+// Authorization endpoint
+
+// AuthenticateUser checks the credentials a user logs in with on the authorization page
+func (s *OAuthService) AuthenticateUser(ctx context.Context, email string, password string) (user model.User, err error) {
+	defer func() { metrics.RecordLogin(metrics.LoginMethodOAuth, outcomeOf(err)) }()
+
+	user, err = s.userService.AuthenticateUser(ctx, email, password)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	// inactive users cannot authorize clients
+	if user.AccountStatus != "active" {
+		return model.User{}, ErrAccountNotActive
+	}
+
+	return user, nil
+}
+
+// ValidateClientRedirect looks up the client of an authorization request and
+// resolves its redirect URI. Errors must not be redirected to the client.
+func (s *OAuthService) ValidateClientRedirect(ctx context.Context, clientId string, redirectURI string) (model.OAuthClient, string, error) {
+	client, err := s.oauthRepo.GetClientById(ctx, clientId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.OAuthClient{}, "", oauthError("invalid_request", "Unknown client")
+	}
+	if err != nil {
+		return model.OAuthClient{}, "", err
+	}
+
+	// the redirect URI may only be omitted when the client registered exactly one
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		return client, client.RedirectURIs[0], nil
+	}
+	for _, registered := range client.RedirectURIs {
+		if registered == redirectURI {
+			return client, redirectURI, nil
+		}
+	}
+
+	return model.OAuthClient{}, "", oauthError("invalid_request", "The redirect URI is not registered for this client")
+}
+
+// ValidateAuthorizationRequest checks the rest of an authorization request and
+// resolves its scope. Errors are redirected to the client.
+func (s *OAuthService) ValidateAuthorizationRequest(client model.OAuthClient, request model.AuthorizationRequest) (model.AuthorizationRequest, error) {
+	if request.ResponseType != "code" {
+		return request, oauthError("unsupported_response_type", "Only the code response type is supported")
+	}
+	if !client.AllowsGrant(model.GrantTypeAuthorizationCode) {
+		return request, oauthError("unauthorized_client", "The client is not allowed to use the authorization code grant")
+	}
+	if request.CodeChallenge == "" || request.CodeChallengeMethod != "S256" {
+		return request, oauthError("invalid_request", "PKCE with the S256 code challenge method is required")
+	}
+	if request.Nonce != "" && !hasScope(request.Scope, model.ScopeOpenID) {
+		return request, oauthError("invalid_request", "The nonce parameter requires the openid scope")
+	}
+
+	scope, err := resolveScope(client, request.Scope)
+	if err != nil {
+		return request, err
+	}
+	request.Scope = scope
+
+	return request, nil
+}
+
+// NeedsConsent reports whether the user has yet to consent to the requested scope
+func (s *OAuthService) NeedsConsent(ctx context.Context, userId uuid.UUID, request model.AuthorizationRequest) (bool, error) {
+	consentedScope, err := s.oauthRepo.GetConsentedScope(ctx, userId, request.ClientID)
+	if err != nil {
+		return false, err
+	}
+
+	return !scopeCovers(consentedScope, request.Scope), nil
+}
+
+// GrantConsent records the user's consent to the requested scope on top of earlier consents
+func (s *OAuthService) GrantConsent(ctx context.Context, userId uuid.UUID, request model.AuthorizationRequest) error {
+	consentedScope, err := s.oauthRepo.GetConsentedScope(ctx, userId, request.ClientID)
+	if err != nil {
+		return err
+	}
+
+	return s.oauthRepo.UpsertConsent(ctx, userId, request.ClientID, joinScopes(consentedScope, request.Scope))
+}
+
+// IssueAuthorizationCode issues a code for the request and returns the URL to redirect the user to
+func (s *OAuthService) IssueAuthorizationCode(ctx context.Context, userId uuid.UUID, request model.AuthorizationRequest) (string, error) {
+	// generate code
+	code, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	// store code, with the redirect URI only if the client sent it, as the
+	// token request must then repeat it (RFC 6749 section 4.1.3)
+	redirectURI := request.RedirectURI
+	if request.RedirectURIOmitted {
+		redirectURI = ""
+	}
+	err = s.oauthRepo.CreateAuthorizationCode(ctx, model.AuthorizationCode{
+		CodeHash:            utils.HashToken(code),
+		ClientID:            request.ClientID,
+		UserID:              userId,
+		RedirectURI:         redirectURI,
+		Scope:               request.Scope,
+		CodeChallenge:       request.CodeChallenge,
+		CodeChallengeMethod: request.CodeChallengeMethod,
+		Nonce:               request.Nonce,
+		ExpiresAt:           time.Now().Add(authorizationCodeLifetime),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return AuthorizationRedirect(request, url.Values{"code": {code}}), nil
+}
+
+// AuthorizationRedirect builds the redirect back to the client with the response parameters and the request's state
+func AuthorizationRedirect(request model.AuthorizationRequest, params url.Values) string {
+	if request.State != "" {
+		params.Set("state", request.State)
+	}
+	if len(params) == 0 {
+		return request.RedirectURI
+	}
+
+	separator := "?"
+	if strings.Contains(request.RedirectURI, "?") {
+		separator = "&"
+	}
+
+	return request.RedirectURI + separator + params.Encode()
+}
+
+// Token endpoint
+
+// ExchangeAuthorizationCode redeems an authorization code for tokens
+func (s *OAuthService) ExchangeAuthorizationCode(
+	ctx context.Context,
+	client model.OAuthClient,
+	code string,
+	redirectURI string,
+	codeVerifier string,
+) (model.TokenResponse, error) {
+	if !client.AllowsGrant(model.GrantTypeAuthorizationCode) {
+		return model.TokenResponse{}, oauthError("unauthorized_client", "The client is not allowed to use the authorization code grant")
+	}
+
+	// codes can only be redeemed once
+	authorizationCode, err := s.oauthRepo.ConsumeAuthorizationCode(ctx, utils.HashToken(code))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.TokenResponse{}, oauthError("invalid_grant", "The authorization code is invalid or has already been used")
+	}
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+
+	if authorizationCode.ClientID != client.ID || time.Now().After(authorizationCode.ExpiresAt) {
+		return model.TokenResponse{}, oauthError("invalid_grant", "The authorization code is invalid or has expired")
+	}
+	if authorizationCode.RedirectURI != "" && authorizationCode.RedirectURI != redirectURI {
+		return model.TokenResponse{}, oauthError("invalid_grant", "The redirect URI does not match the authorization request")
+	}
+	if !utils.VerifyCodeChallenge(codeVerifier, authorizationCode.CodeChallenge, authorizationCode.CodeChallengeMethod) {
+		return model.TokenResponse{}, oauthError("invalid_grant", "The code verifier does not match the code challenge")
+	}
+
+	// issue tokens
+	user, err := s.userRepo.GetUserById(ctx, authorizationCode.UserID)
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+	if user.AccountStatus != "active" {
+		return model.TokenResponse{}, oauthError("invalid_grant", "The account of the user is not active")
+	}
+
+	return s.issueUserTokens(ctx, client, user, authorizationCode.Scope, authorizationCode.Nonce)
+}
+
+// RefreshAccessToken exchanges a refresh token issued to the client for a new token pair.
+// The old refresh token is revoked.
+func (s *OAuthService) RefreshAccessToken(ctx context.Context, client model.OAuthClient, refreshToken string, scope string) (tokenResponse model.TokenResponse, err error) {
+	defer func() { metrics.RecordTokenRefresh(outcomeOf(err)) }()
+
+	if !client.AllowsGrant(model.GrantTypeRefreshToken) {
+		return model.TokenResponse{}, oauthError("unauthorized_client", "The client is not allowed to use the refresh token grant")
+	}
+
+	// check the refresh token
+	storedRefreshToken, err := s.userRepo.GetRefreshToken(ctx, refreshToken)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.TokenResponse{}, oauthError("invalid_grant", "The refresh token is invalid")
+	}
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+
+	if storedRefreshToken.ClientID.String != client.ID || storedRefreshToken.RevokedAt.Valid || time.Now().After(storedRefreshToken.ExpiresAt) {
+		return model.TokenResponse{}, oauthError("invalid_grant", "The refresh token is invalid, expired or revoked")
+	}
+
+	// the scope can only be narrowed
+	grantedScope := storedRefreshToken.Scope.String
+	if scope == "" {
+		scope = grantedScope
+	}
+	if !scopeCovers(grantedScope, scope) {
+		return model.TokenResponse{}, oauthError("invalid_scope", "The requested scope exceeds the granted scope")
+	}
+
+	// rotate the refresh token
+	user, err := s.userRepo.GetUserById(ctx, storedRefreshToken.UserID)
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+	if user.AccountStatus != "active" {
+		return model.TokenResponse{}, oauthError("invalid_grant", "The account of the user is not active")
+	}
+
+	err = s.userRepo.RevokeRefreshToken(ctx, storedRefreshToken.ID)
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+
+	return s.issueUserTokens(ctx, client, user, scope, "")
+}
+
+// ClientCredentials issues an access token to a confidential client acting on its own behalf
+func (s *OAuthService) ClientCredentials(ctx context.Context, client model.OAuthClient, scope string) (model.TokenResponse, error) {
+	if client.Public || !client.AllowsGrant(model.GrantTypeClientCredentials) {
+		return model.TokenResponse{}, oauthError("unauthorized_client", "The client is not allowed to use the client credentials grant")
+	}
+
+	scope, err := resolveScope(client, scope)
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+
+	// client tokens have no user and are subject to the client
+	claims := utils.UserClaims{
+		ClientID: client.ID,
+		Scope:    scope,
+	}
+	claims.Subject = client.ID
+
+	accessToken, _, err := utils.GenerateAccessToken(claims)
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+
+	return model.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(utils.AccessTokenLifetime.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// issueUserTokens issues tokens for the user to the client, with a refresh token
+// if the client may use them and an ID token if the openid scope was granted
+func (s *OAuthService) issueUserTokens(ctx context.Context, client model.OAuthClient, user model.User, scope string, nonce string) (model.TokenResponse, error) {
+	orgId, err := s.userService.DefaultOrganization(ctx, user.ID)
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+
+	claims := utils.UserClaims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     user.UserRole,
+		OrgID:    orgId,
+		ClientID: client.ID,
+		Scope:    scope,
+	}
+
+	tokenResponse := model.TokenResponse{
+		TokenType: "Bearer",
+		ExpiresIn: int64(utils.AccessTokenLifetime.Seconds()),
+		Scope:     scope,
+	}
+
+	if client.AllowsGrant(model.GrantTypeRefreshToken) {
+		accessToken, refreshToken, expireTime, err := utils.GenerateTokenPair(claims)
+		if err != nil {
+			return model.TokenResponse{}, err
+		}
+
+		_, err = s.oauthRepo.StoreClientRefreshToken(ctx, user.ID, client.ID, scope, refreshToken, expireTime)
+		if err != nil {
+			return model.TokenResponse{}, err
+		}
+
+		tokenResponse.AccessToken = accessToken
+		tokenResponse.RefreshToken = refreshToken
+	} else {
+		tokenResponse.AccessToken, _, err = utils.GenerateAccessToken(claims)
+		if err != nil {
+			return model.TokenResponse{}, err
+		}
+	}
+
+	if hasScope(scope, model.ScopeOpenID) {
+		tokenResponse.IDToken, err = s.generateIDToken(client, user, scope, nonce, tokenResponse.AccessToken)
+		if err != nil {
+			return model.TokenResponse{}, err
+		}
+	}
+
+	return tokenResponse, nil
+}
+
+// Revocation and introspection endpoints
+
+// RevokeToken revokes a refresh token issued to the client (RFC 7009). Unknown
+// tokens are ignored. Access tokens are self-contained and cannot be revoked.
+func (s *OAuthService) RevokeToken(ctx context.Context, client model.OAuthClient, token string, tokenTypeHint string) error {
+	if tokenTypeHint == "access_token" {
+		return oauthError("unsupported_token_type", "Access tokens cannot be revoked; they expire on their own")
+	}
+
+	storedRefreshToken, err := s.userRepo.GetRefreshToken(ctx, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := utils.ParseToken(token, true); err == nil {
+			return oauthError("unsupported_token_type", "Access tokens cannot be revoked; they expire on their own")
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// clients can only revoke their own tokens
+	if storedRefreshToken.ClientID.String != client.ID {
+		return nil
+	}
+
+	return s.userRepo.RevokeRefreshToken(ctx, storedRefreshToken.ID)
+}
+
+// IntrospectToken describes an access token or a refresh token of the client (RFC 7662)
+func (s *OAuthService) IntrospectToken(ctx context.Context, client model.OAuthClient, token string) (model.TokenIntrospection, error) {
+	if client.Public {
+		return model.TokenIntrospection{}, oauthError("unauthorized_client", "Public clients cannot introspect tokens")
+	}
+
+	// access tokens are valid as long as their signature and expiry are
+	if claims, err := utils.ParseToken(token, true); err == nil {
+		return model.TokenIntrospection{
+			Active:    true,
+			Scope:     claims.Scope,
+			ClientID:  claims.ClientID,
+			Username:  claims.Username,
+			TokenType: "Bearer",
+			Exp:       claims.ExpiresAt.Unix(),
+			Iat:       claims.IssuedAt.Unix(),
+			Sub:       claims.Subject,
+		}, nil
+	}
+
+	// refresh tokens must also be stored, unrevoked and the client's own
+	storedRefreshToken, err := s.userRepo.GetRefreshToken(ctx, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.TokenIntrospection{Active: false}, nil
+	}
+	if err != nil {
+		return model.TokenIntrospection{}, err
+	}
+
+	if storedRefreshToken.ClientID.String != client.ID || storedRefreshToken.RevokedAt.Valid || time.Now().After(storedRefreshToken.ExpiresAt) {
+		return model.TokenIntrospection{Active: false}, nil
+	}
+
+	user, err := s.userRepo.GetUserById(ctx, storedRefreshToken.UserID)
+	if err != nil {
+		return model.TokenIntrospection{}, err
+	}
+	if user.AccountStatus != "active" {
+		return model.TokenIntrospection{Active: false}, nil
+	}
+
+	return model.TokenIntrospection{
+		Active:   true,
+		Scope:    storedRefreshToken.Scope.String,
+		ClientID: client.ID,
+		Username: user.Username,
+		Exp:      storedRefreshToken.ExpiresAt.Unix(),
+		Iat:      storedRefreshToken.CreatedAt.Unix(),
+		Sub:      user.ID.String(),
+	}, nil
+}
+
+// OpenID Connect
+
+// generateIDToken signs an ID token for the user, carrying the claims released by the scope
+func (s *OAuthService) generateIDToken(client model.OAuthClient, user model.User, scope string, nonce string, accessToken string) (string, error) {
+	claims := jwt.MapClaims(userInfoClaims(user, scope))
+	claims["iss"] = s.issuer
+	claims["aud"] = client.ID
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = time.Now().Add(utils.IDTokenLifetime).Unix()
+	claims["at_hash"] = utils.AccessTokenHash(accessToken)
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+
+	return utils.GenerateIDToken(claims)
+}
+
+// userInfoClaims returns the standard claims about the user released by the
+// scope. Email addresses and phone numbers are not verified by this server.
+func userInfoClaims(user model.User, scope string) map[string]interface{} {
+	claims := map[string]interface{}{
+		"sub": user.ID.String(),
+	}
+
+	if hasScope(scope, model.ScopeProfile) {
+		claims["name"] = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		claims["given_name"] = user.FirstName
+		claims["family_name"] = user.LastName
+		claims["preferred_username"] = user.Username
+		if user.ProfilePicture.Valid {
+			claims["picture"] = user.ProfilePicture.String
+		}
+		if user.Gender.Valid {
+			claims["gender"] = user.Gender.String
+		}
+		if user.DateOfBirth.Valid {
+			claims["birthdate"] = user.DateOfBirth.Time.Format("2006-01-02")
+		}
+	}
+
+	if hasScope(scope, model.ScopeEmail) {
+		claims["email"] = user.Email
+		claims["email_verified"] = false
+	}
+
+	if hasScope(scope, model.ScopePhone) && user.PhoneNumber.Valid {
+		claims["phone_number"] = user.PhoneNumber.String
+		claims["phone_number_verified"] = false
+	}
+
+	return claims
+}
+
+// UserInfo returns the claims about the user of an access token granted the openid scope
+func (s *OAuthService) UserInfo(ctx context.Context, claims *utils.UserClaims) (map[string]interface{}, error) {
+	if claims.UserID == uuid.Nil || claims.PrincipalType == model.PrincipalServiceAccount {
+		return nil, oauthError("invalid_token", "The access token was not issued to a user")
+	}
+	if !hasScope(claims.Scope, model.ScopeOpenID) {
+		return nil, oauthError("insufficient_scope", "The access token was not granted the openid scope")
+	}
+
+	user, err := s.userService.GetUserById(ctx, claims.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, oauthError("invalid_token", "The user of the access token no longer exists")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.AccountStatus != "active" {
+		return nil, oauthError("invalid_token", "The account of the user is not active")
+	}
+
+	return userInfoClaims(user, claims.Scope), nil
+}
+
+// OpenIDConfiguration returns the discovery document of the server
+func (s *OAuthService) OpenIDConfiguration() model.OpenIDConfiguration {
+	return model.OpenIDConfiguration{
+		Issuer:                 s.issuer,
+		AuthorizationEndpoint:  s.issuer + "/oauth/authorize",
+		TokenEndpoint:          s.issuer + "/oauth/token",
+		UserInfoEndpoint:       s.issuer + "/oauth/userinfo",
+		JWKSURI:                s.issuer + "/.well-known/jwks.json",
+		EndSessionEndpoint:     s.issuer + "/oauth/logout",
+		RevocationEndpoint:     s.issuer + "/oauth/revoke",
+		IntrospectionEndpoint:  s.issuer + "/oauth/introspect",
+		ScopesSupported:        []string{model.ScopeOpenID, model.ScopeProfile, model.ScopeEmail, model.ScopePhone},
+		ResponseTypesSupported: []string{"code"},
+		GrantTypesSupported: []string{
+			model.GrantTypeAuthorizationCode,
+			model.GrantTypeRefreshToken,
+			model.GrantTypeClientCredentials,
+			model.GrantTypeJWTBearer,
+		},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "private_key_jwt", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "nonce", "at_hash",
+			"name", "given_name", "family_name", "preferred_username", "picture", "gender", "birthdate",
+			"email", "email_verified", "phone_number", "phone_number_verified",
+		},
+	}
+}
+
+// Logout ends the sessions of the user of the ID token hint at its client by
+// revoking the refresh tokens issued to it, and returns where to send the user
+// afterwards. The redirect is empty unless the client registered it.
+func (s *OAuthService) Logout(ctx context.Context, request model.LogoutRequest) (string, error) {
+	clientId := request.ClientID
+
+	if request.IDTokenHint != "" {
+		claims, err := utils.ParseIDTokenHint(request.IDTokenHint)
+		if err != nil {
+			return "", oauthError("invalid_request", "The ID token hint is invalid")
+		}
+
+		audience, _ := claims.GetAudience()
+		subject, _ := claims.GetSubject()
+		userId, err := uuid.Parse(subject)
+		if err != nil || len(audience) != 1 || (clientId != "" && audience[0] != clientId) {
+			return "", oauthError("invalid_request", "The ID token hint does not match the client")
+		}
+		clientId = audience[0]
+
+		err = s.oauthRepo.RevokeClientRefreshTokens(ctx, userId, clientId)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if request.PostLogoutRedirectURI == "" {
+		return "", nil
+	}
+
+	// only redirect to URIs registered by the client
+	client, err := s.oauthRepo.GetClientById(ctx, clientId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", oauthError("invalid_request", "Unknown client")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for _, registered := range client.PostLogoutRedirectURIs {
+		if registered == request.PostLogoutRedirectURI {
+			return AuthorizationRedirect(model.AuthorizationRequest{
+				RedirectURI: registered,
+				State:       request.State,
+			}, url.Values{}), nil
+		}
+	}
+
+	return "", oauthError("invalid_request", "The post logout redirect URI is not registered for this client")
+}
+
+// Scopes
+
+// hasScope reports whether the space-separated scope list contains the scope
+func hasScope(scopeList string, scope string) bool {
+	for _, granted := range strings.Fields(scopeList) {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveScope checks the requested scopes are registered for the client. An
+// empty request gets every scope of the client.
+func resolveScope(client model.OAuthClient, requested string) (string, error) {
+	if requested == "" {
+		return strings.Join(client.Scopes, " "), nil
+	}
+
+	allowed := make(map[string]bool, len(client.Scopes))
+	for _, scope := range client.Scopes {
+		allowed[scope] = true
+	}
+
+	for _, scope := range strings.Fields(requested) {
+		if !allowed[scope] {
+			return "", oauthError("invalid_scope", fmt.Sprintf("The scope %q is not registered for this client", scope))
+		}
+	}
+
+	return joinScopes(requested), nil
+}
+
+// scopeCovers reports whether every requested scope was granted
+func scopeCovers(granted string, requested string) bool {
+	grantedScopes := make(map[string]bool)
+	for _, scope := range strings.Fields(granted) {
+		grantedScopes[scope] = true
+	}
+
+	for _, scope := range strings.Fields(requested) {
+		if !grantedScopes[scope] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// joinScopes joins space-separated scope lists without duplicates
+func joinScopes(scopeLists ...string) string {
+	seen := make(map[string]bool)
+	var scopes []string
+	for _, scopeList := range scopeLists {
+		for _, scope := range strings.Fields(scopeList) {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	return strings.Join(scopes, " ")
+}
@@ -0,0 +1,92 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/google/uuid"
+)
+
+// codeOAuthRepository hands out the one authorization code stored through it
+type codeOAuthRepository struct {
+	repository.OAuthRepository
+	code model.AuthorizationCode
+}
+
+func (r *codeOAuthRepository) CreateAuthorizationCode(ctx context.Context, code model.AuthorizationCode) error {
+	r.code = code
+	return nil
+}
+
+func (r *codeOAuthRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (model.AuthorizationCode, error) {
+	return r.code, nil
+}
+
+// suspendedUserRepository returns suspended users, so exchanges that get past
+// the checks of the code stop before issuing tokens
+type suspendedUserRepository struct {
+	repository.UserRepository
+}
+
+func (r *suspendedUserRepository) GetUserById(ctx context.Context, userId uuid.UUID) (model.User, error) {
+	return model.User{ID: userId, AccountStatus: "suspended"}, nil
+}
+
+func TestExchangeAuthorizationCodeRedirectURI(t *testing.T) {
+	const registered = "https://client.example.com/callback"
+	verifier := strings.Repeat("v", 43)
+	sum := sha256.Sum256([]byte(verifier))
+	client := model.OAuthClient{ID: "client", RedirectURIs: []string{registered}, GrantTypes: []string{model.GrantTypeAuthorizationCode}}
+
+	tests := []struct {
+		name        string
+		omitted     bool
+		redirectURI string
+		description string
+	}{
+		{name: "sent at authorize and repeated", redirectURI: registered},
+		{name: "sent at authorize but not repeated", description: "The redirect URI does not match the authorization request"},
+		{name: "sent at authorize but changed", redirectURI: "https://client.example.com/other", description: "The redirect URI does not match the authorization request"},
+		{name: "omitted at authorize and at the token endpoint", omitted: true},
+		{name: "omitted at authorize but sent at the token endpoint", omitted: true, redirectURI: registered},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			oauthRepo := &codeOAuthRepository{}
+			service := NewOAuthService(oauthRepo, &suspendedUserRepository{}, nil, "https://auth.example.com")
+
+			_, err := service.IssueAuthorizationCode(context.Background(), uuid.New(), model.AuthorizationRequest{
+				ClientID:            client.ID,
+				RedirectURI:         registered,
+				RedirectURIOmitted:  test.omitted,
+				CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+				CodeChallengeMethod: "S256",
+			})
+			if err != nil {
+				t.Fatalf("issuing the code: %v", err)
+			}
+
+			_, err = service.ExchangeAuthorizationCode(context.Background(), client, "code", test.redirectURI, verifier)
+			var oauthErr *OAuthError
+			if !errors.As(err, &oauthErr) {
+				t.Fatalf("got error %v, want an OAuth error", err)
+			}
+
+			// the suspended user is only looked up once the code checks passed
+			want := test.description
+			if want == "" {
+				want = "The account of the user is not active"
+			}
+			if oauthErr.Description != want {
+				t.Errorf("got %q, want %q", oauthErr.Description, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+
+	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNoOrganization is returned when a tenant-scoped action is made without an organization on the token
+	ErrNoOrganization = &Error{Kind: ErrForbidden, Code: "no_organization", Message: "This action requires an organization"}
+	// ErrOutsideOrganization is returned when the target user is not a member of the caller's organization
+	ErrOutsideOrganization = &Error{Kind: ErrForbidden, Code: "outside_organization", Message: "User is not a member of your organization"}
+	// ErrNotOrganizationMember is returned when the caller is not a member of the requested organization
+	ErrNotOrganizationMember = &Error{Kind: ErrForbidden, Code: "not_organization_member", Message: "You are not a member of this organization"}
+	// ErrOrganizationAdminTarget is returned when an organization admin acts on another organization admin
+	ErrOrganizationAdminTarget = &Error{Kind: ErrForbidden, Code: "organization_admin_target", Message: "Organization admins cannot act on other organization admins"}
+	// ErrInvalidOrgRole is returned for organization roles other than admin and member
+	ErrInvalidOrgRole = &Error{Kind: ErrValidation, Code: "invalid_org_role", Message: "Organization role must be 'admin' or 'member'"}
+	// ErrInvalidSlug is returned for slugs outside of [a-z0-9-]{1,50}
+	ErrInvalidSlug = &Error{Kind: ErrValidation, Code: "invalid_slug", Message: "Slug must be 1-50 lowercase letters, digits or '-'"}
+	// ErrSlugTaken is returned when another organization already has the slug
+	ErrSlugTaken = &Error{Kind: ErrConflict, Code: "slug_taken", Message: "Organization slug already exists"}
+)
+
+var slugPattern = regexp.MustCompile("^[a-z0-9-]{1,50}$")
+
+type OrganizationService struct {
+	orgRepo  repository.OrganizationRepository
+	userRepo repository.UserRepository
+	roleRepo repository.RoleRepository
+}
+
+func NewOrganizationService(orgRepo repository.OrganizationRepository, userRepo repository.UserRepository, roleRepo repository.RoleRepository) *OrganizationService {
+	return &OrganizationService{
+		orgRepo:  orgRepo,
+		userRepo: userRepo,
+		roleRepo: roleRepo,
+	}
+}
+
+// CreateOrganization creates a new organization
+func (s *OrganizationService) CreateOrganization(ctx context.Context, name string, slug string) (model.Organization, error) {
+	if !slugPattern.MatchString(slug) {
+		return model.Organization{}, ErrInvalidSlug
+	}
+
+	org, err := s.orgRepo.CreateOrganization(ctx, model.Organization{
+		ID:   uuid.New(),
+		Name: name,
+		Slug: slug,
+	})
+	if repository.IsConflict(err, "organizations_slug_key") {
+		return model.Organization{}, ErrSlugTaken
+	}
+
+	return org, err
+}
+
+// ListOrganizations returns a page of organizations
+func (s *OrganizationService) ListOrganizations(ctx context.Context, limit, offset int32) ([]model.Organization, error) {
+	return s.orgRepo.ListOrganizations(ctx, limit, offset)
+}
+
+// SetMember adds the user to the organization or changes their role in it
+func (s *OrganizationService) SetMember(ctx context.Context, orgId uuid.UUID, userId uuid.UUID, orgRole string) (model.OrganizationMember, error) {
+	if orgRole != model.OrgRoleAdmin && orgRole != model.OrgRoleMember {
+		return model.OrganizationMember{}, ErrInvalidOrgRole
+	}
+
+	// make sure the organization exists
+	_, err := s.orgRepo.GetOrganizationById(ctx, orgId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.OrganizationMember{}, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return model.OrganizationMember{}, err
+	}
+
+	return s.orgRepo.UpsertOrganizationMember(ctx, orgId, userId, orgRole)
+}
+
+// RemoveMember removes the user from the organization
+func (s *OrganizationService) RemoveMember(ctx context.Context, orgId uuid.UUID, userId uuid.UUID) error {
+	return s.orgRepo.DeleteOrganizationMember(ctx, orgId, userId)
+}
+
+// GetMembership returns the user's membership of the organization
+func (s *OrganizationService) GetMembership(ctx context.Context, orgId uuid.UUID, userId uuid.UUID) (model.OrganizationMember, error) {
+	member, err := s.orgRepo.GetOrganizationMember(ctx, orgId, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.OrganizationMember{}, ErrNotOrganizationMember
+	}
+
+	return member, err
+}
+
+// GetUserMemberships returns the memberships of the user in the context
+func (s *OrganizationService) GetUserMemberships(ctx context.Context) ([]model.OrganizationMember, error) {
+	userId := ctx.Value("userId").(uuid.UUID)
+	return s.orgRepo.GetUserOrganizationMemberships(ctx, userId)
+}
+
+// GetMembers returns a page of the members of the caller's organization
+func (s *OrganizationService) GetMembers(ctx context.Context, limit, offset int32) ([]database.User, error) {
+	orgId, ok := utils.GetOrgIdFromContext(ctx)
+	if !ok {
+		return nil, ErrNoOrganization
+	}
+
+	return s.orgRepo.GetOrganizationUsers(ctx, orgId, limit, offset)
+}
+
+// SuspendMember suspends a member of the caller's organization
+func (s *OrganizationService) SuspendMember(ctx context.Context, userId uuid.UUID) (model.User, error) {
+	change, err := s.checkMemberTarget(ctx, userId, true)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if err := deactivateUser(ctx, s.userRepo, change, "suspended"); err != nil {
+		return model.User{}, err
+	}
+	return s.userRepo.GetUserById(ctx, userId)
+}
+
+// RecoverMember recovers a member of the caller's organization
+func (s *OrganizationService) RecoverMember(ctx context.Context, userId uuid.UUID) (model.User, error) {
+	if _, err := s.checkMemberTarget(ctx, userId, false); err != nil {
+		return model.User{}, err
+	}
+
+	return s.userRepo.RecoverUser(ctx, userId)
+}
+
+// checkMemberTarget makes sure an organization admin only acts on regular
+// members of their own organization. Account statuses are shared by every
+// organization, so members holding a platform role other than user must also
+// rank below the admin's own platform role.
+func (s *OrganizationService) checkMemberTarget(ctx context.Context, userId uuid.UUID, deactivates bool) (statusChange, error) {
+	orgId, ok := utils.GetOrgIdFromContext(ctx)
+	if !ok {
+		return statusChange{}, ErrNoOrganization
+	}
+
+	member, err := s.orgRepo.GetOrganizationMember(ctx, orgId, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return statusChange{}, ErrOutsideOrganization
+	}
+	if err != nil {
+		return statusChange{}, err
+	}
+
+	if member.OrgRole == model.OrgRoleAdmin {
+		return statusChange{}, ErrOrganizationAdminTarget
+	}
+
+	// check the platform roles against the hierarchy
+	actorId := ctx.Value("userId").(uuid.UUID)
+	actorRole, err := highestRole(ctx, s.roleRepo, actorId)
+	if err != nil {
+		return statusChange{}, err
+	}
+	change, err := newStatusChange(ctx, s.roleRepo, actorId, actorRole, userId, deactivates)
+	if err != nil {
+		return statusChange{}, err
+	}
+	if change.targetRole.Name == model.RoleUser {
+		return change, nil
+	}
+
+	return change, checkStatusChange(change)
+}
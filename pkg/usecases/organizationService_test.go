@@ -0,0 +1,74 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+	"github.com/google/uuid"
+)
+
+// memberOrganizationRepository makes every user a member of every organization
+type memberOrganizationRepository struct {
+	repository.OrganizationRepository
+	orgRole string
+}
+
+func (r *memberOrganizationRepository) GetOrganizationMember(ctx context.Context, orgId uuid.UUID, userId uuid.UUID) (model.OrganizationMember, error) {
+	return model.OrganizationMember{OrgID: orgId, UserID: userId, OrgRole: r.orgRole}, nil
+}
+
+// rankedRoleRepository holds the platform roles of users
+type rankedRoleRepository struct {
+	repository.RoleRepository
+	roles             map[uuid.UUID]model.Role
+	activeSuperAdmins int64
+}
+
+func (r *rankedRoleRepository) GetUserRoles(ctx context.Context, userId uuid.UUID) ([]model.Role, error) {
+	if role, ok := r.roles[userId]; ok {
+		return []model.Role{role}, nil
+	}
+	return nil, nil
+}
+
+func (r *rankedRoleRepository) CountActiveRoleMembers(ctx context.Context, role string) (int64, error) {
+	return r.activeSuperAdmins, nil
+}
+
+func TestCheckMemberTarget(t *testing.T) {
+	actor, target := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name       string
+		orgRole    string
+		actorRole  model.Role
+		targetRole model.Role
+		code       string
+	}{
+		{name: "regular member", orgRole: model.OrgRoleMember, actorRole: userRole, targetRole: userRole},
+		{name: "member ranked below the actor", orgRole: model.OrgRoleMember, actorRole: adminRole, targetRole: supportRole},
+		{name: "organization admin", orgRole: model.OrgRoleAdmin, actorRole: adminRole, targetRole: userRole, code: "organization_admin_target"},
+		{name: "member who is a platform admin", orgRole: model.OrgRoleMember, actorRole: userRole, targetRole: adminRole, code: "target_not_subordinate"},
+		{name: "member who is a platform peer", orgRole: model.OrgRoleMember, actorRole: adminRole, targetRole: adminRole, code: "target_not_subordinate"},
+		{name: "member who is a platform superadmin", orgRole: model.OrgRoleMember, actorRole: adminRole, targetRole: superAdminRole, code: "target_not_subordinate"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := NewOrganizationService(
+				&memberOrganizationRepository{orgRole: test.orgRole},
+				nil,
+				&rankedRoleRepository{roles: map[uuid.UUID]model.Role{actor: test.actorRole, target: test.targetRole}, activeSuperAdmins: 1},
+			)
+			ctx := utils.SetOrgIdInContext(utils.SetUserIdInContext(context.Background(), actor), uuid.New())
+
+			_, err := service.checkMemberTarget(ctx, target, true)
+			if code := roleCode(t, err); code != test.code {
+				t.Errorf("got code %q, want %q", code, test.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package usecases
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Registration modes
+const (
+	// RegistrationOpen lets anyone sign up
+	RegistrationOpen = "open"
+	// RegistrationInviteOnly only lets people who were invited to an organization sign up
+	RegistrationInviteOnly = "invite_only"
+	// RegistrationClosed only lets administrators create accounts
+	RegistrationClosed = "closed"
+)
+
+var (
+	// ErrRegistrationClosed is returned when signing up while registration is closed
+	ErrRegistrationClosed = &Error{Kind: ErrForbidden, Code: "registration_closed", Message: "Registration is closed"}
+	// ErrRegistrationInviteOnly is returned when signing up without an invitation while registration is invite only
+	ErrRegistrationInviteOnly = &Error{Kind: ErrForbidden, Code: "registration_invite_only", Message: "Registration is by invitation only"}
+	// ErrEmailDomainNotAllowed is returned when signing up with an email domain the policy refuses
+	ErrEmailDomainNotAllowed = &Error{Kind: ErrForbidden, Code: "email_domain_not_allowed", Message: "Sign-ups with this email domain are not allowed"}
+)
+
+// RegistrationPolicy decides who can create an account for themselves and
+// with which role. Accounts created by administrators are not subject to it.
+type RegistrationPolicy struct {
+	Mode string
+	// DefaultRole is the role of users who sign up themselves
+	DefaultRole string
+	// AllowedDomains, when not empty, are the only email domains that can sign up
+	AllowedDomains []string
+	// DeniedDomains are email domains that cannot sign up
+	DeniedDomains []string
+}
+
+// NewRegistrationPolicy returns the policy after making sure its mode is known
+func NewRegistrationPolicy(mode string, defaultRole string, allowedDomains []string, deniedDomains []string) (RegistrationPolicy, error) {
+	switch mode {
+	case RegistrationOpen, RegistrationInviteOnly, RegistrationClosed:
+	default:
+		return RegistrationPolicy{}, fmt.Errorf("unknown registration mode %q, expected %s, %s or %s", mode, RegistrationOpen, RegistrationInviteOnly, RegistrationClosed)
+	}
+
+	if defaultRole == "" {
+		return RegistrationPolicy{}, fmt.Errorf("default role is required")
+	}
+
+	return RegistrationPolicy{
+		Mode:           mode,
+		DefaultRole:    defaultRole,
+		AllowedDomains: lowerAll(allowedDomains),
+		DeniedDomains:  lowerAll(deniedDomains),
+	}, nil
+}
+
+// lowerAll returns the values in lower case
+func lowerAll(values []string) []string {
+	lowered := make([]string, 0, len(values))
+	for _, value := range values {
+		lowered = append(lowered, strings.ToLower(value))
+	}
+
+	return lowered
+}
+
+// checkSignUp makes sure the email can sign up without an invitation
+func (p RegistrationPolicy) checkSignUp(email string) error {
+	switch p.Mode {
+	case RegistrationInviteOnly:
+		return ErrRegistrationInviteOnly
+	case RegistrationClosed:
+		return ErrRegistrationClosed
+	}
+
+	return p.checkEmailDomain(email)
+}
+
+// checkInvitedSignUp makes sure the email can sign up with an invitation
+func (p RegistrationPolicy) checkInvitedSignUp(email string) error {
+	if p.Mode == RegistrationClosed {
+		return ErrRegistrationClosed
+	}
+
+	return p.checkEmailDomain(email)
+}
+
+// checkEmailDomain makes sure the domain of the email is allowed and not
+// denied. Listed domains also match their subdomains.
+func (p RegistrationPolicy) checkEmailDomain(email string) error {
+	_, domain, _ := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+
+	if matchesDomain(domain, p.DeniedDomains) {
+		return ErrEmailDomainNotAllowed
+	}
+	if len(p.AllowedDomains) > 0 && !matchesDomain(domain, p.AllowedDomains) {
+		return ErrEmailDomainNotAllowed
+	}
+
+	return nil
+}
+
+// matchesDomain reports whether the domain is one of domains or a subdomain of one
+func matchesDomain(domain string, domains []string) bool {
+	for _, listed := range domains {
+		if domain == listed || strings.HasSuffix(domain, "."+listed) {
+			return true
+		}
+	}
+
+	return false
+}
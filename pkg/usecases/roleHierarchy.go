@@ -0,0 +1,219 @@
+package usecases
+
+import (
+	"context"
+	"math"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrRoleNotBelowActor is returned when the role is not ranked below the actor's own role
+	ErrRoleNotBelowActor = &Error{Kind: ErrForbidden, Code: "role_not_below_actor", Message: "You can only grant or manage roles below your own"}
+	// ErrTargetNotSubordinate is returned when the target user is the actor's peer or superior
+	ErrTargetNotSubordinate = &Error{Kind: ErrForbidden, Code: "target_not_subordinate", Message: "You cannot change the roles of a peer or superior"}
+	// ErrLastSuperAdmin is returned when the change would leave no super administrator
+	ErrLastSuperAdmin = &Error{Kind: ErrConflict, Code: "last_superadmin", Message: "The last super administrator cannot be demoted"}
+	// ErrLastActiveSuperAdmin is returned when the change would leave no active super administrator
+	ErrLastActiveSuperAdmin = &Error{Kind: ErrConflict, Code: "last_superadmin", Message: "The last active super administrator cannot be suspended or deleted"}
+	// ErrStatusTargetNotSubordinate is returned when the target user of a status change is the actor's peer or superior
+	ErrStatusTargetNotSubordinate = &Error{Kind: ErrForbidden, Code: "target_not_subordinate", Message: "You cannot suspend, recover or delete a peer or superior"}
+	// ErrRoleNotFound is returned when the named role does not exist
+	ErrRoleNotFound = &Error{Kind: ErrNotFound, Code: "role_not_found", Message: "Role not found"}
+	// ErrRoleExists is returned when creating or renaming to a role name that is taken
+	ErrRoleExists = &Error{Kind: ErrConflict, Code: "role_exists", Message: "A role with this name already exists"}
+	// ErrInvalidRoleName is returned for names outside of [a-z0-9_-]{1,50}
+	ErrInvalidRoleName = &Error{Kind: ErrValidation, Code: "invalid_role_name", Message: "Role names must be 1-50 lowercase letters, digits, '-' or '_'"}
+	// ErrReservedRoleName is returned when creating or renaming to the role service accounts act with
+	ErrReservedRoleName = &Error{Kind: ErrConflict, Code: "reserved_role_name", Message: "This role name is reserved for service accounts"}
+	// ErrBuiltInRole is returned when renaming, deleting or editing a built-in role
+	ErrBuiltInRole = &Error{Kind: ErrConflict, Code: "built_in_role", Message: "Built-in roles cannot be modified"}
+	// ErrRoleInUse is returned when deleting a role that still has members
+	ErrRoleInUse = &Error{Kind: ErrConflict, Code: "role_in_use", Message: "The role still has members"}
+	// ErrPermissionNotHeld is returned when attaching a permission the actor does not hold
+	ErrPermissionNotHeld = &Error{Kind: ErrForbidden, Code: "permission_not_held", Message: "You can only attach permissions you hold"}
+	// ErrRoleAlreadyAssigned is returned when the user already holds the role
+	ErrRoleAlreadyAssigned = &Error{Kind: ErrConflict, Code: "role_already_assigned", Message: "The user already holds this role"}
+	// ErrRoleNotAssigned is returned when revoking a role the user does not hold
+	ErrRoleNotAssigned = &Error{Kind: ErrConflict, Code: "role_not_assigned", Message: "The user does not hold this role"}
+)
+
+// operatorRole is where operators on the command line sit in the hierarchy.
+// They outrank every role, as they have access to the database anyway.
+var operatorRole = model.Role{Name: "operator", Rank: math.MaxInt32}
+
+// roleChange describes a request by actor to grant or revoke role on target.
+// Ranks are those of the highest ranked role each user holds.
+type roleChange struct {
+	actorId    uuid.UUID
+	actorRole  model.Role
+	targetId   uuid.UUID
+	targetRole model.Role
+	role       model.Role
+	// superAdmins is the current number of super administrators
+	superAdmins int64
+}
+
+// checkRoleGrant enforces the role hierarchy on grants:
+//   - actors can only grant roles below their own. Super administrators sit at
+//     the top of the hierarchy and may also grant their own role to others.
+//   - actors cannot change the roles of peers or superiors.
+func checkRoleGrant(c roleChange) error {
+	isSelf := c.actorId == c.targetId
+
+	// peers and superiors are off limits
+	if !isSelf && c.targetRole.Rank >= c.actorRole.Rank {
+		return ErrTargetNotSubordinate
+	}
+
+	// granted role must sit below the actor
+	if c.role.Rank >= c.actorRole.Rank {
+		isApexGrant := c.actorRole.Name == model.RoleSuperAdmin && c.role.Name == model.RoleSuperAdmin && !isSelf
+		if !isApexGrant {
+			return ErrRoleNotBelowActor
+		}
+	}
+
+	return nil
+}
+
+// checkRoleRevoke enforces the role hierarchy on revocations:
+//   - actors cannot change the roles of peers or superiors, but may give up
+//     their own roles.
+//   - the last super administrator cannot be demoted.
+func checkRoleRevoke(c roleChange) error {
+	isSelf := c.actorId == c.targetId
+
+	// peers and superiors are off limits
+	if !isSelf && c.targetRole.Rank >= c.actorRole.Rank {
+		return ErrTargetNotSubordinate
+	}
+
+	// keep at least one super administrator around
+	if c.role.Name == model.RoleSuperAdmin && c.superAdmins <= 1 {
+		return ErrLastSuperAdmin
+	}
+
+	return nil
+}
+
+// highestRole returns the highest ranked role the user holds, or the user role
+// when they hold none
+func highestRole(ctx context.Context, roleRepo repository.RoleRepository, userId uuid.UUID) (model.Role, error) {
+	roles, err := roleRepo.GetUserRoles(ctx, userId)
+	if err != nil {
+		return model.Role{}, err
+	}
+	if len(roles) == 0 {
+		return model.Role{Name: model.RoleUser}, nil
+	}
+
+	return roles[0], nil
+}
+
+// revokeUserRole takes the role away from the user. The super administrator
+// role is revoked in one statement that keeps its last member, as the count
+// checkRoleRevoke saw can be stale by the time it is revoked.
+func revokeUserRole(ctx context.Context, roleRepo repository.RoleRepository, userId uuid.UUID, role string) error {
+	if role != model.RoleSuperAdmin {
+		return roleRepo.RevokeUserRole(ctx, userId, role)
+	}
+
+	revoked, err := roleRepo.RevokeUserRoleUnlessLast(ctx, userId, role)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrLastSuperAdmin
+	}
+
+	return nil
+}
+
+// statusChange describes a request by actor to suspend, recover or delete
+// target. Ranks are those of the highest ranked role each user holds.
+type statusChange struct {
+	actorId    uuid.UUID
+	actorRole  model.Role
+	targetId   uuid.UUID
+	targetRole model.Role
+	// deactivates is set for suspensions and deletions
+	deactivates bool
+	// activeSuperAdmins is the current number of active super administrators
+	activeSuperAdmins int64
+}
+
+// newStatusChange returns the change of the account status of the user by the
+// actor, with the rank of the user and the active super administrators counted
+func newStatusChange(ctx context.Context, roleRepo repository.RoleRepository, actorId uuid.UUID, actorRole model.Role, userId uuid.UUID, deactivates bool) (statusChange, error) {
+	targetRole, err := highestRole(ctx, roleRepo, userId)
+	if err != nil {
+		return statusChange{}, err
+	}
+	activeSuperAdmins, err := roleRepo.CountActiveRoleMembers(ctx, model.RoleSuperAdmin)
+	if err != nil {
+		return statusChange{}, err
+	}
+
+	return statusChange{
+		actorId:           actorId,
+		actorRole:         actorRole,
+		targetId:          userId,
+		targetRole:        targetRole,
+		deactivates:       deactivates,
+		activeSuperAdmins: activeSuperAdmins,
+	}, nil
+}
+
+// checkStatusChange enforces the role hierarchy on account status changes:
+//   - actors cannot change the status of peers or superiors, but may change
+//     their own.
+//   - the last active super administrator cannot be suspended or deleted.
+//     Operators on the command line are exempt, so they can lock out a
+//     compromised account and recover it later.
+func checkStatusChange(c statusChange) error {
+	isSelf := c.actorId == c.targetId
+
+	// peers and superiors are off limits
+	if !isSelf && c.targetRole.Rank >= c.actorRole.Rank {
+		return ErrStatusTargetNotSubordinate
+	}
+
+	// keep at least one active super administrator around
+	if guardsLastSuperAdmin(c) && c.activeSuperAdmins <= 1 {
+		return ErrLastActiveSuperAdmin
+	}
+
+	return nil
+}
+
+// guardsLastSuperAdmin reports whether the change must keep the last active
+// super administrator. No role can be ranked as high as operators.
+func guardsLastSuperAdmin(c statusChange) bool {
+	return c.deactivates && c.targetRole.Name == model.RoleSuperAdmin && c.actorRole.Rank != operatorRole.Rank
+}
+
+// deactivateUser sets the account status of the user to suspended or deleted.
+// Super administrators are deactivated in one statement that keeps the last
+// active one, as the count checkStatusChange saw can be stale by then.
+func deactivateUser(ctx context.Context, userRepo repository.UserRepository, c statusChange, status string) error {
+	if !guardsLastSuperAdmin(c) {
+		if status == "deleted" {
+			return userRepo.DeleteUser(ctx, c.targetId)
+		}
+		_, err := userRepo.SuspendUser(ctx, c.targetId)
+		return err
+	}
+
+	updated, err := userRepo.SetAccountStatusUnlessLastSuperAdmin(ctx, c.targetId, status)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrLastActiveSuperAdmin
+	}
+
+	return nil
+}
@@ -0,0 +1,317 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	userRole       = model.Role{Name: model.RoleUser, Rank: 0}
+	supportRole    = model.Role{Name: "support", Rank: 25}
+	adminRole      = model.Role{Name: model.RoleAdmin, Rank: 50}
+	superAdminRole = model.Role{Name: model.RoleSuperAdmin, Rank: 100}
+)
+
+// roleCode returns the code of a refused role operation, or "" for nil
+func roleCode(t *testing.T, err error) string {
+	t.Helper()
+	if err == nil {
+		return ""
+	}
+
+	var domainErr *Error
+	if !errors.As(err, &domainErr) {
+		t.Fatalf("got error %v, want a *Error", err)
+	}
+	return domainErr.Code
+}
+
+func TestCheckRoleGrant(t *testing.T) {
+	actor, target := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name   string
+		change roleChange
+		code   string
+	}{
+		{
+			name:   "admin grants a role below their own",
+			change: roleChange{actorId: actor, actorRole: adminRole, targetId: target, targetRole: userRole, role: supportRole},
+		},
+		{
+			name:   "admin grants their own role",
+			change: roleChange{actorId: actor, actorRole: adminRole, targetId: target, targetRole: userRole, role: adminRole},
+			code:   "role_not_below_actor",
+		},
+		{
+			name:   "admin grants a role above their own",
+			change: roleChange{actorId: actor, actorRole: adminRole, targetId: target, targetRole: userRole, role: superAdminRole},
+			code:   "role_not_below_actor",
+		},
+		{
+			name:   "admin grants themselves their own role",
+			change: roleChange{actorId: actor, actorRole: adminRole, targetId: actor, targetRole: adminRole, role: adminRole},
+			code:   "role_not_below_actor",
+		},
+		{
+			name:   "superadmin makes another user superadmin",
+			change: roleChange{actorId: actor, actorRole: superAdminRole, targetId: target, targetRole: userRole, role: superAdminRole},
+		},
+		{
+			name:   "admin changes the roles of a peer",
+			change: roleChange{actorId: actor, actorRole: adminRole, targetId: target, targetRole: adminRole, role: supportRole},
+			code:   "target_not_subordinate",
+		},
+		{
+			name:   "admin changes the roles of a superior",
+			change: roleChange{actorId: actor, actorRole: adminRole, targetId: target, targetRole: superAdminRole, role: supportRole},
+			code:   "target_not_subordinate",
+		},
+		{
+			name:   "superadmin changes the roles of a peer",
+			change: roleChange{actorId: actor, actorRole: superAdminRole, targetId: target, targetRole: superAdminRole, role: adminRole},
+			code:   "target_not_subordinate",
+		},
+		{
+			name:   "operator grants superadmin",
+			change: roleChange{actorRole: operatorRole, targetId: target, targetRole: superAdminRole, role: superAdminRole},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := roleCode(t, checkRoleGrant(test.change)); code != test.code {
+				t.Errorf("got code %q, want %q", code, test.code)
+			}
+		})
+	}
+}
+
+func TestCheckRoleRevoke(t *testing.T) {
+	actor, target := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name   string
+		change roleChange
+		code   string
+	}{
+		{
+			name:   "admin revokes a role of a subordinate",
+			change: roleChange{actorId: actor, actorRole: adminRole, targetId: target, targetRole: supportRole, role: supportRole},
+		},
+		{
+			name:   "admin gives up their own role",
+			change: roleChange{actorId: actor, actorRole: adminRole, targetId: actor, targetRole: adminRole, role: adminRole},
+		},
+		{
+			name:   "admin revokes a role of a peer",
+			change: roleChange{actorId: actor, actorRole: adminRole, targetId: target, targetRole: adminRole, role: adminRole},
+			code:   "target_not_subordinate",
+		},
+		{
+			name:   "admin revokes a role of a superior",
+			change: roleChange{actorId: actor, actorRole: adminRole, targetId: target, targetRole: superAdminRole, role: superAdminRole},
+			code:   "target_not_subordinate",
+		},
+		{
+			name:   "superadmin demotes another superadmin",
+			change: roleChange{actorId: actor, actorRole: superAdminRole, targetId: target, targetRole: superAdminRole, role: superAdminRole, superAdmins: 2},
+			code:   "target_not_subordinate",
+		},
+		{
+			name:   "superadmin gives up the role with another superadmin left",
+			change: roleChange{actorId: actor, actorRole: superAdminRole, targetId: actor, targetRole: superAdminRole, role: superAdminRole, superAdmins: 2},
+		},
+		{
+			name:   "last superadmin gives up the role",
+			change: roleChange{actorId: actor, actorRole: superAdminRole, targetId: actor, targetRole: superAdminRole, role: superAdminRole, superAdmins: 1},
+			code:   "last_superadmin",
+		},
+		{
+			name:   "operator demotes the last superadmin",
+			change: roleChange{actorRole: operatorRole, targetId: target, targetRole: superAdminRole, role: superAdminRole, superAdmins: 1},
+			code:   "last_superadmin",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := roleCode(t, checkRoleRevoke(test.change)); code != test.code {
+				t.Errorf("got code %q, want %q", code, test.code)
+			}
+		})
+	}
+}
+
+// revokingRoleRepository records the revocations made through it. The
+// guarded revocation only succeeds when unlessLastRevokes is set.
+type revokingRoleRepository struct {
+	repository.RoleRepository
+	unlessLastRevokes bool
+	revoked           []string
+}
+
+func (r *revokingRoleRepository) RevokeUserRole(ctx context.Context, userId uuid.UUID, role string) error {
+	r.revoked = append(r.revoked, role)
+	return nil
+}
+
+func (r *revokingRoleRepository) RevokeUserRoleUnlessLast(ctx context.Context, userId uuid.UUID, role string) (bool, error) {
+	if r.unlessLastRevokes {
+		r.revoked = append(r.revoked, role)
+	}
+	return r.unlessLastRevokes, nil
+}
+
+func TestRevokeUserRole(t *testing.T) {
+	tests := []struct {
+		name              string
+		role              string
+		unlessLastRevokes bool
+		code              string
+		revoked           int
+	}{
+		{name: "other roles are revoked unguarded", role: model.RoleAdmin, revoked: 1},
+		{name: "superadmin with another member left", role: model.RoleSuperAdmin, unlessLastRevokes: true, revoked: 1},
+		{name: "last superadmin, demoted concurrently", role: model.RoleSuperAdmin, code: "last_superadmin"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &revokingRoleRepository{unlessLastRevokes: test.unlessLastRevokes}
+			err := revokeUserRole(context.Background(), repo, uuid.New(), test.role)
+			if code := roleCode(t, err); code != test.code {
+				t.Errorf("got code %q, want %q", code, test.code)
+			}
+			if len(repo.revoked) != test.revoked {
+				t.Errorf("got %d revocations, want %d", len(repo.revoked), test.revoked)
+			}
+		})
+	}
+}
+
+func TestCheckStatusChange(t *testing.T) {
+	actor, target := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name   string
+		change statusChange
+		code   string
+	}{
+		{
+			name:   "admin suspends a subordinate",
+			change: statusChange{actorId: actor, actorRole: adminRole, targetId: target, targetRole: supportRole, deactivates: true},
+		},
+		{
+			name:   "admin suspends a peer",
+			change: statusChange{actorId: actor, actorRole: adminRole, targetId: target, targetRole: adminRole, deactivates: true},
+			code:   "target_not_subordinate",
+		},
+		{
+			name:   "admin deletes a superadmin",
+			change: statusChange{actorId: actor, actorRole: adminRole, targetId: target, targetRole: superAdminRole, deactivates: true, activeSuperAdmins: 2},
+			code:   "target_not_subordinate",
+		},
+		{
+			name:   "admin recovers a superior",
+			change: statusChange{actorId: actor, actorRole: adminRole, targetId: target, targetRole: superAdminRole, activeSuperAdmins: 1},
+			code:   "target_not_subordinate",
+		},
+		{
+			name:   "admin suspends themselves",
+			change: statusChange{actorId: actor, actorRole: adminRole, targetId: actor, targetRole: adminRole, deactivates: true},
+		},
+		{
+			name:   "superadmin suspends another superadmin",
+			change: statusChange{actorId: actor, actorRole: superAdminRole, targetId: target, targetRole: superAdminRole, deactivates: true, activeSuperAdmins: 2},
+			code:   "target_not_subordinate",
+		},
+		{
+			name:   "superadmin deletes themselves with another superadmin left",
+			change: statusChange{actorId: actor, actorRole: superAdminRole, targetId: actor, targetRole: superAdminRole, deactivates: true, activeSuperAdmins: 2},
+		},
+		{
+			name:   "last active superadmin suspends themselves",
+			change: statusChange{actorId: actor, actorRole: superAdminRole, targetId: actor, targetRole: superAdminRole, deactivates: true, activeSuperAdmins: 1},
+			code:   "last_superadmin",
+		},
+		{
+			name:   "operator suspends the last active superadmin",
+			change: statusChange{actorRole: operatorRole, targetId: target, targetRole: superAdminRole, deactivates: true, activeSuperAdmins: 1},
+		},
+		{
+			name:   "operator recovers a superadmin",
+			change: statusChange{actorRole: operatorRole, targetId: target, targetRole: superAdminRole},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := roleCode(t, checkStatusChange(test.change)); code != test.code {
+				t.Errorf("got code %q, want %q", code, test.code)
+			}
+		})
+	}
+}
+
+// deactivatingUserRepository records the account statuses set through it. The
+// guarded update only succeeds when unlessLastUpdates is set.
+type deactivatingUserRepository struct {
+	repository.UserRepository
+	unlessLastUpdates bool
+	statuses          []string
+}
+
+func (r *deactivatingUserRepository) SuspendUser(ctx context.Context, userId uuid.UUID) (model.User, error) {
+	r.statuses = append(r.statuses, "suspended")
+	return model.User{ID: userId, AccountStatus: "suspended"}, nil
+}
+
+func (r *deactivatingUserRepository) DeleteUser(ctx context.Context, userId uuid.UUID) error {
+	r.statuses = append(r.statuses, "deleted")
+	return nil
+}
+
+func (r *deactivatingUserRepository) SetAccountStatusUnlessLastSuperAdmin(ctx context.Context, userId uuid.UUID, status string) (bool, error) {
+	if r.unlessLastUpdates {
+		r.statuses = append(r.statuses, status)
+	}
+	return r.unlessLastUpdates, nil
+}
+
+func TestDeactivateUser(t *testing.T) {
+	tests := []struct {
+		name              string
+		actorRole         model.Role
+		targetRole        model.Role
+		status            string
+		unlessLastUpdates bool
+		code              string
+		updated           int
+	}{
+		{name: "other users are suspended unguarded", actorRole: superAdminRole, targetRole: adminRole, status: "suspended", updated: 1},
+		{name: "other users are deleted unguarded", actorRole: superAdminRole, targetRole: adminRole, status: "deleted", updated: 1},
+		{name: "superadmin with another one left", actorRole: superAdminRole, targetRole: superAdminRole, status: "deleted", unlessLastUpdates: true, updated: 1},
+		{name: "last active superadmin, suspended concurrently", actorRole: superAdminRole, targetRole: superAdminRole, status: "suspended", code: "last_superadmin"},
+		{name: "operators are not guarded", actorRole: operatorRole, targetRole: superAdminRole, status: "suspended", updated: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &deactivatingUserRepository{unlessLastUpdates: test.unlessLastUpdates}
+			change := statusChange{actorRole: test.actorRole, targetId: uuid.New(), targetRole: test.targetRole, deactivates: true}
+			err := deactivateUser(context.Background(), repo, change, test.status)
+			if code := roleCode(t, err); code != test.code {
+				t.Errorf("got code %q, want %q", code, test.code)
+			}
+			if len(repo.statuses) != test.updated {
+				t.Errorf("got %d status changes, want %d", len(repo.statuses), test.updated)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/google/uuid"
+)
+
+var roleNamePattern = regexp.MustCompile("^[a-z0-9_-]{1,50}$")
+
+type RoleService struct {
+	roleRepo repository.RoleRepository
+	userRepo repository.UserRepository
+}
+
+func NewRoleService(roleRepo repository.RoleRepository, userRepo repository.UserRepository) *RoleService {
+	return &RoleService{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+	}
+}
+
+// HasPermission reports whether any of the user's roles grants the given permission
+func (s *RoleService) HasPermission(ctx context.Context, userId uuid.UUID, permission string) (bool, error) {
+	if userId == uuid.Nil || permission == "" {
+		return false, nil
+	}
+
+	return s.roleRepo.UserHasPermission(ctx, userId, permission)
+}
+
+// ListRoles returns every role with its permissions and number of members
+func (s *RoleService) ListRoles(ctx context.Context) ([]model.Role, error) {
+	roles, err := s.roleRepo.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range roles {
+		roles[i].Permissions, err = s.roleRepo.GetRolePermissions(ctx, roles[i].Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return roles, nil
+}
+
+// ListPermissions returns every permission that can be attached to a role
+func (s *RoleService) ListPermissions(ctx context.Context) ([]model.Permission, error) {
+	return s.roleRepo.ListPermissions(ctx)
+}
+
+// CreateRole creates a custom role ranked below the actor with permissions the actor holds
+func (s *RoleService) CreateRole(ctx context.Context, name string, description string, rank int32, permissions []string) (model.Role, error) {
+	if !roleNamePattern.MatchString(name) {
+		return model.Role{}, ErrInvalidRoleName
+	}
+	if name == model.RoleServiceAccount {
+		return model.Role{}, ErrReservedRoleName
+	}
+
+	// check hierarchy
+	actorId, actorRole, err := s.getActor(ctx)
+	if err != nil {
+		return model.Role{}, err
+	}
+	if rank >= actorRole.Rank {
+		return model.Role{}, ErrRoleNotBelowActor
+	}
+	if err := s.checkPermissionsHeld(ctx, actorId, permissions); err != nil {
+		return model.Role{}, err
+	}
+
+	// check name is free
+	if _, err := s.getRole(ctx, name); err == nil {
+		return model.Role{}, ErrRoleExists
+	} else if err != ErrRoleNotFound {
+		return model.Role{}, err
+	}
+
+	// create role
+	role, err := s.roleRepo.CreateRole(ctx, model.Role{
+		Name:        name,
+		Description: description,
+		Rank:        rank,
+	})
+	if err != nil {
+		return model.Role{}, err
+	}
+
+	if len(permissions) > 0 {
+		if err := s.roleRepo.SetRolePermissions(ctx, role.Name, permissions); err != nil {
+			return model.Role{}, err
+		}
+	}
+	role.Permissions = permissions
+
+	return role, nil
+}
+
+// RenameRole renames a custom role ranked below the actor
+func (s *RoleService) RenameRole(ctx context.Context, name string, newName string, description string) (model.Role, error) {
+	if !roleNamePattern.MatchString(newName) {
+		return model.Role{}, ErrInvalidRoleName
+	}
+	if newName == model.RoleServiceAccount {
+		return model.Role{}, ErrReservedRoleName
+	}
+
+	role, err := s.getManageableRole(ctx, name)
+	if err != nil {
+		return model.Role{}, err
+	}
+
+	// check new name is free
+	if newName != name {
+		if _, err := s.getRole(ctx, newName); err == nil {
+			return model.Role{}, ErrRoleExists
+		} else if err != ErrRoleNotFound {
+			return model.Role{}, err
+		}
+	}
+
+	if description == "" {
+		description = role.Description
+	}
+
+	return s.roleRepo.RenameRole(ctx, name, newName, description)
+}
+
+// SetRolePermissions replaces the permissions of a custom role ranked below the actor
+func (s *RoleService) SetRolePermissions(ctx context.Context, name string, permissions []string) (model.Role, error) {
+	role, err := s.getManageableRole(ctx, name)
+	if err != nil {
+		return model.Role{}, err
+	}
+
+	actorId := ctx.Value("userId").(uuid.UUID)
+	if err := s.checkPermissionsHeld(ctx, actorId, permissions); err != nil {
+		return model.Role{}, err
+	}
+
+	if err := s.roleRepo.SetRolePermissions(ctx, name, permissions); err != nil {
+		return model.Role{}, err
+	}
+	role.Permissions = permissions
+
+	return role, nil
+}
+
+// DeleteRole deletes a custom role ranked below the actor that has no members left
+func (s *RoleService) DeleteRole(ctx context.Context, name string) error {
+	if _, err := s.getManageableRole(ctx, name); err != nil {
+		return err
+	}
+
+	members, err := s.roleRepo.CountRoleMembers(ctx, name)
+	if err != nil {
+		return err
+	}
+	if members > 0 {
+		return ErrRoleInUse
+	}
+
+	return s.roleRepo.DeleteRole(ctx, name)
+}
+
+// GetUserRoles returns the roles held by the user, highest ranked first
+func (s *RoleService) GetUserRoles(ctx context.Context, userId uuid.UUID) ([]model.Role, error) {
+	return s.roleRepo.GetUserRoles(ctx, userId)
+}
+
+// AssignRole grants a role to the user on behalf of the user in the context
+func (s *RoleService) AssignRole(ctx context.Context, userId uuid.UUID, roleName string) (model.User, error) {
+	change, heldRoles, err := s.getRoleChange(ctx, userId, roleName)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if hasRole(heldRoles, roleName) {
+		return model.User{}, ErrRoleAlreadyAssigned
+	}
+
+	if err := checkRoleGrant(change); err != nil {
+		return model.User{}, err
+	}
+
+	if err := s.roleRepo.AssignUserRole(ctx, userId, roleName); err != nil {
+		return model.User{}, err
+	}
+
+	return s.roleRepo.SyncUserPrimaryRole(ctx, userId)
+}
+
+// RevokeRole takes a role away from the user on behalf of the user in the context
+func (s *RoleService) RevokeRole(ctx context.Context, userId uuid.UUID, roleName string) (model.User, error) {
+	change, heldRoles, err := s.getRoleChange(ctx, userId, roleName)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if !hasRole(heldRoles, roleName) {
+		return model.User{}, ErrRoleNotAssigned
+	}
+
+	if err := checkRoleRevoke(change); err != nil {
+		return model.User{}, err
+	}
+
+	if err := revokeUserRole(ctx, s.roleRepo, userId, roleName); err != nil {
+		return model.User{}, err
+	}
+
+	return s.roleRepo.SyncUserPrimaryRole(ctx, userId)
+}
+
+// getRoleChange loads everything needed to check a role change on the target user
+func (s *RoleService) getRoleChange(ctx context.Context, targetId uuid.UUID, roleName string) (roleChange, []model.Role, error) {
+	role, err := s.getRole(ctx, roleName)
+	if err != nil {
+		return roleChange{}, nil, err
+	}
+
+	actorId, actorRole, err := s.getActor(ctx)
+	if err != nil {
+		return roleChange{}, nil, err
+	}
+
+	// make sure the target exists
+	if _, err := s.userRepo.GetUserById(ctx, targetId); err != nil {
+		return roleChange{}, nil, err
+	}
+
+	heldRoles, err := s.roleRepo.GetUserRoles(ctx, targetId)
+	if err != nil {
+		return roleChange{}, nil, err
+	}
+
+	// the highest ranked role decides where the target sits in the hierarchy
+	targetRole := model.Role{Name: model.RoleUser}
+	if len(heldRoles) > 0 {
+		targetRole = heldRoles[0]
+	}
+
+	superAdmins, err := s.roleRepo.CountRoleMembers(ctx, model.RoleSuperAdmin)
+	if err != nil {
+		return roleChange{}, nil, err
+	}
+
+	return roleChange{
+		actorId:     actorId,
+		actorRole:   actorRole,
+		targetId:    targetId,
+		targetRole:  targetRole,
+		role:        role,
+		superAdmins: superAdmins,
+	}, heldRoles, nil
+}
+
+// getActor returns the id and highest ranked role of the user in the context
+func (s *RoleService) getActor(ctx context.Context) (uuid.UUID, model.Role, error) {
+	actorId := ctx.Value("userId").(uuid.UUID)
+
+	roles, err := s.roleRepo.GetUserRoles(ctx, actorId)
+	if err != nil {
+		return uuid.Nil, model.Role{}, err
+	}
+	if len(roles) == 0 {
+		return actorId, model.Role{Name: model.RoleUser}, nil
+	}
+
+	return actorId, roles[0], nil
+}
+
+// getRole returns the named role, or ErrRoleNotFound
+func (s *RoleService) getRole(ctx context.Context, name string) (model.Role, error) {
+	role, err := s.roleRepo.GetRoleByName(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Role{}, ErrRoleNotFound
+	}
+
+	return role, err
+}
+
+// getManageableRole returns the named role if it is a custom role ranked below the actor
+func (s *RoleService) getManageableRole(ctx context.Context, name string) (model.Role, error) {
+	role, err := s.getRole(ctx, name)
+	if err != nil {
+		return model.Role{}, err
+	}
+
+	if role.BuiltIn {
+		return model.Role{}, ErrBuiltInRole
+	}
+
+	_, actorRole, err := s.getActor(ctx)
+	if err != nil {
+		return model.Role{}, err
+	}
+	if role.Rank >= actorRole.Rank {
+		return model.Role{}, ErrRoleNotBelowActor
+	}
+
+	return role, nil
+}
+
+// checkPermissionsHeld makes sure the actor holds every permission they attach to a role
+func (s *RoleService) checkPermissionsHeld(ctx context.Context, actorId uuid.UUID, permissions []string) error {
+	for _, permission := range permissions {
+		held, err := s.roleRepo.UserHasPermission(ctx, actorId, permission)
+		if err != nil {
+			return err
+		}
+		if !held {
+			return ErrPermissionNotHeld
+		}
+	}
+
+	return nil
+}
+
+// hasRole reports whether the named role is among roles
+func hasRole(roles []model.Role, name string) bool {
+	for _, role := range roles {
+		if role.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
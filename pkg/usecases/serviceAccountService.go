@@ -0,0 +1,429 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrServiceAccountNotFound is returned for service accounts that do not exist or are outside the caller's organization
+	ErrServiceAccountNotFound = &Error{Kind: ErrNotFound, Code: "service_account_not_found", Message: "Service account not found"}
+	// ErrInvalidServiceAccountName is returned for names that are empty or longer than 50 characters
+	ErrInvalidServiceAccountName = &Error{Kind: ErrValidation, Code: "invalid_service_account_name", Message: "Service account names must be 1-50 characters"}
+	// ErrInvalidServiceAccountKey is returned for public keys that are not PEM encoded RSA or ECDSA keys
+	ErrInvalidServiceAccountKey = &Error{Kind: ErrValidation, Code: "invalid_service_account_key", Message: "Public key must be a PEM encoded RSA or ECDSA public key"}
+	// ErrServiceAccountPermissionNotHeld is returned when granting a service account a permission the caller does not hold
+	ErrServiceAccountPermissionNotHeld = &Error{Kind: ErrForbidden, Code: "service_account_permission_not_held", Message: "You can only grant a service account permissions you hold"}
+	// ErrServiceAccountPermissionExcluded is returned when granting a service account a permission they cannot hold
+	ErrServiceAccountPermissionExcluded = &Error{Kind: ErrValidation, Code: "service_account_permission_excluded", Message: "Service accounts cannot hold permissions to manage roles or service accounts, or to impersonate users"}
+	// ErrOrganizationNotFound is returned when creating a service account for an organization that does not exist
+	ErrOrganizationNotFound = &Error{Kind: ErrNotFound, Code: "organization_not_found", Message: "Organization not found"}
+)
+
+// serviceAccountExcludedPermissions are the permissions service accounts cannot
+// hold. Service accounts rank with their creator to manage users, but must not
+// change roles, impersonate users or mint other service accounts.
+var serviceAccountExcludedPermissions = []string{
+	model.PermissionRolesAssign,
+	model.PermissionRolesManage,
+	model.PermissionServiceAccountsManage,
+	model.PermissionUsersImpersonate,
+}
+
+type ServiceAccountService struct {
+	serviceAccountRepo repository.ServiceAccountRepository
+	roleRepo           repository.RoleRepository
+	orgRepo            repository.OrganizationRepository
+	userService        *UserService
+
+	// issuer is the base URL of the server, the audience of service account assertions
+	issuer string
+}
+
+func NewServiceAccountService(
+	serviceAccountRepo repository.ServiceAccountRepository,
+	roleRepo repository.RoleRepository,
+	orgRepo repository.OrganizationRepository,
+	userService *UserService,
+	issuer string,
+) *ServiceAccountService {
+	return &ServiceAccountService{
+		serviceAccountRepo: serviceAccountRepo,
+		roleRepo:           roleRepo,
+		orgRepo:            orgRepo,
+		userService:        userService,
+		issuer:             strings.TrimSuffix(issuer, "/"),
+	}
+}
+
+// Administration
+
+// CreateServiceAccount creates a service account. Without a public key it gets
+// a client secret that is only returned here. Administrators confined to an
+// organization can only create service accounts of that organization; others
+// create platform service accounts unless they name an organization.
+func (s *ServiceAccountService) CreateServiceAccount(
+	ctx context.Context,
+	name string,
+	description string,
+	orgId *uuid.UUID,
+	permissions []string,
+	publicKey string,
+) (model.ServiceAccountCredentials, error) {
+	actorId := ctx.Value("userId").(uuid.UUID)
+
+	// resolve organization
+	scope, err := s.userService.tenantScope(ctx)
+	if err != nil {
+		return model.ServiceAccountCredentials{}, err
+	}
+	if scope.Valid {
+		if orgId != nil && *orgId != scope.UUID {
+			return model.ServiceAccountCredentials{}, ErrOutsideOrganization
+		}
+		orgId = &scope.UUID
+	} else if orgId != nil {
+		_, err := s.orgRepo.GetOrganizationById(ctx, *orgId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ServiceAccountCredentials{}, ErrOrganizationNotFound
+		}
+		if err != nil {
+			return model.ServiceAccountCredentials{}, err
+		}
+	}
+
+	// validate details
+	serviceAccount := model.ServiceAccount{
+		Name:        strings.TrimSpace(name),
+		Description: description,
+		OrgID:       orgId,
+		PublicKey:   strings.TrimSpace(publicKey),
+		CreatedBy:   &actorId,
+	}
+	serviceAccount.Permissions, err = s.validateServiceAccount(ctx, actorId, serviceAccount, permissions)
+	if err != nil {
+		return model.ServiceAccountCredentials{}, err
+	}
+
+	// generate credentials
+	serviceAccount.ClientID, err = utils.GenerateServiceAccountClientID()
+	if err != nil {
+		return model.ServiceAccountCredentials{}, err
+	}
+
+	var clientSecret string
+	if serviceAccount.PublicKey == "" {
+		clientSecret, serviceAccount.SecretHash, err = generateClientSecret()
+		if err != nil {
+			return model.ServiceAccountCredentials{}, err
+		}
+	}
+
+	// create service account
+	createdServiceAccount, err := s.serviceAccountRepo.CreateServiceAccount(ctx, serviceAccount)
+	if err != nil {
+		return model.ServiceAccountCredentials{}, err
+	}
+
+	return model.ServiceAccountCredentials{ServiceAccount: createdServiceAccount, ClientSecret: clientSecret}, nil
+}
+
+// ListServiceAccounts lists the service accounts the caller can manage
+func (s *ServiceAccountService) ListServiceAccounts(ctx context.Context) ([]model.ServiceAccount, error) {
+	scope, err := s.userService.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.serviceAccountRepo.ListServiceAccounts(ctx, scope)
+}
+
+// UpdateServiceAccount replaces the name, description, permissions and public key of a service account
+func (s *ServiceAccountService) UpdateServiceAccount(
+	ctx context.Context,
+	serviceAccountId uuid.UUID,
+	name string,
+	description string,
+	permissions []string,
+	publicKey string,
+) (model.ServiceAccount, error) {
+	actorId := ctx.Value("userId").(uuid.UUID)
+
+	serviceAccount, err := s.getManageableServiceAccount(ctx, serviceAccountId)
+	if err != nil {
+		return model.ServiceAccount{}, err
+	}
+
+	serviceAccount.Name = strings.TrimSpace(name)
+	serviceAccount.Description = description
+	serviceAccount.PublicKey = strings.TrimSpace(publicKey)
+	serviceAccount.Permissions, err = s.validateServiceAccount(ctx, actorId, serviceAccount, permissions)
+	if err != nil {
+		return model.ServiceAccount{}, err
+	}
+
+	return s.serviceAccountRepo.UpdateServiceAccount(ctx, serviceAccount)
+}
+
+// RotateServiceAccountSecret gives a service account a new client secret, which is only returned here
+func (s *ServiceAccountService) RotateServiceAccountSecret(ctx context.Context, serviceAccountId uuid.UUID) (model.ServiceAccountCredentials, error) {
+	serviceAccount, err := s.getManageableServiceAccount(ctx, serviceAccountId)
+	if err != nil {
+		return model.ServiceAccountCredentials{}, err
+	}
+
+	clientSecret, secretHash, err := generateClientSecret()
+	if err != nil {
+		return model.ServiceAccountCredentials{}, err
+	}
+
+	err = s.serviceAccountRepo.UpdateServiceAccountSecret(ctx, serviceAccount.ID, secretHash)
+	if err != nil {
+		return model.ServiceAccountCredentials{}, err
+	}
+	serviceAccount.HasSecret = true
+
+	return model.ServiceAccountCredentials{ServiceAccount: serviceAccount, ClientSecret: clientSecret}, nil
+}
+
+// DeleteServiceAccount deletes a service account. Its access tokens stop
+// passing permission checks straight away.
+func (s *ServiceAccountService) DeleteServiceAccount(ctx context.Context, serviceAccountId uuid.UUID) error {
+	serviceAccount, err := s.getManageableServiceAccount(ctx, serviceAccountId)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.serviceAccountRepo.DeleteServiceAccount(ctx, serviceAccount.ID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrServiceAccountNotFound
+	}
+
+	return nil
+}
+
+// getManageableServiceAccount returns the service account if it is within the caller's tenant
+func (s *ServiceAccountService) getManageableServiceAccount(ctx context.Context, serviceAccountId uuid.UUID) (model.ServiceAccount, error) {
+	serviceAccount, err := s.serviceAccountRepo.GetServiceAccountById(ctx, serviceAccountId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ServiceAccount{}, ErrServiceAccountNotFound
+	}
+	if err != nil {
+		return model.ServiceAccount{}, err
+	}
+
+	scope, err := s.userService.tenantScope(ctx)
+	if err != nil {
+		return model.ServiceAccount{}, err
+	}
+	if scope.Valid && (serviceAccount.OrgID == nil || *serviceAccount.OrgID != scope.UUID) {
+		return model.ServiceAccount{}, ErrServiceAccountNotFound
+	}
+
+	return serviceAccount, nil
+}
+
+// validateServiceAccount checks the name and public key of a service account
+// and returns the permissions it may be granted by the actor, without duplicates
+func (s *ServiceAccountService) validateServiceAccount(ctx context.Context, actorId uuid.UUID, serviceAccount model.ServiceAccount, permissions []string) ([]string, error) {
+	if serviceAccount.Name == "" || len(serviceAccount.Name) > 50 {
+		return nil, ErrInvalidServiceAccountName
+	}
+
+	if serviceAccount.PublicKey != "" {
+		if _, err := utils.ParseServiceAccountPublicKey(serviceAccount.PublicKey); err != nil {
+			return nil, ErrInvalidServiceAccountKey
+		}
+	}
+
+	granted := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		if slices.Contains(granted, permission) {
+			continue
+		}
+		if slices.Contains(serviceAccountExcludedPermissions, permission) {
+			return nil, ErrServiceAccountPermissionExcluded
+		}
+
+		held, err := s.roleRepo.UserHasPermission(ctx, actorId, permission)
+		if err != nil {
+			return nil, err
+		}
+		if !held {
+			return nil, ErrServiceAccountPermissionNotHeld
+		}
+		granted = append(granted, permission)
+	}
+
+	return granted, nil
+}
+
+// generateClientSecret returns a new client secret and its bcrypt hash
+func generateClientSecret() (string, string, error) {
+	clientSecret, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	secretHash, err := bcrypt.GenerateFromPassword([]byte(clientSecret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+
+	return clientSecret, string(secretHash), nil
+}
+
+// Authorization
+
+// HasPermission reports whether the service account holds the permission.
+// Deleted service accounts hold none.
+func (s *ServiceAccountService) HasPermission(ctx context.Context, serviceAccountId uuid.UUID, permission string) (bool, error) {
+	serviceAccount, err := s.serviceAccountRepo.GetServiceAccountById(ctx, serviceAccountId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(serviceAccount.Permissions, permission), nil
+}
+
+// Token endpoint
+
+// ClientCredentials issues an access token to a service account authenticating
+// with its client secret or, when clientAssertion is given, with an assertion
+// signed by its private key (RFC 7523 section 2.2)
+func (s *ServiceAccountService) ClientCredentials(
+	ctx context.Context,
+	clientId string,
+	clientSecret string,
+	clientAssertionType string,
+	clientAssertion string,
+) (model.TokenResponse, error) {
+	var serviceAccount model.ServiceAccount
+	var err error
+	if clientAssertion != "" {
+		if clientAssertionType != model.ClientAssertionTypeJWTBearer {
+			return model.TokenResponse{}, oauthError("invalid_request", "Unsupported client assertion type")
+		}
+		serviceAccount, err = s.authenticateAssertion(ctx, clientAssertion)
+		if err == nil && clientId != "" && clientId != serviceAccount.ClientID {
+			err = oauthError("invalid_client", "Client authentication failed")
+		}
+	} else {
+		serviceAccount, err = s.authenticateSecret(ctx, clientId, clientSecret)
+	}
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+
+	return s.issueToken(ctx, serviceAccount)
+}
+
+// JWTBearer issues an access token to the service account that signed the
+// assertion with its private key (RFC 7523 section 2.1)
+func (s *ServiceAccountService) JWTBearer(ctx context.Context, assertion string) (model.TokenResponse, error) {
+	serviceAccount, err := s.authenticateAssertion(ctx, assertion)
+	if err != nil {
+		var oauthErr *OAuthError
+		if errors.As(err, &oauthErr) && oauthErr.Code == "invalid_client" {
+			return model.TokenResponse{}, oauthError("invalid_grant", "The assertion is invalid")
+		}
+		return model.TokenResponse{}, err
+	}
+
+	return s.issueToken(ctx, serviceAccount)
+}
+
+// authenticateSecret checks the client secret of a service account
+func (s *ServiceAccountService) authenticateSecret(ctx context.Context, clientId string, clientSecret string) (model.ServiceAccount, error) {
+	serviceAccount, err := s.serviceAccountRepo.GetServiceAccountByClientId(ctx, clientId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ServiceAccount{}, oauthError("invalid_client", "Client authentication failed")
+	}
+	if err != nil {
+		return model.ServiceAccount{}, err
+	}
+
+	if serviceAccount.SecretHash == "" {
+		return model.ServiceAccount{}, oauthError("invalid_client", "Client authentication failed")
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(serviceAccount.SecretHash), []byte(clientSecret))
+	if err != nil {
+		return model.ServiceAccount{}, oauthError("invalid_client", "Client authentication failed")
+	}
+
+	return serviceAccount, nil
+}
+
+// authenticateAssertion checks an assertion against the public key of the service account that issued it
+func (s *ServiceAccountService) authenticateAssertion(ctx context.Context, assertion string) (model.ServiceAccount, error) {
+	clientId, err := utils.ServiceAccountAssertionIssuer(assertion)
+	if err != nil || !utils.IsServiceAccountClientID(clientId) {
+		return model.ServiceAccount{}, oauthError("invalid_client", "Client authentication failed")
+	}
+
+	serviceAccount, err := s.serviceAccountRepo.GetServiceAccountByClientId(ctx, clientId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ServiceAccount{}, oauthError("invalid_client", "Client authentication failed")
+	}
+	if err != nil {
+		return model.ServiceAccount{}, err
+	}
+
+	if serviceAccount.PublicKey == "" {
+		return model.ServiceAccount{}, oauthError("invalid_client", "Client authentication failed")
+	}
+	audiences := []string{s.issuer, s.issuer + "/oauth/token"}
+	err = utils.VerifyServiceAccountAssertion(assertion, serviceAccount.ClientID, serviceAccount.PublicKey, audiences)
+	if err != nil {
+		logging.FromContext(ctx).Info("Rejected assertion of service account", "client_id", serviceAccount.ClientID, "error", err)
+		return model.ServiceAccount{}, oauthError("invalid_client", "Client authentication failed")
+	}
+
+	return serviceAccount, nil
+}
+
+// issueToken issues an access token acting as the service account. The granted
+// scope is the service account's permissions; there is no refresh token.
+func (s *ServiceAccountService) issueToken(ctx context.Context, serviceAccount model.ServiceAccount) (model.TokenResponse, error) {
+	scope := strings.Join(serviceAccount.Permissions, " ")
+
+	accessToken, _, err := utils.GenerateAccessToken(utils.UserClaims{
+		UserID:        serviceAccount.ID,
+		Username:      serviceAccount.Name,
+		Role:          model.RoleServiceAccount,
+		OrgID:         serviceAccount.OrgID,
+		ClientID:      serviceAccount.ClientID,
+		Scope:         scope,
+		PrincipalType: model.PrincipalServiceAccount,
+	})
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+
+	if err := s.serviceAccountRepo.TouchServiceAccount(ctx, serviceAccount.ID); err != nil {
+		logging.FromContext(ctx).Error("Failed to record use of service account", "service_account_id", serviceAccount.ID, "error", err)
+	}
+
+	return model.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(utils.AccessTokenLifetime.Seconds()),
+		Scope:       scope,
+	}, nil
+}
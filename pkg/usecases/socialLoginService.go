@@ -0,0 +1,285 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/metrics"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrUnknownProvider is returned for identity providers that are not configured
+	ErrUnknownProvider = &Error{Kind: ErrNotFound, Code: "unknown_provider", Message: "Unknown identity provider"}
+	// ErrInvalidSocialLoginState is returned when the callback does not belong to a sign in started by the browser
+	ErrInvalidSocialLoginState = &Error{Kind: ErrValidation, Code: "invalid_social_login_state", Message: "The sign in has expired or was started elsewhere, please start again"}
+	// ErrEmailNotVerified is returned when signing up or matching a user with an address the provider did not verify
+	ErrEmailNotVerified = &Error{Kind: ErrForbidden, Code: "email_not_verified", Message: "The identity provider did not verify the email address; sign in and link the identity from your profile instead"}
+	// ErrIdentityLinked is returned when linking an identity that is linked to another user
+	ErrIdentityLinked = &Error{Kind: ErrConflict, Code: "identity_linked", Message: "The identity is linked to another user"}
+	// ErrProviderLinked is returned when linking a second identity at the same provider
+	ErrProviderLinked = &Error{Kind: ErrConflict, Code: "provider_linked", Message: "An identity at this provider is already linked"}
+	// ErrIdentityProviderFailed is returned when the identity provider cannot be reached or does not complete the sign in
+	ErrIdentityProviderFailed = &Error{Kind: ErrUpstream, Code: "identity_provider_failed", Message: "The identity provider could not complete the sign in"}
+	// ErrIdentityNotFound is returned when unlinking an identity that is not linked
+	ErrIdentityNotFound = &Error{Kind: ErrNotFound, Code: "identity_not_found", Message: "No identity at this provider is linked"}
+)
+
+type SocialLoginService struct {
+	identityRepo repository.IdentityRepository
+	userRepo     repository.UserRepository
+	userService  *UserService
+	providers    map[string]*utils.SocialProvider
+}
+
+func NewSocialLoginService(
+	identityRepo repository.IdentityRepository,
+	userRepo repository.UserRepository,
+	userService *UserService,
+	providers []*utils.SocialProvider,
+) *SocialLoginService {
+	providersByName := make(map[string]*utils.SocialProvider, len(providers))
+	for _, provider := range providers {
+		providersByName[provider.Name] = provider
+	}
+
+	return &SocialLoginService{
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		userService:  userService,
+		providers:    providersByName,
+	}
+}
+
+// Providers lists the names of the configured identity providers
+func (s *SocialLoginService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (s *SocialLoginService) getProvider(name string) (*utils.SocialProvider, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// StartLogin starts a sign in at the provider and returns the URL to send the
+// user to with the ticket to keep in their browser. A link ticket from
+// CreateLinkTicket links the identity to its user instead of signing in.
+func (s *SocialLoginService) StartLogin(ctx context.Context, providerName string, linkTicket string) (string, string, error) {
+	provider, err := s.getProvider(providerName)
+	if err != nil {
+		return "", "", err
+	}
+
+	claims := utils.SocialLoginTicketClaims{
+		Provider:     provider.Name,
+		CodeVerifier: oauth2.GenerateVerifier(),
+	}
+
+	if linkTicket != "" {
+		linkClaims, err := utils.VerifySocialLinkTicket(linkTicket)
+		if err != nil || linkClaims.Provider != provider.Name {
+			return "", "", ErrInvalidSocialLoginState
+		}
+		claims.LinkUserID = &linkClaims.UserID
+	}
+
+	// generate state and nonce
+	claims.State, err = utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	claims.Nonce, err = utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, claims.State, claims.CodeVerifier, claims.Nonce)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %w", ErrIdentityProviderFailed, err)
+	}
+
+	ticket, err := utils.GenerateSocialLoginTicket(claims)
+	if err != nil {
+		return "", "", err
+	}
+
+	return authURL, ticket, nil
+}
+
+// CompleteLogin checks the callback belongs to the ticket's sign in and returns
+// the identity of the user who signed in at the provider
+func (s *SocialLoginService) CompleteLogin(
+	ctx context.Context,
+	providerName string,
+	ticket string,
+	state string,
+	code string,
+) (model.ExternalIdentity, *utils.SocialLoginTicketClaims, error) {
+	provider, err := s.getProvider(providerName)
+	if err != nil {
+		return model.ExternalIdentity{}, nil, err
+	}
+
+	claims, err := utils.VerifySocialLoginTicket(ticket)
+	if err != nil || claims.Provider != provider.Name || claims.State == "" || claims.State != state {
+		return model.ExternalIdentity{}, nil, ErrInvalidSocialLoginState
+	}
+
+	identity, err := provider.Exchange(ctx, code, claims.CodeVerifier, claims.Nonce)
+	if err != nil {
+		return model.ExternalIdentity{}, nil, fmt.Errorf("%w: %w", ErrIdentityProviderFailed, err)
+	}
+
+	return identity, claims, nil
+}
+
+// SignIn signs in the user the identity is linked to. Unlinked identities are
+// linked to the user with their verified email address, who is created if
+// there is none. Accounts that are not active are refused with
+// ErrAccountNotActive, and no identity is linked to them.
+func (s *SocialLoginService) SignIn(ctx context.Context, identity model.ExternalIdentity) (loginResponse model.LoginResponse, err error) {
+	defer func() { metrics.RecordLogin(metrics.LoginMethodSocial, outcomeOf(err)) }()
+
+	// linked identity
+	linkedIdentity, err := s.identityRepo.GetIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetUserById(ctx, linkedIdentity.UserID)
+		if err != nil {
+			return model.LoginResponse{}, err
+		}
+		if user.AccountStatus != "active" {
+			return model.LoginResponse{}, ErrAccountNotActive
+		}
+
+		err = s.identityRepo.TouchIdentity(ctx, linkedIdentity.ID)
+		if err != nil {
+			return model.LoginResponse{}, err
+		}
+
+		return s.userService.startSession(ctx, user)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return model.LoginResponse{}, err
+	}
+
+	// match by verified email address
+	if !identity.EmailVerified || identity.Email == "" {
+		return model.LoginResponse{}, ErrEmailNotVerified
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, identity.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err = s.createUser(ctx, identity)
+	}
+	if err != nil {
+		return model.LoginResponse{}, err
+	}
+	if user.AccountStatus != "active" {
+		return model.LoginResponse{}, ErrAccountNotActive
+	}
+
+	// link identity
+	createdIdentity, err := s.identityRepo.CreateIdentity(ctx, user.ID, identity)
+	if err != nil {
+		return model.LoginResponse{}, err
+	}
+
+	err = s.identityRepo.TouchIdentity(ctx, createdIdentity.ID)
+	if err != nil {
+		return model.LoginResponse{}, err
+	}
+
+	return s.userService.startSession(ctx, user)
+}
+
+// createUser signs up the user of the identity. They get a random password
+// they can replace through a password reset.
+func (s *SocialLoginService) createUser(ctx context.Context, identity model.ExternalIdentity) (model.User, error) {
+	password, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	firstName := identity.FirstName
+	if firstName == "" && identity.LastName == "" {
+		firstName, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	return s.userService.RegisterUser(ctx, identity.Email, password, firstName, identity.LastName)
+}
+
+// CreateLinkTicket returns a ticket for the user in the context to link an identity at the provider
+func (s *SocialLoginService) CreateLinkTicket(ctx context.Context, providerName string) (string, error) {
+	provider, err := s.getProvider(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	userId := ctx.Value("userId").(uuid.UUID)
+	return utils.GenerateSocialLinkTicket(userId, provider.Name)
+}
+
+// LinkIdentity links the identity to the user. Email addresses need not match.
+func (s *SocialLoginService) LinkIdentity(ctx context.Context, userId uuid.UUID, identity model.ExternalIdentity) (model.UserIdentity, error) {
+	linkedIdentity, err := s.identityRepo.GetIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		if linkedIdentity.UserID != userId {
+			return model.UserIdentity{}, ErrIdentityLinked
+		}
+		return linkedIdentity, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return model.UserIdentity{}, err
+	}
+
+	// one identity per provider
+	identities, err := s.identityRepo.GetUserIdentities(ctx, userId)
+	if err != nil {
+		return model.UserIdentity{}, err
+	}
+	for _, existing := range identities {
+		if existing.Provider == identity.Provider {
+			return model.UserIdentity{}, ErrProviderLinked
+		}
+	}
+
+	return s.identityRepo.CreateIdentity(ctx, userId, identity)
+}
+
+// GetIdentities lists the identities linked to the user in the context
+func (s *SocialLoginService) GetIdentities(ctx context.Context) ([]model.UserIdentity, error) {
+	userId := ctx.Value("userId").(uuid.UUID)
+	return s.identityRepo.GetUserIdentities(ctx, userId)
+}
+
+// UnlinkIdentity unlinks the identity at the provider from the user in the context
+func (s *SocialLoginService) UnlinkIdentity(ctx context.Context, providerName string) error {
+	userId := ctx.Value("userId").(uuid.UUID)
+
+	deleted, err := s.identityRepo.DeleteIdentity(ctx, userId, providerName)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrIdentityNotFound
+	}
+
+	return nil
+}
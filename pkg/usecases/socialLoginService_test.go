@@ -0,0 +1,415 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/config"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const mockClientID = "go-auth"
+
+// mockIdP is an OpenID Connect provider answering every code with an ID token
+// for the same user
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	// nonce is put in the ID tokens issued; empty to echo the nonce of the last authorization
+	nonce         string
+	lastNonce     string
+	subject       string
+	email         string
+	emailVerified bool
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &mockIdP{key: key, subject: "mock-subject", email: "jane@example.com", emailVerified: true}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(model.JSONWebKeySet{Keys: []model.JSONWebKey{{
+			KeyType:   "RSA",
+			Use:       "sig",
+			Algorithm: "RS256",
+			KeyID:     "mock",
+			N:         base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		nonce := idp.nonce
+		if nonce == "" {
+			nonce = idp.lastNonce
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            idp.server.URL,
+			"aud":            mockClientID,
+			"sub":            idp.subject,
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"nonce":          nonce,
+			"email":          idp.email,
+			"email_verified": idp.emailVerified,
+			"name":           "Jane Doe",
+		})
+		token.Header["kid"] = "mock"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "mock-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+// authorize plays the user signing in at the provider, returning the state to
+// call back with and remembering the nonce for the ID token
+func (idp *mockIdP) authorize(t *testing.T, authURL string) string {
+	t.Helper()
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(authURL, idp.server.URL+"/authorize") {
+		t.Fatalf("got authorization URL %s, want one of the mock provider", authURL)
+	}
+
+	idp.lastNonce = parsed.Query().Get("nonce")
+	return parsed.Query().Get("state")
+}
+
+// socialUserRepository keeps users in memory, by email address
+type socialUserRepository struct {
+	repository.UserRepository
+	users map[string]model.User
+}
+
+func (r *socialUserRepository) GetUserByEmail(ctx context.Context, email string) (model.User, error) {
+	user, ok := r.users[email]
+	if !ok {
+		return model.User{}, fmt.Errorf("%w: %w", repository.ErrNotFound, sql.ErrNoRows)
+	}
+	return user, nil
+}
+
+func (r *socialUserRepository) GetUserById(ctx context.Context, userId uuid.UUID) (model.User, error) {
+	for _, user := range r.users {
+		if user.ID == userId {
+			return user, nil
+		}
+	}
+	return model.User{}, fmt.Errorf("%w: %w", repository.ErrNotFound, sql.ErrNoRows)
+}
+
+func (r *socialUserRepository) StoreRefreshToken(ctx context.Context, userId uuid.UUID, refreshToken string, expiresAt time.Time) (model.RefreshToken, error) {
+	return model.RefreshToken{}, nil
+}
+
+func (r *socialUserRepository) UpdateUserLastLogin(ctx context.Context, userId uuid.UUID) error {
+	return nil
+}
+
+// socialIdentityRepository keeps linked identities in memory
+type socialIdentityRepository struct {
+	repository.IdentityRepository
+	identities []model.UserIdentity
+}
+
+func (r *socialIdentityRepository) CreateIdentity(ctx context.Context, userId uuid.UUID, identity model.ExternalIdentity) (model.UserIdentity, error) {
+	created := model.UserIdentity{
+		ID:       uuid.New(),
+		UserID:   userId,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+	r.identities = append(r.identities, created)
+	return created, nil
+}
+
+func (r *socialIdentityRepository) TouchIdentity(ctx context.Context, identityId uuid.UUID) error {
+	return nil
+}
+
+func (r *socialIdentityRepository) GetIdentity(ctx context.Context, provider string, subject string) (model.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return model.UserIdentity{}, sql.ErrNoRows
+}
+
+func (r *socialIdentityRepository) GetUserIdentities(ctx context.Context, userId uuid.UUID) ([]model.UserIdentity, error) {
+	var identities []model.UserIdentity
+	for _, identity := range r.identities {
+		if identity.UserID == userId {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+// socialOrganizationRepository has no organizations
+type socialOrganizationRepository struct {
+	repository.OrganizationRepository
+}
+
+func (r *socialOrganizationRepository) GetUserOrganizationMemberships(ctx context.Context, userId uuid.UUID) ([]model.OrganizationMember, error) {
+	return nil, nil
+}
+
+// socialLoginTest is a social login service signing in at a mock provider,
+// with Jane already having an account
+type socialLoginTest struct {
+	service      *SocialLoginService
+	idp          *mockIdP
+	userRepo     *socialUserRepository
+	identityRepo *socialIdentityRepository
+	jane         model.User
+}
+
+func newSocialLoginTest(t *testing.T) *socialLoginTest {
+	t.Helper()
+	utils.SetTokenSecret("social login test secret")
+
+	idp := newMockIdP(t)
+	jane := model.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com", UserRole: model.RoleUser, AccountStatus: "active"}
+	userRepo := &socialUserRepository{users: map[string]model.User{jane.Email: jane}}
+	identityRepo := &socialIdentityRepository{}
+	userService := NewUserService(userRepo, nil, &socialOrganizationRepository{}, nil, RegistrationPolicy{})
+
+	provider := utils.NewSocialProvider(config.SocialProviderConfig{
+		Name:        "mock",
+		Type:        config.SocialProviderOIDC,
+		ClientID:    mockClientID,
+		Issuer:      idp.server.URL,
+		Scopes:      []string{"openid", "email", "profile"},
+		RedirectURL: "http://localhost/auth/social/mock/callback",
+	})
+
+	return &socialLoginTest{
+		service:      NewSocialLoginService(identityRepo, userRepo, userService, []*utils.SocialProvider{provider}),
+		idp:          idp,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		jane:         jane,
+	}
+}
+
+// signInAtProvider starts a sign in, linking to the user of the link ticket if
+// given, and returns the ticket and the state the provider calls back with
+func (s *socialLoginTest) signInAtProvider(t *testing.T, linkTicket string) (string, string) {
+	t.Helper()
+
+	authURL, ticket, err := s.service.StartLogin(context.Background(), "mock", linkTicket)
+	if err != nil {
+		t.Fatalf("StartLogin: %v", err)
+	}
+
+	return ticket, s.idp.authorize(t, authURL)
+}
+
+func TestSocialLoginStateMismatch(t *testing.T) {
+	test := newSocialLoginTest(t)
+	ticket, _ := test.signInAtProvider(t, "")
+
+	// the callback of a sign in started in another browser
+	_, otherState := test.signInAtProvider(t, "")
+
+	_, _, err := test.service.CompleteLogin(context.Background(), "mock", ticket, otherState, "code")
+	if !errors.Is(err, ErrInvalidSocialLoginState) {
+		t.Errorf("got error %v, want %v", err, ErrInvalidSocialLoginState)
+	}
+}
+
+func TestSocialLoginNonceMismatch(t *testing.T) {
+	test := newSocialLoginTest(t)
+	ticket, state := test.signInAtProvider(t, "")
+
+	// an ID token issued for another authorization
+	test.idp.nonce = "replayed nonce"
+
+	_, _, err := test.service.CompleteLogin(context.Background(), "mock", ticket, state, "code")
+	if err == nil || !strings.Contains(err.Error(), "nonce does not match") {
+		t.Errorf("got error %v, want a nonce mismatch", err)
+	}
+}
+
+func TestSocialLoginUnverifiedEmail(t *testing.T) {
+	test := newSocialLoginTest(t)
+	test.idp.emailVerified = false
+	ticket, state := test.signInAtProvider(t, "")
+
+	identity, _, err := test.service.CompleteLogin(context.Background(), "mock", ticket, state, "code")
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if identity.EmailVerified {
+		t.Fatal("got a verified email address, want an unverified one")
+	}
+
+	// an unverified address must not take over the account with it
+	_, err = test.service.SignIn(context.Background(), identity)
+	if !errors.Is(err, ErrEmailNotVerified) {
+		t.Errorf("got error %v, want %v", err, ErrEmailNotVerified)
+	}
+	if len(test.identityRepo.identities) != 0 {
+		t.Errorf("got %d linked identities, want none", len(test.identityRepo.identities))
+	}
+}
+
+func TestSocialLoginLinksExistingAccount(t *testing.T) {
+	test := newSocialLoginTest(t)
+	ticket, state := test.signInAtProvider(t, "")
+
+	identity, _, err := test.service.CompleteLogin(context.Background(), "mock", ticket, state, "code")
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+
+	loginResponse, err := test.service.SignIn(context.Background(), identity)
+	if err != nil {
+		t.Fatalf("SignIn: %v", err)
+	}
+	if loginResponse.AccessToken == "" || loginResponse.RefreshToken == "" {
+		t.Error("got no tokens")
+	}
+
+	// the identity is linked to the account with the verified address
+	if len(test.identityRepo.identities) != 1 {
+		t.Fatalf("got %d linked identities, want 1", len(test.identityRepo.identities))
+	}
+	linked := test.identityRepo.identities[0]
+	if linked.UserID != test.jane.ID || linked.Subject != test.idp.subject {
+		t.Errorf("got identity %s of user %s, want %s of user %s", linked.Subject, linked.UserID, test.idp.subject, test.jane.ID)
+	}
+
+	// signing in again goes through the link
+	_, err = test.service.SignIn(context.Background(), identity)
+	if err != nil {
+		t.Fatalf("SignIn again: %v", err)
+	}
+	if len(test.identityRepo.identities) != 1 {
+		t.Errorf("got %d linked identities, want 1", len(test.identityRepo.identities))
+	}
+}
+
+func TestSocialLoginSuspendedAccount(t *testing.T) {
+	test := newSocialLoginTest(t)
+	ticket, state := test.signInAtProvider(t, "")
+
+	identity, _, err := test.service.CompleteLogin(context.Background(), "mock", ticket, state, "code")
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+
+	// suspended accounts are not linked to by email
+	suspended := test.jane
+	suspended.AccountStatus = "suspended"
+	test.userRepo.users[suspended.Email] = suspended
+
+	_, err = test.service.SignIn(context.Background(), identity)
+	if !errors.Is(err, ErrAccountNotActive) {
+		t.Errorf("got error %v, want %v", err, ErrAccountNotActive)
+	}
+	if len(test.identityRepo.identities) != 0 {
+		t.Errorf("got %d linked identities, want none", len(test.identityRepo.identities))
+	}
+
+	// nor signed in through an identity linked before the suspension
+	test.userRepo.users[test.jane.Email] = test.jane
+	if _, err := test.service.SignIn(context.Background(), identity); err != nil {
+		t.Fatalf("SignIn: %v", err)
+	}
+	test.userRepo.users[suspended.Email] = suspended
+
+	_, err = test.service.SignIn(context.Background(), identity)
+	if !errors.Is(err, ErrAccountNotActive) {
+		t.Errorf("got error %v, want %v", err, ErrAccountNotActive)
+	}
+}
+
+func TestSocialLoginLinksIdentityFromProfile(t *testing.T) {
+	test := newSocialLoginTest(t)
+
+	// the provider's address need not match the account's
+	test.idp.email = "jane.doe@example.org"
+	test.idp.emailVerified = false
+
+	ctx := context.WithValue(context.Background(), "userId", test.jane.ID)
+	linkTicket, err := test.service.CreateLinkTicket(ctx, "mock")
+	if err != nil {
+		t.Fatalf("CreateLinkTicket: %v", err)
+	}
+	ticket, state := test.signInAtProvider(t, linkTicket)
+
+	identity, claims, err := test.service.CompleteLogin(context.Background(), "mock", ticket, state, "code")
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if claims.LinkUserID == nil || *claims.LinkUserID != test.jane.ID {
+		t.Fatalf("got link to user %v, want %s", claims.LinkUserID, test.jane.ID)
+	}
+
+	linked, err := test.service.LinkIdentity(context.Background(), *claims.LinkUserID, identity)
+	if err != nil {
+		t.Fatalf("LinkIdentity: %v", err)
+	}
+	if linked.UserID != test.jane.ID {
+		t.Errorf("got identity of user %s, want %s", linked.UserID, test.jane.ID)
+	}
+
+	// the identity cannot be linked to another user
+	_, err = test.service.LinkIdentity(context.Background(), uuid.New(), identity)
+	if !errors.Is(err, ErrIdentityLinked) {
+		t.Errorf("got error %v, want %v", err, ErrIdentityLinked)
+	}
+}
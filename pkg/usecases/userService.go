@@ -4,30 +4,166 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"log"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/StaphoneWizzoh/Go_Auth/internal/database"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/logging"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/metrics"
 	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
 	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/tracing"
 	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrInvalidRefreshToken is returned for refresh tokens that were revoked or never issued
+var ErrInvalidRefreshToken = &Error{Kind: ErrUnauthorized, Code: "invalid_refresh_token", Message: "refresh token is invalid or has been revoked"}
+
+var (
+	// ErrInvalidMagicLink is returned for magic links that expired, were used or belong to another browser
+	ErrInvalidMagicLink = &Error{Kind: ErrUnauthorized, Code: "invalid_magic_link", Message: "login link is invalid, has expired or has already been used"}
+	// ErrMagicLinkRateLimited is returned when a user asks for too many magic links
+	ErrMagicLinkRateLimited = &Error{Kind: ErrRateLimited, Code: "magic_link_rate_limited", Message: "too many login links requested, please try again later"}
+)
+
+const (
+	// magicLinkLifetime is how long a magic link can be redeemed
+	magicLinkLifetime = 15 * time.Minute
+	// magicLinkRateLimit is how many magic links a user can be sent per magicLinkRateWindow
+	magicLinkRateLimit  = 3
+	magicLinkRateWindow = 15 * time.Minute
+)
+
 type UserService struct {
-	userRepo repository.UserRepository
+	userRepo           repository.UserRepository
+	roleRepo           repository.RoleRepository
+	orgRepo            repository.OrganizationRepository
+	serviceAccountRepo repository.ServiceAccountRepository
+	registration       RegistrationPolicy
 }
 
-func NewUserService(userRepo repository.UserRepository) *UserService {
+func NewUserService(userRepo repository.UserRepository, roleRepo repository.RoleRepository, orgRepo repository.OrganizationRepository, serviceAccountRepo repository.ServiceAccountRepository, registration RegistrationPolicy) *UserService {
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:           userRepo,
+		roleRepo:           roleRepo,
+		orgRepo:            orgRepo,
+		serviceAccountRepo: serviceAccountRepo,
+		registration:       registration,
 	}
 }
 
+// RegisterUser signs up a user with the default role, as the registration policy allows
+func (s *UserService) RegisterUser(ctx context.Context, email string, password string, firstName string, lastName string) (_ model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.RegisterUser")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	if err := s.registration.checkSignUp(email); err != nil {
+		return model.User{}, err
+	}
+
+	return s.CreateUser(ctx, email, password, firstName, lastName, s.registration.DefaultRole)
+}
+
+// RegisterInvitedUser signs up a user who was invited to an organization with
+// the default role, as the registration policy allows
+func (s *UserService) RegisterInvitedUser(ctx context.Context, email string, password string, firstName string, lastName string) (_ model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.RegisterInvitedUser")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	if err := s.registration.checkInvitedSignUp(email); err != nil {
+		return model.User{}, err
+	}
+
+	return s.CreateUser(ctx, email, password, firstName, lastName, s.registration.DefaultRole)
+}
+
+// CreateUserWithRoles creates a user with the roles on behalf of the
+// administrator in the context, who can only grant roles below their own.
+// Users get the default role when no roles are given. Administrators confined
+// to an organization add the user to it.
+func (s *UserService) CreateUserWithRoles(
+	ctx context.Context,
+	email string,
+	password string,
+	firstName string,
+	lastName string,
+	roles []string,
+) (_ model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUserWithRoles")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	if len(roles) == 0 {
+		roles = []string{s.registration.DefaultRole}
+	}
+
+	// check the roles against the actor's own
+	orgId, err := s.tenantScope(ctx)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	actorId, actorRole, err := s.actor(ctx)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	for _, roleName := range roles {
+		role, err := s.roleRepo.GetRoleByName(ctx, roleName)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, ErrRoleNotFound
+		}
+		if err != nil {
+			return model.User{}, err
+		}
+
+		// the new user has no roles yet and sits at the bottom of the hierarchy
+		err = checkRoleGrant(roleChange{
+			actorId:    actorId,
+			actorRole:  actorRole,
+			targetId:   uuid.Nil,
+			targetRole: model.Role{Name: model.RoleUser},
+			role:       role,
+		})
+		if err != nil {
+			return model.User{}, err
+		}
+	}
+
+	// create user with the first role and grant the others
+	user, err := s.CreateUser(ctx, email, password, firstName, lastName, roles[0])
+	if err != nil {
+		return model.User{}, err
+	}
+
+	for _, roleName := range roles[1:] {
+		if err := s.roleRepo.AssignUserRole(ctx, user.ID, roleName); err != nil {
+			return model.User{}, err
+		}
+	}
+	if len(roles) > 1 {
+		user, err = s.roleRepo.SyncUserPrimaryRole(ctx, user.ID)
+		if err != nil {
+			return model.User{}, err
+		}
+	}
+
+	// keep the user within the administrator's organization
+	if orgId.Valid {
+		if _, err := s.orgRepo.UpsertOrganizationMember(ctx, orgId.UUID, user.ID, model.OrgRoleMember); err != nil {
+			return model.User{}, err
+		}
+	}
+
+	return user, nil
+}
+
 // CreateUser creates a new user
 func (s *UserService) CreateUser(
 	ctx context.Context,
@@ -36,9 +172,36 @@ func (s *UserService) CreateUser(
 	firstName string,
 	lastName string,
 	userRole string,
-) (model.User, error) {
+) (_ model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	// check required fields
+	validation := &ValidationError{}
+	if strings.TrimSpace(email) == "" {
+		validation.Add("email", "required", "Email is required")
+	}
+	if password == "" {
+		validation.Add("password", "required", "Password is required")
+	}
+	if strings.TrimSpace(firstName) == "" {
+		validation.Add("first_name", "required", "First name is required")
+	}
+	if strings.TrimSpace(lastName) == "" {
+		validation.Add("last_name", "required", "Last name is required")
+	}
+	if _, err := s.roleRepo.GetRoleByName(ctx, userRole); errors.Is(err, sql.ErrNoRows) {
+		validation.Add("user_role", "unknown_role", "Role does not exist")
+	} else if err != nil {
+		return model.User{}, err
+	}
+	if err := validation.Err(); err != nil {
+		return model.User{}, err
+	}
+
 	// hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(password)
 	if err != nil {
 		return model.User{}, err
 	}
@@ -60,7 +223,18 @@ func (s *UserService) CreateUser(
 		UserRole:       userRole,
 	}
 
-	return s.userRepo.CreateUser(ctx, newUser)
+	user, err := s.userRepo.CreateUser(ctx, newUser)
+	if err != nil {
+		return model.User{}, toUserError(err)
+	}
+
+	// record role membership
+	err = s.roleRepo.AssignUserRole(ctx, user.ID, user.UserRole)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	return user, nil
 }
 
 // sanitizeUsername sanitizes the given username
@@ -103,36 +277,149 @@ func generateUsername(ctx context.Context, userRepo repository.UserRepository, f
 	}
 }
 
+// hashPassword hashes the password with bcrypt, refusing passwords longer
+// than the 72 bytes bcrypt can hash as invalid
+func hashPassword(password string) ([]byte, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		validation := &ValidationError{}
+		validation.Add("password", "too_long", "must be at most 72 bytes long")
+		return nil, validation.Err()
+	}
+
+	return hashedPassword, err
+}
+
 // LoginUser logs in a user
-func (s *UserService) LoginUser(ctx context.Context, email string, password string) (model.LoginResponse, error) {
+func (s *UserService) LoginUser(ctx context.Context, email string, password string) (loginResponse model.LoginResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.LoginUser")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	defer func() { metrics.RecordLogin(metrics.LoginMethodPassword, outcomeOf(err)) }()
+
+	// check credentials
+	user, err := s.AuthenticateUser(ctx, email, password)
+	if err != nil {
+		return model.LoginResponse{}, err
+	}
+
+	return s.startSession(ctx, user)
+}
+
+// startSession issues tokens for a user who proved who they are and records
+// the login. Suspended and deleted users are refused with ErrAccountNotActive.
+func (s *UserService) startSession(ctx context.Context, user model.User) (model.LoginResponse, error) {
+	tracing.SetUser(ctx, user.ID)
+
+	// inactive users cannot sign in, whichever way they proved who they are
+	if user.AccountStatus != "active" {
+		return model.LoginResponse{}, ErrAccountNotActive
+	}
+
+	// act in the user's oldest organization, if any
+	orgId, err := s.DefaultOrganization(ctx, user.ID)
+	if err != nil {
+		return model.LoginResponse{}, err
+	}
+
+	// generate and store tokens
+	loginResponse, err := s.issueTokens(ctx, user, orgId)
+	if err != nil {
+		return model.LoginResponse{}, err
+	}
+
+	// update last login
+	err = s.userRepo.UpdateUserLastLogin(ctx, user.ID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update last login of user", "user_id", user.ID, "error", err)
+	}
+
+	return loginResponse, nil
+}
+
+// AuthenticateUser returns the user with the email if the password is theirs,
+// or ErrInvalidCredentials
+func (s *UserService) AuthenticateUser(ctx context.Context, email string, password string) (_ model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.AuthenticateUser")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	// get user by email
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, ErrNotFound) {
+		return model.User{}, ErrInvalidCredentials
+	}
 	if err != nil {
-		return model.LoginResponse{}, err
+		return model.User{}, err
 	}
 
 	// compare password
 	err = bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return model.User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+// DefaultOrganization returns the user's oldest organization, nil if they belong to none
+func (s *UserService) DefaultOrganization(ctx context.Context, userId uuid.UUID) (_ *uuid.UUID, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.DefaultOrganization")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	memberships, err := s.orgRepo.GetUserOrganizationMemberships(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(memberships) == 0 {
+		return nil, nil
+	}
+	return &memberships[0].OrgID, nil
+}
+
+// SwitchOrganization issues tokens acting in another organization the user in the context belongs to
+func (s *UserService) SwitchOrganization(ctx context.Context, orgId uuid.UUID) (_ model.LoginResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.SwitchOrganization")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	// get user
+	userId := ctx.Value("userId").(uuid.UUID)
+	user, err := s.userRepo.GetUserById(ctx, userId)
 	if err != nil {
 		return model.LoginResponse{}, err
 	}
 
-	// generate access token and refresh token
-	accessToken, refreshToken, expireTime, err := utils.GenerateTokens(user.ID, user.Username, user.Email, user.UserRole)
+	// check membership
+	_, err = s.orgRepo.GetOrganizationMember(ctx, orgId, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.LoginResponse{}, ErrNotOrganizationMember
+	}
 	if err != nil {
 		return model.LoginResponse{}, err
 	}
 
-	// save refresh token
-	_, err = s.userRepo.StoreRefreshToken(ctx, user.ID, refreshToken, expireTime)
+	return s.issueTokens(ctx, user, &orgId)
+}
+
+// issueTokens generates an access and refresh token pair for the user and stores the refresh token
+func (s *UserService) issueTokens(ctx context.Context, user model.User, orgId *uuid.UUID) (model.LoginResponse, error) {
+	// generate access token and refresh token
+	accessToken, refreshToken, expireTime, err := utils.GenerateTokens(user.ID, user.Username, user.Email, user.UserRole, orgId)
 	if err != nil {
 		return model.LoginResponse{}, err
 	}
 
-	// update last login
-	err = s.userRepo.UpdateUserLastLogin(ctx, user.ID)
+	// save refresh token
+	_, err = s.userRepo.StoreRefreshToken(ctx, user.ID, refreshToken, expireTime)
 	if err != nil {
-		log.Printf("Failed to update last login for user with id %s: %v", user.ID.String(), err)
+		return model.LoginResponse{}, err
 	}
 
 	return model.LoginResponse{
@@ -142,7 +429,30 @@ func (s *UserService) LoginUser(ctx context.Context, email string, password stri
 }
 
 // RefreshToken refreshes a user's access token
-func (s *UserService) RefreshToken(ctx context.Context, refreshToken string) (model.LoginResponse, error) {
+func (s *UserService) RefreshToken(ctx context.Context, refreshToken string) (loginResponse model.LoginResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.RefreshToken")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	defer func() { metrics.RecordTokenRefresh(outcomeOf(err)) }()
+
+	// check the refresh token was issued and is not revoked
+	storedRefreshToken, err := s.userRepo.GetRefreshToken(ctx, refreshToken)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.LoginResponse{}, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return model.LoginResponse{}, err
+	}
+	if storedRefreshToken.RevokedAt.Valid {
+		return model.LoginResponse{}, ErrInvalidRefreshToken
+	}
+
+	// refresh tokens issued to OAuth clients are refreshed at the token endpoint
+	if storedRefreshToken.ClientID.Valid {
+		return model.LoginResponse{}, ErrInvalidRefreshToken
+	}
+
 	// generate access token
 	newAccessToken, err := utils.RefreshToken(refreshToken)
 	if err != nil {
@@ -155,6 +465,10 @@ func (s *UserService) RefreshToken(ctx context.Context, refreshToken string) (mo
 	}, nil
 }
 
+// DateLayout is the ISO 8601 format of dates such as dates of birth. It
+// replaced the day first 02-01-2006 format the service used to accept.
+const DateLayout = "2006-01-02"
+
 // UpdateUser updates a user
 func (s *UserService) UpdateUser(
 	ctx context.Context,
@@ -164,7 +478,11 @@ func (s *UserService) UpdateUser(
 	phoneNumber string,
 	gender string,
 	dateOfBirth string,
-) (model.User, error) {
+) (_ model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	// get user
 	userId := ctx.Value("userId").(uuid.UUID)
 	user, err := s.userRepo.GetUserById(ctx, userId)
@@ -196,19 +514,30 @@ func (s *UserService) UpdateUser(
 	}
 
 	if dateOfBirth != "" {
-		dateOfBirthDate, err := time.Parse("02-01-2006", dateOfBirth)
+		dateOfBirthDate, err := time.Parse(DateLayout, dateOfBirth)
 		if err != nil {
-			dateOfBirthDate = time.Time{}
+			validation := &ValidationError{}
+			validation.Add("date_of_birth", "invalid_date", "must be a date in YYYY-MM-DD format")
+			return model.User{}, validation
 		}
-		dateOfBirthValue := sql.NullTime{Time: dateOfBirthDate, Valid: dateOfBirthDate != time.Time{}}
+		dateOfBirthValue := sql.NullTime{Time: dateOfBirthDate, Valid: true}
 		user.DateOfBirth = dateOfBirthValue
 	}
 
-	return s.userRepo.UpdateUser(ctx, user)
+	updatedUser, err := s.userRepo.UpdateUser(ctx, user)
+	if err != nil {
+		return model.User{}, toUserError(err)
+	}
+
+	return updatedUser, nil
 }
 
 // UpdateProfilePicture updates a user's profile picture
-func (s *UserService) UpdateProfilePicture(ctx context.Context, profilePicture string) (model.User, error) {
+func (s *UserService) UpdateProfilePicture(ctx context.Context, profilePicture string) (_ model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateProfilePicture")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	// get user
 	userId := ctx.Value("userId").(uuid.UUID)
 	user, err := s.userRepo.GetUserById(ctx, userId)
@@ -224,16 +553,17 @@ func (s *UserService) UpdateProfilePicture(ctx context.Context, profilePicture s
 }
 
 // SendResetPasswordEmail sends a reset password email to the user
-func (s *UserService) SendResetPasswordEmail(ctx context.Context, email string) error {
+func (s *UserService) SendResetPasswordEmail(ctx context.Context, email string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.SendResetPasswordEmail")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	defer func() { metrics.RecordPasswordReset(metrics.PasswordResetRequested, outcomeOf(err)) }()
+
 	// get user by email
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return err
-	}
-
-	// check if valid user
-	if user.ID == uuid.Nil {
-		return errors.New("invalid user")
+		return toUserError(err)
 	}
 
 	// send reset password email
@@ -241,11 +571,17 @@ func (s *UserService) SendResetPasswordEmail(ctx context.Context, email string)
 }
 
 // ResetPassword resets a user's password
-func (s *UserService) ResetPassword(ctx context.Context, token string, newPassword string) error {
+func (s *UserService) ResetPassword(ctx context.Context, token string, newPassword string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.ResetPassword")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	defer func() { metrics.RecordPasswordReset(metrics.PasswordResetCompleted, outcomeOf(err)) }()
+
 	// verify reset password token
 	userId, err := utils.VerifyResetPasswordToken(token)
 	if err != nil {
-		return err
+		return ErrInvalidResetToken
 	}
 
 	// validate new password
@@ -255,85 +591,575 @@ func (s *UserService) ResetPassword(ctx context.Context, token string, newPasswo
 	//}
 
 	// hash new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(newPassword)
 	if err != nil {
 		return err
 	}
 
 	// update user password
-	return s.userRepo.UpdateUserPassword(ctx, userId, string(hashedPassword))
+	return toUserError(s.userRepo.UpdateUserPassword(ctx, userId, string(hashedPassword)))
+}
+
+// SendMagicLink emails a single-use login link to the user with the email.
+// When bindBrowser is set, the link only works with the returned binding
+// secret, which the caller keeps in the requesting browser. Unknown emails and
+// inactive accounts get no link and no error so the endpoint does not reveal
+// who has an account.
+func (s *UserService) SendMagicLink(ctx context.Context, email string, bindBrowser bool) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.SendMagicLink")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	// get user by email
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if user.AccountStatus != "active" {
+		return "", nil
+	}
+
+	// rate limit
+	sent, err := s.userRepo.CountRecentMagicLinks(ctx, user.ID, time.Now().Add(-magicLinkRateWindow))
+	if err != nil {
+		return "", err
+	}
+	if sent >= magicLinkRateLimit {
+		return "", ErrMagicLinkRateLimited
+	}
+
+	// bind to the browser
+	var bindingSecret, bindingHash string
+	if bindBrowser {
+		bindingSecret, err = utils.GenerateOpaqueToken(32)
+		if err != nil {
+			return "", err
+		}
+		bindingHash = utils.HashToken(bindingSecret)
+	}
+
+	// store magic link
+	magicLink, err := s.userRepo.CreateMagicLink(ctx, user.ID, bindingHash, time.Now().Add(magicLinkLifetime))
+	if err != nil {
+		return "", err
+	}
+
+	// send magic link email
+	err = utils.SendMagicLinkEmail(magicLink.ID, user.ID, user.Email, magicLink.ExpiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	return bindingSecret, nil
+}
+
+// LoginWithMagicLink redeems a magic link, logging its user in like LoginUser
+func (s *UserService) LoginWithMagicLink(ctx context.Context, token string, bindingSecret string) (loginResponse model.LoginResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.LoginWithMagicLink")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	defer func() { metrics.RecordLogin(metrics.LoginMethodMagicLink, outcomeOf(err)) }()
+
+	// verify magic link token
+	claims, err := utils.VerifyMagicLinkToken(token)
+	if err != nil {
+		return model.LoginResponse{}, ErrInvalidMagicLink
+	}
+
+	// consume magic link
+	var bindingHash string
+	if bindingSecret != "" {
+		bindingHash = utils.HashToken(bindingSecret)
+	}
+	magicLink, err := s.userRepo.ConsumeMagicLink(ctx, claims.MagicLinkID, bindingHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.LoginResponse{}, ErrInvalidMagicLink
+	}
+	if err != nil {
+		return model.LoginResponse{}, err
+	}
+
+	// get user
+	user, err := s.userRepo.GetUserById(ctx, magicLink.UserID)
+	if err != nil {
+		return model.LoginResponse{}, err
+	}
+
+	return s.startSession(ctx, user)
 }
 
 // VerifyResetPasswordToken verifies a reset password token
-func (s *UserService) VerifyResetPasswordToken(ctx context.Context, token string) (uuid.UUID, error) {
+func (s *UserService) VerifyResetPasswordToken(ctx context.Context, token string) (_ uuid.UUID, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.VerifyResetPasswordToken")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
 	// verify reset password token
 	passwordToken, err := utils.VerifyResetPasswordToken(token)
 	return passwordToken, err
 }
 
-func (s *UserService) PromoteUserToAdmin(ctx context.Context, userId uuid.UUID)(model.User, error){
-	return s.userRepo.PromoteUserToAdmin(ctx, userId)
+func (s *UserService) SuspendUser(ctx context.Context, userId uuid.UUID)(_ model.User, err error){
+	ctx, span := tracing.Start(ctx, "UserService.SuspendUser")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	if err := s.checkTenantAccess(ctx, userId); err != nil {
+		return model.User{}, err
+	}
+
+	user, err := s.GetUserById(ctx, userId)
+	if err != nil {
+		return model.User{}, err
+	}
+	if user.AccountStatus == "suspended" {
+		return model.User{}, ErrUserAlreadySuspended
+	}
+
+	// check the suspension against the hierarchy
+	change, err := s.statusChangeOf(ctx, userId, true)
+	if err != nil {
+		return model.User{}, err
+	}
+	if err := checkStatusChange(change); err != nil {
+		return model.User{}, err
+	}
+
+	if err := deactivateUser(ctx, s.userRepo, change, "suspended"); err != nil {
+		return model.User{}, toUserError(err)
+	}
+	return s.GetUserById(ctx, userId)
 }
 
-func (s *UserService) PromoteUserToSuperAdmin(ctx context.Context, userId uuid.UUID)(model.User, error){
-	return s.userRepo.PromoteUserToSuperAdmin(ctx, userId)
+func (s *UserService) RecoverUser(ctx context.Context, userId uuid.UUID)(_ model.User, err error){
+	ctx, span := tracing.Start(ctx, "UserService.RecoverUser")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	if err := s.checkTenantAccess(ctx, userId); err != nil {
+		return model.User{}, err
+	}
+
+	user, err := s.GetUserById(ctx, userId)
+	if err != nil {
+		return model.User{}, err
+	}
+	if user.AccountStatus == "active" {
+		return model.User{}, ErrUserAlreadyActive
+	}
+
+	// check the recovery against the hierarchy
+	change, err := s.statusChangeOf(ctx, userId, false)
+	if err != nil {
+		return model.User{}, err
+	}
+	if err := checkStatusChange(change); err != nil {
+		return model.User{}, err
+	}
+
+	user, err = s.userRepo.RecoverUser(ctx, userId)
+	return user, toUserError(err)
 }
 
-func (s *UserService) DemoteSuperAdminToAdmin(ctx context.Context, userId uuid.UUID)(model.User, error){
-	return s.userRepo.DemoteSuperAdminToAdmin(ctx, userId)
+func (s *UserService) DeleteUser(ctx context.Context, userId uuid.UUID) (err error){
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	if err := s.checkTenantAccess(ctx, userId); err != nil {
+		return err
+	}
+
+	user, err := s.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.AccountStatus == "deleted" {
+		return ErrUserAlreadyDeleted
+	}
+
+	// check the deletion against the hierarchy
+	change, err := s.statusChangeOf(ctx, userId, true)
+	if err != nil {
+		return err
+	}
+	if err := checkStatusChange(change); err != nil {
+		return err
+	}
+
+	return toUserError(deactivateUser(ctx, s.userRepo, change, "deleted"))
 }
 
-func (s *UserService) DemoteSuperAdminToUser(ctx context.Context, userId uuid.UUID)(model.User, error){
-	return s.userRepo.DemoteSuperAdminToUser(ctx, userId)
+// SetUserRole replaces the roles of the user with the role, on behalf of the
+// actor in the context, who can only grant and revoke roles below their own
+func (s *UserService) SetUserRole(ctx context.Context, userId uuid.UUID, roleName string) (_ model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.SetUserRole")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	if err := s.checkTenantAccess(ctx, userId); err != nil {
+		return model.User{}, err
+	}
+	if _, err := s.GetUserById(ctx, userId); err != nil {
+		return model.User{}, err
+	}
+
+	role, err := s.roleRepo.GetRoleByName(ctx, roleName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, ErrRoleNotFound
+	}
+	if err != nil {
+		return model.User{}, err
+	}
+
+	// check the grant and every revocation against the hierarchy
+	actorId, actorRole, err := s.actor(ctx)
+	if err != nil {
+		return model.User{}, err
+	}
+	heldRoles, err := s.roleRepo.GetUserRoles(ctx, userId)
+	if err != nil {
+		return model.User{}, err
+	}
+	superAdmins, err := s.roleRepo.CountRoleMembers(ctx, model.RoleSuperAdmin)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	change := roleChange{
+		actorId:     actorId,
+		actorRole:   actorRole,
+		targetId:    userId,
+		targetRole:  model.Role{Name: model.RoleUser},
+		role:        role,
+		superAdmins: superAdmins,
+	}
+	if len(heldRoles) > 0 {
+		change.targetRole = heldRoles[0]
+	}
+
+	held := false
+	for _, heldRole := range heldRoles {
+		if heldRole.Name == roleName {
+			held = true
+			continue
+		}
+		change.role = heldRole
+		if err := checkRoleRevoke(change); err != nil {
+			return model.User{}, err
+		}
+	}
+	if !held {
+		change.role = role
+		if err := checkRoleGrant(change); err != nil {
+			return model.User{}, err
+		}
+	}
+
+	// grant first so the user is never left without a role
+	if !held {
+		if err := s.roleRepo.AssignUserRole(ctx, userId, roleName); err != nil {
+			return model.User{}, err
+		}
+	}
+	for _, heldRole := range heldRoles {
+		if heldRole.Name == roleName {
+			continue
+		}
+		if err := revokeUserRole(ctx, s.roleRepo, userId, heldRole.Name); err != nil {
+			return model.User{}, err
+		}
+	}
+
+	return s.roleRepo.SyncUserPrimaryRole(ctx, userId)
 }
 
-func (s *UserService) DemoteAdminToUser(ctx context.Context, userId uuid.UUID)(model.User, error){
-	return s.userRepo.DemoteAdminToUser(ctx, userId)
+// SetPassword sets the password of the user on behalf of an administrator and
+// signs the user out everywhere
+func (s *UserService) SetPassword(ctx context.Context, userId uuid.UUID, newPassword string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserService.SetPassword")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	if err := s.checkTenantAccess(ctx, userId); err != nil {
+		return err
+	}
+	if newPassword == "" {
+		validation := &ValidationError{}
+		validation.Add("password", "required", "Password is required")
+		return validation.Err()
+	}
+
+	// hash new password
+	hashedPassword, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	// update user password
+	if err := s.userRepo.UpdateUserPassword(ctx, userId, string(hashedPassword)); err != nil {
+		return toUserError(err)
+	}
+
+	_, err = s.userRepo.RevokeUserRefreshTokens(ctx, userId)
+	return err
+}
+
+// RevokeUserTokens revokes the refresh tokens of the user, signing them out
+// once their access tokens expire, and returns how many were revoked
+func (s *UserService) RevokeUserTokens(ctx context.Context, userId uuid.UUID) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.RevokeUserTokens")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	if err := s.checkTenantAccess(ctx, userId); err != nil {
+		return 0, err
+	}
+	if _, err := s.GetUserById(ctx, userId); err != nil {
+		return 0, err
+	}
+
+	return s.userRepo.RevokeUserRefreshTokens(ctx, userId)
+}
+
+// RevokeAllTokens revokes the refresh tokens of every user and returns how
+// many were revoked. Only operators on the command line can do so.
+func (s *UserService) RevokeAllTokens(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.RevokeAllTokens")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	if !utils.IsOperatorInContext(ctx) {
+		return 0, ErrOperatorOnly
+	}
+
+	return s.userRepo.RevokeAllRefreshTokens(ctx)
+}
+
+// statusChangeOf returns the change of the account status of the user by the
+// actor in the context, for checkStatusChange
+func (s *UserService) statusChangeOf(ctx context.Context, userId uuid.UUID, deactivates bool) (statusChange, error) {
+	actorId, actorRole, err := s.actor(ctx)
+	if err != nil {
+		return statusChange{}, err
+	}
+
+	return newStatusChange(ctx, s.roleRepo, actorId, actorRole, userId, deactivates)
+}
+
+// actor returns the id and highest ranked role of the caller. Operators on the
+// command line have no id and outrank every role. Service accounts rank with
+// the user who created them, as they only hold permissions that user holds.
+func (s *UserService) actor(ctx context.Context) (uuid.UUID, model.Role, error) {
+	if utils.IsOperatorInContext(ctx) {
+		return uuid.Nil, operatorRole, nil
+	}
+
+	actorId := ctx.Value("userId").(uuid.UUID)
+	if utils.IsServiceAccountInContext(ctx) {
+		actorRole, err := s.serviceAccountRole(ctx, actorId)
+		return actorId, actorRole, err
+	}
+
+	actorRole, err := highestRole(ctx, s.roleRepo, actorId)
+	return actorId, actorRole, err
 }
 
-func (s *UserService) SuspendUser(ctx context.Context, userId uuid.UUID)(model.User, error){
-	return s.userRepo.SuspendUser(ctx, userId)
+// serviceAccountRole returns the service account role ranked as the highest
+// role of the user who created the service account, or as the user role when
+// it has no creator
+func (s *UserService) serviceAccountRole(ctx context.Context, serviceAccountId uuid.UUID) (model.Role, error) {
+	serviceAccount, err := s.serviceAccountRepo.GetServiceAccountById(ctx, serviceAccountId)
+	if err != nil {
+		return model.Role{}, err
+	}
+
+	role := model.Role{Name: model.RoleServiceAccount}
+	if serviceAccount.CreatedBy == nil {
+		return role, nil
+	}
+
+	creatorRole, err := highestRole(ctx, s.roleRepo, *serviceAccount.CreatedBy)
+	if err != nil {
+		return model.Role{}, err
+	}
+	role.Rank = creatorRole.Rank
+
+	return role, nil
 }
 
-func (s *UserService) RecoverUser(ctx context.Context, userId uuid.UUID)(model.User, error){
-	return s.userRepo.RecoverUser(ctx, userId)
+// GetUserById returns the user with the id, or ErrUserNotFound
+func (s *UserService) GetUserById(ctx context.Context, userId uuid.UUID)(_ model.User, err error){
+	ctx, span := tracing.Start(ctx, "UserService.GetUserById")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	user, err := s.userRepo.GetUserById(ctx, userId)
+	return user, toUserError(err)
 }
 
-func (s *UserService) DeleteUser(ctx context.Context, userId uuid.UUID) error{
-	return s.userRepo.DeleteUser(ctx, userId)
+// GetUserByEmail returns the user with the email, or ErrUserNotFound
+func (s *UserService) GetUserByEmail(ctx context.Context, email string)(_ model.User, err error){
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByEmail")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	return user, toUserError(err)
 }
 
+func (s *UserService) GetAllUsers(ctx context.Context, limit, offset int32)(_ []database.User, err error){
+	ctx, span := tracing.Start(ctx, "UserService.GetAllUsers")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	orgId, err := s.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-func (s *UserService) GetUserByEmail(ctx context.Context, email string)(model.User, error){
-	return s.userRepo.GetUserByEmail(ctx, email)
+	return s.userRepo.GetAllUsers(ctx, orgId, limit, offset)
 }
 
-func (s *UserService) GetAllUsers(ctx context.Context, limit, offset int32)([]database.User, error){
-	return s.userRepo.GetAllUsers(ctx, limit, offset)
+func (s *UserService) GetAdminUsers(ctx context.Context, limit, offset int32)(_ []database.User, err error){
+	ctx, span := tracing.Start(ctx, "UserService.GetAdminUsers")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	orgId, err := s.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetAdminUsers(ctx, orgId, limit, offset)
 }
 
-func (s *UserService) GetAdminUsers(ctx context.Context, limit, offset int32)([]database.User, error){
-	return s.userRepo.GetAdminUsers(ctx, limit, offset)
+func (s *UserService) GetSuperAdminUsers(ctx context.Context, limit, offset int32)(_ []database.User, err error){
+	ctx, span := tracing.Start(ctx, "UserService.GetSuperAdminUsers")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	orgId, err := s.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetSuperAdminUsers(ctx, orgId, limit, offset)
 }
 
-func (s *UserService) GetSuperAdminUsers(ctx context.Context, limit, offset int32)([]database.User, error){
-	return s.userRepo.GetSuperAdminUsers(ctx, limit, offset)
+func (s *UserService) GetActiveUsers(ctx context.Context, limit, offset int32)(_ []database.User, err error){
+	ctx, span := tracing.Start(ctx, "UserService.GetActiveUsers")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	orgId, err := s.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetActiveUsers(ctx, orgId, limit, offset)
 }
 
-func (s *UserService) GetActiveUsers(ctx context.Context, limit, offset int32)([]database.User, error){
-	return s.userRepo.GetActiveUsers(ctx, limit, offset)
+func (s *UserService) GetInactiveUsers(ctx context.Context, limit, offset int32)(_ []database.User, err error){
+	ctx, span := tracing.Start(ctx, "UserService.GetInactiveUsers")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	orgId, err := s.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetInactiveUsers(ctx, orgId, limit, offset)
 }
 
-func (s *UserService) GetInactiveUsers(ctx context.Context, limit, offset int32)([]database.User, error){
-	return s.userRepo.GetInactiveUsers(ctx, limit, offset)
+func (s *UserService) GetSuspendedUsers(ctx context.Context, limit, offset int32)(_ []database.User, err error){
+	ctx, span := tracing.Start(ctx, "UserService.GetSuspendedUsers")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	orgId, err := s.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetSuspendedUsers(ctx, orgId, limit, offset)
 }
 
-func (s *UserService) GetSuspendedUsers(ctx context.Context, limit, offset int32)([]database.User, error){
-	return s.userRepo.GetSuspendedUsers(ctx, limit, offset)
+func (s *UserService) GetDeletedUsers(ctx context.Context, limit, offset int32)(_ []database.User, err error){
+	ctx, span := tracing.Start(ctx, "UserService.GetDeletedUsers")
+	defer span.End()
+	defer func() { tracing.RecordError(span, err) }()
+
+	orgId, err := s.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetDeletedUsers(ctx, orgId, limit, offset)
 }
 
-func (s *UserService) GetDeletedUsers(ctx context.Context, limit, offset int32)([]database.User, error){
-	return s.userRepo.GetDeletedUsers(ctx, limit, offset)
+// tenantScope returns the organization user listings and admin actions are
+// confined to. Platform super administrators and platform service accounts are
+// not confined to any.
+func (s *UserService) tenantScope(ctx context.Context) (uuid.NullUUID, error) {
+	// operators on the command line are not confined
+	if utils.IsOperatorInContext(ctx) {
+		return uuid.NullUUID{}, nil
+	}
+
+	// service accounts are confined to their organization, platform ones to none
+	if utils.IsServiceAccountInContext(ctx) {
+		orgId, ok := utils.GetOrgIdFromContext(ctx)
+		return uuid.NullUUID{UUID: orgId, Valid: ok}, nil
+	}
+
+	// get caller
+	userId := ctx.Value("userId").(uuid.UUID)
+	user, err := s.userRepo.GetUserById(ctx, userId)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	if user.UserRole == model.RoleSuperAdmin {
+		return uuid.NullUUID{}, nil
+	}
+
+	// everyone else is confined to the organization on their token
+	orgId, ok := utils.GetOrgIdFromContext(ctx)
+	if !ok {
+		return uuid.NullUUID{}, ErrNoOrganization
+	}
+
+	_, err = s.orgRepo.GetOrganizationMember(ctx, orgId, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.NullUUID{}, ErrNotOrganizationMember
+	}
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	return uuid.NullUUID{UUID: orgId, Valid: true}, nil
 }
 
+// checkTenantAccess makes sure the target user is within the caller's tenant
+func (s *UserService) checkTenantAccess(ctx context.Context, targetId uuid.UUID) error {
+	orgId, err := s.tenantScope(ctx)
+	if err != nil {
+		return err
+	}
+	if !orgId.Valid {
+		return nil
+	}
+
+	_, err = s.orgRepo.GetOrganizationMember(ctx, orgId.UUID, targetId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrOutsideOrganization
+	}
 
+	return err
+}
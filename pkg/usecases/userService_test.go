@@ -0,0 +1,97 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/repository"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/utils"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashPassword(t *testing.T) {
+	// 72 bytes is as long as bcrypt goes
+	password := strings.Repeat("é", 36)
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		t.Fatalf("hashPassword: %v", err)
+	}
+	if err := bcrypt.CompareHashAndPassword(hashedPassword, []byte(password)); err != nil {
+		t.Errorf("password does not match its hash: %v", err)
+	}
+
+	// one more character is refused as an invalid password, not a server error
+	_, err = hashPassword(password + "a")
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) != 1 || validationErr.Fields[0].Code != "too_long" {
+		t.Errorf("got error %v, want the password to be too long", err)
+	}
+}
+
+func TestStartSessionRefusesInactiveAccounts(t *testing.T) {
+	service := &UserService{}
+	for _, status := range []string{"inactive", "suspended", "deleted"} {
+		_, err := service.startSession(context.Background(), model.User{ID: uuid.New(), AccountStatus: status})
+		if !errors.Is(err, ErrAccountNotActive) {
+			t.Errorf("got error %v for a %s account, want ErrAccountNotActive", err, status)
+		}
+	}
+}
+
+// creatorServiceAccountRepository has service accounts created by their creators
+type creatorServiceAccountRepository struct {
+	repository.ServiceAccountRepository
+	creators map[uuid.UUID]*uuid.UUID
+}
+
+func (r *creatorServiceAccountRepository) GetServiceAccountById(ctx context.Context, serviceAccountId uuid.UUID) (model.ServiceAccount, error) {
+	return model.ServiceAccount{ID: serviceAccountId, CreatedBy: r.creators[serviceAccountId]}, nil
+}
+
+func TestServiceAccountActor(t *testing.T) {
+	admin, support := uuid.New(), uuid.New()
+	byAdmin, bySupport, orphan := uuid.New(), uuid.New(), uuid.New()
+
+	service := NewUserService(
+		nil,
+		&rankedRoleRepository{roles: map[uuid.UUID]model.Role{admin: adminRole, support: supportRole}},
+		nil,
+		&creatorServiceAccountRepository{creators: map[uuid.UUID]*uuid.UUID{byAdmin: &admin, bySupport: &support}},
+		RegistrationPolicy{},
+	)
+
+	tests := []struct {
+		name           string
+		serviceAccount uuid.UUID
+		rank           int32
+		// code is the code of granting the user role
+		code string
+	}{
+		{name: "created by an admin", serviceAccount: byAdmin, rank: adminRole.Rank},
+		{name: "created by a support agent", serviceAccount: bySupport, rank: supportRole.Rank},
+		{name: "without a creator", serviceAccount: orphan, rank: 0, code: "target_not_subordinate"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := utils.SetPrincipalTypeInContext(utils.SetUserIdInContext(context.Background(), test.serviceAccount), model.PrincipalServiceAccount)
+			_, role, err := service.actor(ctx)
+			if err != nil {
+				t.Fatalf("actor: %v", err)
+			}
+			if role.Name != model.RoleServiceAccount || role.Rank != test.rank {
+				t.Errorf("got role %s ranked %d, want %s ranked %d", role.Name, role.Rank, model.RoleServiceAccount, test.rank)
+			}
+
+			// users can be created, as by CreateUserWithRoles, with roles below the creator's
+			err = checkRoleGrant(roleChange{actorId: test.serviceAccount, actorRole: role, targetRole: userRole, role: userRole})
+			if code := roleCode(t, err); code != test.code {
+				t.Errorf("got code %q granting the user role, want %q", code, test.code)
+			}
+		})
+	}
+}
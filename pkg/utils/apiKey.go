@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyPrefix starts every API key, telling them apart from JWTs and making
+// leaked keys easy to spot in code and logs
+const APIKeyPrefix = "ga_"
+
+// GenerateAPIKey returns a new API key of the form ga_<prefix>_<secret> and
+// its lookup prefix. The prefix is stored in plain text to find the key by.
+func GenerateAPIKey() (string, string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	prefix := hex.EncodeToString(b)
+
+	secret, err := GenerateOpaqueToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	return APIKeyPrefix + prefix + "_" + secret, prefix, nil
+}
+
+// IsAPIKey reports whether a bearer token is an API key rather than a JWT
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// ParseAPIKey returns the lookup prefix of an API key
+func ParseAPIKey(key string) (string, bool) {
+	prefix, secret, ok := strings.Cut(strings.TrimPrefix(key, APIKeyPrefix), "_")
+	if !IsAPIKey(key) || !ok || len(prefix) != 12 || secret == "" {
+		return "", false
+	}
+
+	return prefix, true
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"context"
 
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
 	"github.com/google/uuid"
 )
 
@@ -12,4 +13,80 @@ func SetUserIdInContext(ctx context.Context, userID uuid.UUID) context.Context{
 
 func SetUserRoleInContext(ctx context.Context,  userRole string) context.Context{
 	return context.WithValue(ctx, "userRole", userRole)
-}
\ No newline at end of file
+}
+
+func GetUserRoleFromContext(ctx context.Context) (string, bool){
+	userRole, ok := ctx.Value("userRole").(string)
+	return userRole, ok
+}
+
+func GetUserIdFromContext(ctx context.Context) (uuid.UUID, bool){
+	userID, ok := ctx.Value("userId").(uuid.UUID)
+	return userID, ok
+}
+
+func SetOrgIdInContext(ctx context.Context, orgID uuid.UUID) context.Context{
+	return context.WithValue(ctx, "orgId", orgID)
+}
+
+func GetOrgIdFromContext(ctx context.Context) (uuid.UUID, bool){
+	orgID, ok := ctx.Value("orgId").(uuid.UUID)
+	return orgID, ok
+}
+
+func SetAPIKeyInContext(ctx context.Context, apiKeyID uuid.UUID, scopes []string) context.Context{
+	ctx = context.WithValue(ctx, "apiKeyId", apiKeyID)
+	return context.WithValue(ctx, "apiKeyScopes", scopes)
+}
+
+// GetAPIKeyScopesFromContext returns the scopes of the API key the request was
+// authenticated with. ok is false for requests authenticated with a JWT.
+func GetAPIKeyScopesFromContext(ctx context.Context) ([]string, bool){
+	if _, ok := ctx.Value("apiKeyId").(uuid.UUID); !ok {
+		return nil, false
+	}
+	scopes, _ := ctx.Value("apiKeyScopes").([]string)
+	return scopes, true
+}
+
+func SetPrincipalTypeInContext(ctx context.Context, principalType string) context.Context{
+	return context.WithValue(ctx, "principalType", principalType)
+}
+
+// IsServiceAccountInContext reports whether the request was authenticated as a service account
+func IsServiceAccountInContext(ctx context.Context) bool{
+	principalType, _ := ctx.Value("principalType").(string)
+	return principalType == model.PrincipalServiceAccount
+}
+
+func SetImpersonatorInContext(ctx context.Context, actorID uuid.UUID) context.Context{
+	return context.WithValue(ctx, "impersonatorId", actorID)
+}
+
+// GetImpersonatorFromContext returns the administrator acting as the user in
+// the context. ok is false unless the request used an impersonation token.
+func GetImpersonatorFromContext(ctx context.Context) (uuid.UUID, bool){
+	actorID, ok := ctx.Value("impersonatorId").(uuid.UUID)
+	return actorID, ok
+}
+func SetOperatorInContext(ctx context.Context) context.Context{
+	return context.WithValue(ctx, "operator", true)
+}
+
+// IsOperatorInContext reports whether the call comes from an operator on the
+// command line, who has access to the configuration and database of the server
+func IsOperatorInContext(ctx context.Context) bool{
+	operator, _ := ctx.Value("operator").(bool)
+	return operator
+}
+
+func SetCSRFTokenInContext(ctx context.Context, token string) context.Context{
+	return context.WithValue(ctx, "csrfToken", token)
+}
+
+// GetCSRFTokenFromContext returns the CSRF token forms served for the request
+// must post back, which the CSRF middleware puts in the context
+func GetCSRFTokenFromContext(ctx context.Context) string{
+	token, _ := ctx.Value("csrfToken").(string)
+	return token
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"errors"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/config"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/metrics"
+)
+
+// ErrMailerNotConfigured is returned when sending an email without an SMTP server
+var ErrMailerNotConfigured = errors.New("no SMTP server is configured to send emails with")
+
+var (
+	// mailerMu guards the mailer configuration, which is replaced when secrets are reloaded
+	mailerMu sync.RWMutex
+	// mailer is the SMTP server emails are sent through
+	mailer config.SMTPConfig
+	// publicURL is the base URL of links sent by email
+	publicURL = "http://localhost:8000"
+)
+
+// ConfigureMailer sets the SMTP server emails are sent through and the base URL of the links they carry
+func ConfigureMailer(smtpConfig config.SMTPConfig, baseURL string) {
+	mailerMu.Lock()
+	defer mailerMu.Unlock()
+
+	mailer = smtpConfig
+	publicURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// MailerConfigured reports whether an SMTP server is configured
+func MailerConfigured() bool {
+	mailerMu.RLock()
+	defer mailerMu.RUnlock()
+
+	return mailer.Host != ""
+}
+
+// publicLink returns the public URL of the path carrying the token
+func publicLink(path string, token string) string {
+	mailerMu.RLock()
+	defer mailerMu.RUnlock()
+
+	return publicURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// Kinds of emails, recorded in metrics
+const (
+	emailInvitation    = "invitation"
+	emailMagicLink     = "magic_link"
+	emailResetPassword = "reset_password"
+)
+
+// sendEmail sends an HTML email of the kind
+func sendEmail(kind string, email string, subject string, body string) (err error) {
+	defer func() { metrics.RecordEmail(kind, err) }()
+
+	mailerMu.RLock()
+	mailer := mailer
+	mailerMu.RUnlock()
+
+	if mailer.Host == "" {
+		return ErrMailerNotConfigured
+	}
+
+	// email header
+	headers := []string{
+		"From: " + mailer.From,
+		"To: " + email,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/html; charset=\"utf-8\"",
+	}
+	header := strings.Join(headers, "\r\n")
+	message := []byte(header + "\r\n\r\n" + body)
+
+	// send email, authenticating when there is a password
+	var auth smtp.Auth
+	if mailer.Password != "" {
+		auth = smtp.PlainAuth("", mailer.Username, mailer.Password, mailer.Host)
+	}
+	err = smtp.SendMail(mailer.Host+":"+strconv.Itoa(mailer.Port), auth, mailer.From, []string{email}, message)
+	if err != nil {
+		return err
+	}
+	return nil
+}
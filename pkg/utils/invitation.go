@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// InvitationClaims are carried by organization invitation tokens
+type InvitationClaims struct {
+	InvitationID uuid.UUID `json:"invitationId"`
+	OrgID        uuid.UUID `json:"org_id"`
+	Email        string    `json:"email"`
+	OrgRole      string    `json:"org_role"`
+	jwt.RegisteredClaims
+}
+
+// GenerateInvitationToken signs a token for the invitation that expires with it
+func GenerateInvitationToken(invitationID uuid.UUID, orgID uuid.UUID, email string, orgRole string, expiresAt time.Time) (string, error) {
+	// create claims
+	claims := InvitationClaims{
+		InvitationID: invitationID,
+		OrgID:        orgID,
+		Email:        email,
+		OrgRole:      orgRole,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   email,
+		},
+	}
+
+	// create token
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	// sign token
+	return token.SignedString(tokenKeys().invitation)
+}
+
+// VerifyInvitationToken checks the signature and expiry of an invitation token
+func VerifyInvitationToken(tokenString string) (*InvitationClaims, error) {
+	// parse token
+	token, err := jwt.ParseWithClaims(tokenString, &InvitationClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return tokenKeys().invitation, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// check if token is valid
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	// get claims
+	claims, ok := token.Claims.(*InvitationClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+
+	return claims, nil
+}
+
+// SendInvitationEmail emails the invitation link to the invited address
+func SendInvitationEmail(email string, organizationName string, invitationToken string) error {
+	// generate invitation link
+	invitationLink := publicLink("/accept-invitation", invitationToken)
+
+	// email body
+	body := `
+        <html>
+        <head>
+            <style>
+                body { background-color: #f0f0f0; font-family: Arial, sans-serif; }
+                .container { background-color: #fff; padding: 20px; margin: 10px auto; width: 80%; max-width: 600px; }
+                .button { background-color: #007bff; color: #ffffff; padding: 10px; text-decoration: none; border-radius: 5px; }
+            </style>
+        </head>
+        <body>
+            <div class="container">
+                <h2>You have been invited to ` + html.EscapeString(organizationName) + `</h2>
+                <p>Please click the link below to accept the invitation.</p>
+                <a href="` + html.EscapeString(invitationLink) + `" class="button">Accept Invitation</a>
+                <p>If you were not expecting this invitation, please ignore this email.</p>
+            </div>
+        </body>
+        </html>
+    `
+
+	// send email
+	return sendEmail(emailInvitation, email, "You have been invited to "+organizationName, body)
+}
@@ -9,29 +9,46 @@ import (
 	"github.com/google/uuid"
 )
 
-
-var (
-	jwtAccessSecret  = []byte("eK_sS2AgDstNYrh0Bx5LK3nPx-z1h2l_ZdjchgQjvyA=")
-	jwtRefreshSecret = []byte("bS4RqAvfuWhiAjZJ_104wBUcDAbp4cEt2ChP1IYskI8=")
-)
-
 type UserClaims struct{
 	UserID 		uuid.UUID 		`json:"userId"`
 	Username	string			`json:"username"`
 	Email		string			`json:"email"`
 	Role		string			`json:"role"`
+	OrgID		*uuid.UUID		`json:"org_id,omitempty"`
+	ClientID	string			`json:"client_id,omitempty"`
+	Scope		string			`json:"scope,omitempty"`
+	// PrincipalType is service_account on service account tokens and empty on user tokens
+	PrincipalType	string		`json:"principal_type,omitempty"`
+	// Act is the administrator acting as the user on impersonation tokens (RFC 8693)
+	Act			*ActorClaims	`json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateTokens(userID uuid.UUID, username, email, role string)(string, string, time.Time, error){
+// AccessTokenLifetime is how long access tokens are valid for
+const AccessTokenLifetime = 24 * time.Hour
+
+// GenerateTokens generates an access and refresh token pair. orgID is the
+// organization the tokens act in and may be nil.
+func GenerateTokens(userID uuid.UUID, username, email, role string, orgID *uuid.UUID)(string, string, time.Time, error){
+	return GenerateTokenPair(UserClaims{
+		UserID:   userID,
+		Username: username,
+		Email:    email,
+		Role:     role,
+		OrgID:    orgID,
+	})
+}
+
+// GenerateTokenPair generates an access and refresh token pair carrying the claims
+func GenerateTokenPair(claims UserClaims)(string, string, time.Time, error){
 	// Generating access token
-	accessToken, err := generateAccessToken(userID, username, email, role)
+	accessToken, _, err := GenerateAccessToken(claims)
 	if err != nil{
 		return "", "", time.Time{}, err
 	}
 
 	// Generating refresh token
-	refreshToken, expireTime, err := generateRefreshToken(userID, username, email, role)
+	refreshToken, expireTime, err := generateRefreshToken(claims)
 	if err != nil{
 		return "", "", time.Time{}, err
 	}
@@ -39,51 +56,54 @@ func GenerateTokens(userID uuid.UUID, username, email, role string)(string, stri
 	return accessToken, refreshToken, expireTime, nil
 }
 
-func generateAccessToken(userID uuid.UUID, username, email, role string) (string,  error){
+// GenerateAccessToken signs an access token carrying the claims and returns its expiry.
+// The subject defaults to the user ID.
+func GenerateAccessToken(claims UserClaims) (string, time.Time, error){
+	// Token expires in 24 hours
+	return generateAccessToken(claims, AccessTokenLifetime)
+}
+
+func generateAccessToken(claims UserClaims, lifetime time.Duration) (string, time.Time, error){
+
+	expireTime := time.Now().Add(lifetime)
 
 	// Creating claims
-	claims := UserClaims{
-		UserID:   userID,
-		Username: username,
-		Email:    email,
-		Role:     role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // expires in 24 hours
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Subject:   userID.String(),
-		},
+	if claims.Subject == "" {
+		claims.Subject = claims.UserID.String()
 	}
+	claims.ExpiresAt = jwt.NewNumericDate(expireTime)
+	claims.IssuedAt = jwt.NewNumericDate(time.Now())
 
 	// Creating token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Signing token
-	return token.SignedString(jwtAccessSecret)
+	accessToken, err := token.SignedString(tokenKeys().access)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return accessToken, expireTime, nil
 }
 
-func generateRefreshToken(userID uuid.UUID, username string, email string, role string) (string, time.Time, error) {
+func generateRefreshToken(claims UserClaims) (string, time.Time, error) {
 
 	// Token expires in 90 days (3 months)
 	expireTime := time.Now().Add(24 * 90 * time.Hour) 
 
-	// Creating claims
-	claims := UserClaims{
-		UserID:   userID,
-		Username: username,
-		Email:    email,
-		Role:     role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expireTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Subject:   userID.String(),
-		},
+	// Creating claims, with a unique ID so every stored token is distinct
+	if claims.Subject == "" {
+		claims.Subject = claims.UserID.String()
 	}
+	claims.ID = uuid.New().String()
+	claims.ExpiresAt = jwt.NewNumericDate(expireTime)
+	claims.IssuedAt = jwt.NewNumericDate(time.Now())
 
 	// Creating token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Signing token
-	refreshToken, err := token.SignedString(jwtRefreshSecret)
+	refreshToken, err := token.SignedString(tokenKeys().refresh)
 	if err != nil {
 		return "", time.Time{}, err
 	}
@@ -96,9 +116,9 @@ func ParseToken(tokenString string, isAccessToken bool)(*UserClaims, error){
 	var jwtSecret []byte
 
 	if isAccessToken{
-		jwtSecret = jwtAccessSecret
+		jwtSecret = tokenKeys().access
 	}else{
-		jwtSecret = jwtRefreshSecret
+		jwtSecret = tokenKeys().refresh
 	}
 
 	// Parsing token
@@ -136,8 +156,19 @@ func RefreshToken(refreshToken string) (string, error){
 		return "", errors.New("invalid token claims")
 	}
 
+	// Refresh tokens of OAuth clients are refreshed at the token endpoint
+	if claims.ClientID != "" {
+		return "", errors.New("refresh token was issued to an OAuth client")
+	}
+
 	// Generating new access token
-	newAccessToken, err := generateAccessToken(claims.UserID, claims.Username, claims.Email, claims.Role)
+	newAccessToken, _, err := GenerateAccessToken(UserClaims{
+		UserID:   claims.UserID,
+		Username: claims.Username,
+		Email:    claims.Email,
+		Role:     claims.Role,
+		OrgID:    claims.OrgID,
+	})
 	if err != nil{
 		return "", err
 	}
@@ -150,9 +181,9 @@ func ValidateToken(tokenString string, isAccessToken bool) error{
 	var jwtSecret []byte
 
 	if isAccessToken {
-		jwtSecret = jwtAccessSecret
+		jwtSecret = tokenKeys().access
 	} else {
-		jwtSecret = jwtRefreshSecret
+		jwtSecret = tokenKeys().refresh
 	}
 
 	// Parsing token
@@ -176,9 +207,9 @@ func IsTokenExpired(tokenString string, isAccessToken bool) bool{
 	var jwtSecret []byte
 
 	if isAccessToken {
-		jwtSecret = jwtAccessSecret
+		jwtSecret = tokenKeys().access
 	} else {
-		jwtSecret = jwtRefreshSecret
+		jwtSecret = tokenKeys().refresh
 	}
 
 	// Parsing token
@@ -209,7 +240,7 @@ func ExtractUserRoleFromToken(tokenString string)(string, error){
 	// Parse Token
 	token, err := jwt.ParseWithClaims( tokenString, &UserClaims{}, func(token *jwt.Token)(interface{}, error){
 		// Secret Key
-		return []byte(jwtAccessSecret), nil
+		return []byte(tokenKeys().access), nil
 	})
 	if err != nil{
 		if err == jwt.ErrSignatureInvalid{
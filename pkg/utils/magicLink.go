@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"html"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// MagicLinkClaims are carried by magic link login tokens. The link ID is
+// consumed on redemption so every token works once.
+type MagicLinkClaims struct {
+	MagicLinkID uuid.UUID `json:"magicLinkId"`
+	UserID      uuid.UUID `json:"userId"`
+	jwt.RegisteredClaims
+}
+
+// SendMagicLinkEmail emails the user a login link for the magic link
+func SendMagicLinkEmail(magicLinkID uuid.UUID, userID uuid.UUID, email string, expiresAt time.Time) error {
+	// generate magic link token
+	magicLinkToken, err := generateMagicLinkToken(magicLinkID, userID, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	// generate magic link
+	magicLink := publicLink("/magic-link", magicLinkToken)
+
+	// send email
+	return sendEmail(emailMagicLink, email, "Your Login Link", magicLinkEmailBody(magicLink, expiresAt))
+}
+
+func generateMagicLinkToken(magicLinkID uuid.UUID, userID uuid.UUID, expiresAt time.Time) (string, error) {
+	// create claims
+	claims := MagicLinkClaims{
+		MagicLinkID: magicLinkID,
+		UserID:      userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   userID.String(),
+		},
+	}
+
+	// create token
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	// sign token
+	return token.SignedString(tokenKeys().magicLink)
+}
+
+// VerifyMagicLinkToken checks the signature and expiry of a magic link token
+func VerifyMagicLinkToken(tokenString string) (*MagicLinkClaims, error) {
+	claims := &MagicLinkClaims{}
+	if err := verifyHMACToken(tokenString, claims, tokenKeys().magicLink); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// magicLinkEmailBody renders the magic link email around the link
+func magicLinkEmailBody(magicLink string, expiresAt time.Time) string {
+	minutes := strconv.Itoa(int(time.Until(expiresAt).Round(time.Minute).Minutes()))
+
+	return `
+        <html>
+        <head>
+            <style>
+                body { background-color: #f0f0f0; font-family: Arial, sans-serif; }
+                .container { background-color: #fff; padding: 20px; margin: 10px auto; width: 80%; max-width: 600px; }
+                .button { background-color: #007bff; color: #ffffff; padding: 10px; text-decoration: none; border-radius: 5px; }
+            </style>
+        </head>
+        <body>
+            <div class="container">
+                <h2>Sign In</h2>
+                <p>Click the link below to sign in. The link works once and expires in ` + minutes + ` minutes.</p>
+                <a href="` + html.EscapeString(magicLink) + `" class="button">Sign In</a>
+                <p>If you did not ask to sign in, please ignore this email.</p>
+            </div>
+        </body>
+        </html>
+    `
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// authorizationTicketLifetime is how long a user has to answer the consent page after logging in
+const authorizationTicketLifetime = 10 * time.Minute
+
+// AuthorizationTicketClaims carry a validated authorization request and the
+// user who logged in for it from the login page to the consent page
+type AuthorizationTicketClaims struct {
+	UserID  uuid.UUID                  `json:"userId"`
+	Request model.AuthorizationRequest `json:"request"`
+	jwt.RegisteredClaims
+}
+
+// GenerateOpaqueToken returns a random URL-safe token of n random bytes
+func GenerateOpaqueToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex SHA-256 digest tokens are stored and looked up by
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyCodeChallenge checks a PKCE code verifier against the challenge of the authorization request (RFC 7636)
+func VerifyCodeChallenge(verifier string, challenge string, method string) bool {
+	if method != "S256" || len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+// GenerateAuthorizationTicket signs a short-lived ticket for the authorization request the user logged in for
+func GenerateAuthorizationTicket(userID uuid.UUID, request model.AuthorizationRequest) (string, error) {
+	// create claims
+	claims := AuthorizationTicketClaims{
+		UserID:  userID,
+		Request: request,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(authorizationTicketLifetime)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   userID.String(),
+		},
+	}
+
+	// create token
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	// sign token
+	return token.SignedString(tokenKeys().authorizationTicket)
+}
+
+// VerifyAuthorizationTicket checks the signature and expiry of an authorization ticket
+func VerifyAuthorizationTicket(tokenString string) (*AuthorizationTicketClaims, error) {
+	// parse token
+	token, err := jwt.ParseWithClaims(tokenString, &AuthorizationTicketClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return tokenKeys().authorizationTicket, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// check if token is valid
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	// get claims
+	claims, ok := token.Claims.(*AuthorizationTicketClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,256 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// idTokenKey is a key of the ring ID tokens are signed and verified with
+type idTokenKey struct {
+	key   *rsa.PrivateKey
+	keyID string
+}
+
+// idTokenKeys is the key ring. ID tokens are signed with RSA so relying
+// parties can verify them with the published keys. The first key signs; the
+// others are kept from earlier rotations so the ID tokens they signed still
+// verify. The ring is replaced as a whole when it is reloaded.
+var idTokenKeys atomic.Pointer[[]idTokenKey]
+
+// IDTokenLifetime is how long ID tokens are valid for
+const IDTokenLifetime = time.Hour
+
+// LoadIDTokenSigningKey loads the RSA private keys ID tokens are signed with
+// from a PEM file, the signing key first. Without a file a key is generated,
+// and ID tokens issued before a restart no longer verify.
+func LoadIDTokenSigningKey(path string) error {
+	if path == "" {
+		slog.Warn("No ID token signing key configured, generating a temporary one")
+
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return err
+		}
+		setIDTokenSigningKeys([]*rsa.PrivateKey{key})
+		return nil
+	}
+
+	keys, err := readIDTokenSigningKeys(path)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("no PEM data found in the ID token signing key file")
+	}
+
+	setIDTokenSigningKeys(keys)
+	return nil
+}
+
+// RotateIDTokenSigningKey generates a new signing key and writes it to the
+// front of the key file, keeping the previous signing key so the ID tokens it
+// signed still verify. Older keys are dropped. The server picks up the new key
+// when it is restarted or receives SIGHUP. It returns the ID of the new key.
+func RotateIDTokenSigningKey(path string) (string, error) {
+	keys, err := readIDTokenSigningKeys(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", err
+	}
+	keys = append([]*rsa.PrivateKey{key}, keys...)
+	if len(keys) > 2 {
+		keys = keys[:2]
+	}
+
+	// encode keys
+	var data []byte
+	for _, key := range keys {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return "", err
+		}
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})...)
+	}
+
+	// write to a temporary file first so a failed write keeps the previous keys
+	temp, err := os.CreateTemp(filepath.Dir(path), ".id-token-keys-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return "", err
+	}
+	if err := temp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return "", err
+	}
+
+	return idTokenKeyID(&key.PublicKey), nil
+}
+
+// readIDTokenSigningKeys parses every RSA private key of the PEM file, in order
+func readIDTokenSigningKeys(path string) ([]*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*rsa.PrivateKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return keys, nil
+		}
+
+		// accept PKCS #1 and PKCS #8 keys
+		if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+			keys = append(keys, key)
+			continue
+		}
+
+		parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing ID token signing key: %v", err)
+		}
+
+		key, ok := parsedKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("the ID token signing key is not an RSA key")
+		}
+		keys = append(keys, key)
+	}
+}
+
+// setIDTokenSigningKeys replaces the key ring, the signing key first
+func setIDTokenSigningKeys(keys []*rsa.PrivateKey) {
+	ring := make([]idTokenKey, 0, len(keys))
+	for _, key := range keys {
+		ring = append(ring, idTokenKey{key: key, keyID: idTokenKeyID(&key.PublicKey)})
+	}
+
+	idTokenKeys.Store(&ring)
+}
+
+// idTokenKeyID derives the key ID of a key from the RFC 7638 thumbprint of its public key
+func idTokenKeyID(key *rsa.PublicKey) string {
+	jwk := publicJSONWebKey(key, "")
+	thumbprint := sha256.Sum256([]byte(fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)))
+
+	return base64.RawURLEncoding.EncodeToString(thumbprint[:])
+}
+
+// loadedIDTokenKeys returns the key ring, which is empty until keys are loaded
+func loadedIDTokenKeys() []idTokenKey {
+	if ring := idTokenKeys.Load(); ring != nil {
+		return *ring
+	}
+
+	return nil
+}
+
+// IDTokenKeysLoaded reports whether ID tokens can be signed
+func IDTokenKeysLoaded() bool {
+	return len(loadedIDTokenKeys()) > 0
+}
+
+func publicJSONWebKey(key *rsa.PublicKey, keyID string) model.JSONWebKey {
+	return model.JSONWebKey{
+		KeyType:   "RSA",
+		Use:       "sig",
+		Algorithm: "RS256",
+		KeyID:     keyID,
+		N:         base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// IDTokenKeySet returns the public keys ID tokens can be verified with
+func IDTokenKeySet() model.JSONWebKeySet {
+	keys := []model.JSONWebKey{}
+	for _, key := range loadedIDTokenKeys() {
+		keys = append(keys, publicJSONWebKey(&key.key.PublicKey, key.keyID))
+	}
+
+	return model.JSONWebKeySet{Keys: keys}
+}
+
+// GenerateIDToken signs an ID token carrying the claims
+func GenerateIDToken(claims jwt.MapClaims) (string, error) {
+	keys := loadedIDTokenKeys()
+	if len(keys) == 0 {
+		return "", errors.New("no ID token signing key loaded")
+	}
+
+	// create token
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keys[0].keyID
+
+	// sign token
+	return token.SignedString(keys[0].key)
+}
+
+// ParseIDTokenHint checks the signature of an ID token this server issued and
+// returns its claims. Expired tokens are accepted, as logout requests often
+// carry them.
+func ParseIDTokenHint(tokenString string) (jwt.MapClaims, error) {
+	keys := loadedIDTokenKeys()
+	if len(keys) == 0 {
+		return nil, errors.New("no ID token signing key loaded")
+	}
+
+	// parse token
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		// verify with the key that signed the token, or the signing key for tokens without a key ID
+		keyID, _ := token.Header["kid"].(string)
+		if keyID == "" {
+			return &keys[0].key.PublicKey, nil
+		}
+		for _, key := range keys {
+			if key.keyID == keyID {
+				return &key.key.PublicKey, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown key ID %q", keyID)
+	}, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return nil, fmt.Errorf("error parsing token: %v", err)
+	}
+
+	return claims, nil
+}
+
+// AccessTokenHash returns the at_hash ID token claim for an access token
+func AccessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+}
@@ -2,18 +2,12 @@ package utils
 
 import (
 	"errors"
-	"fmt"
-	"log"
-	"net/smtp"
-	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
-var jwtResetPasswordSecret = []byte("LsM_Zx8sZHuBeC4ty9Aau3x1JuCw-jqeDLS5MxU9bvg=")
-
 func SendResetPasswordEmail(userID uuid.UUID, email string) error {
 	// generate reset password token
 	resetPasswordToken, err := generateResetPasswordToken(userID)
@@ -22,10 +16,10 @@ func SendResetPasswordEmail(userID uuid.UUID, email string) error {
 	}
 
 	// generate reset password link
-	resetPasswordLink := fmt.Sprintf("http://localhost:8000/reset-password?token=%s", resetPasswordToken)
+	resetPasswordLink := publicLink("/reset-password", resetPasswordToken)
 
 	// send email
-	err = sendEmail(email, resetPasswordLink)
+	err = sendEmail(emailResetPassword, email, "Reset Your Password", resetPasswordEmailBody(resetPasswordLink))
 	if err != nil {
 		return err
 	}
@@ -48,13 +42,13 @@ func generateResetPasswordToken(userID uuid.UUID) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// sign token
-	return token.SignedString(jwtResetPasswordSecret)
+	return token.SignedString(tokenKeys().resetPassword)
 }
 
 func VerifyResetPasswordToken(tokenString string) (uuid.UUID, error) {
 	// parse token
 	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtResetPasswordSecret, nil
+		return tokenKeys().resetPassword, nil
 	})
 
 	if err != nil {
@@ -76,25 +70,9 @@ func VerifyResetPasswordToken(tokenString string) (uuid.UUID, error) {
 	return claims.UserID, nil
 }
 
-func sendEmail(email string, resetPasswordLink string) error {
-	// smtp server configuration
-	smtpHost := "smtp.gmail.com"
-	smtpPort := "587"
-	smtpEmail := "mkoraondimo@gmail.com"
-	smtpPassword := "wdib qixk yomq mmpt"
-
-	// email header
-	headers := []string{
-		"From: " + smtpEmail,
-		"To: " + email,
-		"Subject: Reset Your Password",
-		"MIME-Version: 1.0",
-		"Content-Type: text/html; charset=\"utf-8\"",
-	}
-	header := strings.Join(headers, "\r\n")
-	log.Printf(resetPasswordLink)
-	// email body
-	body := `
+// resetPasswordEmailBody renders the reset password email around the link
+func resetPasswordEmailBody(resetPasswordLink string) string {
+	return `
         <html>
         <head>
             <style>
@@ -113,13 +91,4 @@ func sendEmail(email string, resetPasswordLink string) error {
         </body>
         </html>
     `
-	message := []byte(header + "\r\n\r\n" + body)
-
-	// send email
-	auth := smtp.PlainAuth("", smtpEmail, smtpPassword, smtpHost)
-	err := smtp.SendMail(smtpHost+":"+smtpPort, auth, smtpEmail, []string{email}, message)
-	if err != nil {
-		return err
-	}
-	return nil
 }
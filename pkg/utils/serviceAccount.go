@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ServiceAccountClientIDPrefix starts the client ID of every service account,
+// telling them apart from OAuth clients at the token endpoint
+const ServiceAccountClientIDPrefix = "sa_"
+
+// ServiceAccountAssertionMaxLifetime bounds how far in the future a service
+// account assertion may expire, which limits how long a leaked one can be replayed
+const ServiceAccountAssertionMaxLifetime = 5 * time.Minute
+
+// serviceAccountAssertionLeeway allows for clock skew between the service account and the server
+const serviceAccountAssertionLeeway = 30 * time.Second
+
+// GenerateServiceAccountClientID returns a new random service account client ID
+func GenerateServiceAccountClientID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return ServiceAccountClientIDPrefix + hex.EncodeToString(b), nil
+}
+
+// IsServiceAccountClientID reports whether a client ID belongs to a service account
+func IsServiceAccountClientID(clientId string) bool {
+	return strings.HasPrefix(clientId, ServiceAccountClientIDPrefix)
+}
+
+// ParseServiceAccountPublicKey parses a PEM encoded RSA or ECDSA public key
+func ParseServiceAccountPublicKey(pemKey string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("no PEM encoded key found")
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// ServiceAccountAssertionIssuer returns the unverified issuer of a service
+// account assertion, which names the service account whose key verifies it
+func ServiceAccountAssertionIssuer(assertion string) (string, error) {
+	claims := jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(assertion, &claims); err != nil {
+		return "", err
+	}
+
+	return claims.Issuer, nil
+}
+
+// VerifyServiceAccountAssertion checks a JWT a service account signed with its
+// private key to authenticate (RFC 7523). The issuer and subject must be the
+// client ID, the audience one of audiences and the expiry close at hand.
+func VerifyServiceAccountAssertion(assertion string, clientId string, pemKey string, audiences []string) error {
+	publicKey, err := ParseServiceAccountPublicKey(pemKey)
+	if err != nil {
+		return err
+	}
+
+	// parse token
+	claims := jwt.RegisteredClaims{}
+	_, err = jwt.ParseWithClaims(assertion, &claims, func(token *jwt.Token) (interface{}, error) {
+		return publicKey, nil
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(clientId),
+		jwt.WithSubject(clientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(serviceAccountAssertionLeeway),
+	)
+	if err != nil {
+		return err
+	}
+
+	// check audience
+	audienceMatches := false
+	for _, audience := range claims.Audience {
+		if slices.Contains(audiences, strings.TrimSuffix(audience, "/")) {
+			audienceMatches = true
+		}
+	}
+	if !audienceMatches {
+		return errors.New("assertion is not intended for this server")
+	}
+
+	// check lifetime
+	if time.Until(claims.ExpiresAt.Time) > ServiceAccountAssertionMaxLifetime+serviceAccountAssertionLeeway {
+		return fmt.Errorf("assertion must expire within %s", ServiceAccountAssertionMaxLifetime)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// socialLoginTicketLifetime is how long a user has to sign in at the identity provider
+	socialLoginTicketLifetime = 10 * time.Minute
+	// socialLinkTicketLifetime is how long a link URL handed out by the API can be opened
+	socialLinkTicketLifetime = 5 * time.Minute
+)
+
+// SocialLoginTicketClaims carry the state of a sign in at an identity provider.
+// The ticket is kept in a cookie so the callback can only complete in the
+// browser that started it. LinkUserID is set when linking the identity to an
+// existing user instead of signing in.
+type SocialLoginTicketClaims struct {
+	Provider     string     `json:"provider"`
+	State        string     `json:"state"`
+	CodeVerifier string     `json:"code_verifier"`
+	Nonce        string     `json:"nonce"`
+	LinkUserID   *uuid.UUID `json:"link_user_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// SocialLinkTicketClaims authorize the browser that opens the link URL to link an identity to the user
+type SocialLinkTicketClaims struct {
+	UserID   uuid.UUID `json:"userId"`
+	Provider string    `json:"provider"`
+	jwt.RegisteredClaims
+}
+
+// GenerateSocialLoginTicket signs the state of a sign in at an identity provider
+func GenerateSocialLoginTicket(claims SocialLoginTicketClaims) (string, error) {
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(socialLoginTicketLifetime))
+	claims.IssuedAt = jwt.NewNumericDate(time.Now())
+
+	// create token
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	// sign token
+	return token.SignedString(tokenKeys().socialLogin)
+}
+
+// VerifySocialLoginTicket checks the signature and expiry of a social login ticket
+func VerifySocialLoginTicket(tokenString string) (*SocialLoginTicketClaims, error) {
+	claims := &SocialLoginTicketClaims{}
+	if err := verifyHMACToken(tokenString, claims, tokenKeys().socialLogin); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// GenerateSocialLinkTicket signs a short-lived ticket to link an identity at the provider to the user
+func GenerateSocialLinkTicket(userID uuid.UUID, provider string) (string, error) {
+	// create claims
+	claims := SocialLinkTicketClaims{
+		UserID:   userID,
+		Provider: provider,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(socialLinkTicketLifetime)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   userID.String(),
+		},
+	}
+
+	// create token
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	// sign token
+	return token.SignedString(tokenKeys().socialLink)
+}
+
+// VerifySocialLinkTicket checks the signature and expiry of a social link ticket
+func VerifySocialLinkTicket(tokenString string) (*SocialLinkTicketClaims, error) {
+	claims := &SocialLinkTicketClaims{}
+	if err := verifyHMACToken(tokenString, claims, tokenKeys().socialLink); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// verifyHMACToken parses an HS256 token signed with the secret into the claims
+func verifyHMACToken(tokenString string, claims jwt.Claims, secret []byte) error {
+	// parse token
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return secret, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// check if token is valid
+	if !token.Valid {
+		return errors.New("invalid token")
+	}
+
+	return nil
+}
@@ -0,0 +1,324 @@
+package utils
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/config"
+	"github.com/StaphoneWizzoh/Go_Auth/pkg/model"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// GitHub endpoints, as GitHub does not implement OpenID Connect
+const (
+	gitHubAuthURL   = "https://github.com/login/oauth/authorize"
+	gitHubTokenURL  = "https://github.com/login/oauth/access_token"
+	gitHubUserURL   = "https://api.github.com/user"
+	gitHubEmailsURL = "https://api.github.com/user/emails"
+)
+
+// SocialProvider is the relying party side of signing in with an external
+// identity provider. OpenID Connect providers are discovered from their issuer
+// on first use.
+type SocialProvider struct {
+	Name string
+
+	config     config.SocialProviderConfig
+	httpClient *http.Client
+
+	mu           sync.Mutex
+	oauth2Config *oauth2.Config
+	issuer       string
+	jwksURI      string
+	keys         map[string]*rsa.PublicKey
+}
+
+func NewSocialProvider(cfg config.SocialProviderConfig) *SocialProvider {
+	return &SocialProvider{
+		Name:       cfg.Name,
+		config:     cfg,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SetClientSecret replaces the client secret, e.g. when secrets are reloaded
+func (p *SocialProvider) SetClientSecret(clientSecret string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.config.ClientSecret = clientSecret
+	if p.oauth2Config != nil {
+		// requests in flight keep the configuration they started with
+		oauth2Config := *p.oauth2Config
+		oauth2Config.ClientSecret = clientSecret
+		p.oauth2Config = &oauth2Config
+	}
+}
+
+// openIDProviderMetadata holds the fields of a discovery document the relying party uses
+type openIDProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// getOAuth2Config returns the OAuth 2.0 client configuration, discovering the provider if needed
+func (p *SocialProvider) getOAuth2Config(ctx context.Context) (*oauth2.Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth2Config != nil {
+		return p.oauth2Config, nil
+	}
+
+	endpoint := oauth2.Endpoint{AuthURL: gitHubAuthURL, TokenURL: gitHubTokenURL}
+	if p.config.Type == config.SocialProviderOIDC {
+		var metadata openIDProviderMetadata
+		err := p.getJSON(ctx, strings.TrimSuffix(p.config.Issuer, "/")+"/.well-known/openid-configuration", nil, &metadata)
+		if err != nil {
+			return nil, fmt.Errorf("error discovering %s: %v", p.Name, err)
+		}
+
+		endpoint = oauth2.Endpoint{AuthURL: metadata.AuthorizationEndpoint, TokenURL: metadata.TokenEndpoint}
+		p.issuer = metadata.Issuer
+		p.jwksURI = metadata.JWKSURI
+	}
+
+	p.oauth2Config = &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		Endpoint:     endpoint,
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       p.config.Scopes,
+	}
+	return p.oauth2Config, nil
+}
+
+// AuthCodeURL returns the URL to send the user to, with the state, the PKCE
+// challenge of the code verifier and, for OpenID Connect providers, the nonce
+func (p *SocialProvider) AuthCodeURL(ctx context.Context, state string, codeVerifier string, nonce string) (string, error) {
+	oauth2Config, err := p.getOAuth2Config(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	options := []oauth2.AuthCodeOption{oauth2.S256ChallengeOption(codeVerifier)}
+	if p.config.Type == config.SocialProviderOIDC {
+		options = append(options, oauth2.SetAuthURLParam("nonce", nonce))
+	}
+
+	return oauth2Config.AuthCodeURL(state, options...), nil
+}
+
+// Exchange redeems the authorization code and returns the identity of the user who signed in
+func (p *SocialProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (model.ExternalIdentity, error) {
+	oauth2Config, err := p.getOAuth2Config(ctx)
+	if err != nil {
+		return model.ExternalIdentity{}, err
+	}
+
+	// exchange code
+	token, err := oauth2Config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return model.ExternalIdentity{}, fmt.Errorf("error exchanging code with %s: %v", p.Name, err)
+	}
+
+	if p.config.Type == config.SocialProviderGitHub {
+		return p.getGitHubIdentity(ctx, token.AccessToken)
+	}
+
+	// OpenID Connect providers identify the user in the ID token
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return model.ExternalIdentity{}, fmt.Errorf("%s did not return an ID token", p.Name)
+	}
+
+	claims, err := p.verifyIDToken(ctx, rawIDToken, nonce)
+	if err != nil {
+		return model.ExternalIdentity{}, err
+	}
+
+	identity := model.ExternalIdentity{
+		Provider:  p.Name,
+		Email:     stringClaim(claims, "email"),
+		FirstName: stringClaim(claims, "given_name"),
+		LastName:  stringClaim(claims, "family_name"),
+	}
+	identity.Subject, _ = claims.GetSubject()
+
+	// some providers send email_verified as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+
+	if identity.FirstName == "" && identity.LastName == "" {
+		identity.FirstName, identity.LastName = splitName(stringClaim(claims, "name"))
+	}
+
+	return identity, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token
+func (p *SocialProvider) verifyIDToken(ctx context.Context, rawIDToken string, nonce string) (jwt.MapClaims, error) {
+	// parse token
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		return p.getPublicKey(ctx, keyID)
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithAudience(p.config.ClientID), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("error verifying %s ID token: %v", p.Name, err)
+	}
+
+	// multi-tenant issuers such as Microsoft's common endpoint name the tenant of the user
+	expectedIssuer := strings.Replace(p.issuer, "{tenantid}", stringClaim(claims, "tid"), 1)
+	if issuer, _ := claims.GetIssuer(); issuer != expectedIssuer {
+		return nil, fmt.Errorf("%s ID token has unexpected issuer %q", p.Name, issuer)
+	}
+
+	if stringClaim(claims, "nonce") != nonce {
+		return nil, fmt.Errorf("%s ID token nonce does not match", p.Name)
+	}
+
+	return claims, nil
+}
+
+// getPublicKey returns the signing key with the key ID, fetching the provider's
+// key set again when the key is unknown as providers rotate their keys
+func (p *SocialProvider) getPublicKey(ctx context.Context, keyID string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[keyID]; ok {
+		return key, nil
+	}
+
+	var keySet model.JSONWebKeySet
+	if err := p.getJSON(ctx, p.jwksURI, nil, &keySet); err != nil {
+		return nil, fmt.Errorf("error fetching %s signing keys: %v", p.Name, err)
+	}
+
+	p.keys = make(map[string]*rsa.PublicKey)
+	for _, jwk := range keySet.Keys {
+		if jwk.KeyType != "RSA" {
+			continue
+		}
+
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+
+		p.keys[jwk.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	// tokens may leave out the key ID when the provider has a single key
+	if keyID == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, nil
+		}
+	}
+
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown %s signing key %q", p.Name, keyID)
+	}
+	return key, nil
+}
+
+// getGitHubIdentity reads the GitHub user and their primary email address
+func (p *SocialProvider) getGitHubIdentity(ctx context.Context, accessToken string) (model.ExternalIdentity, error) {
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := p.getJSON(ctx, gitHubUserURL, &accessToken, &user); err != nil {
+		return model.ExternalIdentity{}, fmt.Errorf("error fetching GitHub user: %v", err)
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.getJSON(ctx, gitHubEmailsURL, &accessToken, &emails); err != nil {
+		return model.ExternalIdentity{}, fmt.Errorf("error fetching GitHub email addresses: %v", err)
+	}
+
+	identity := model.ExternalIdentity{
+		Provider: p.Name,
+		Subject:  strconv.FormatInt(user.ID, 10),
+	}
+	identity.FirstName, identity.LastName = splitName(user.Name)
+	if identity.FirstName == "" {
+		identity.FirstName = user.Login
+	}
+
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+		}
+	}
+
+	return identity, nil
+}
+
+// getJSON fetches and decodes a JSON document, with the access token as a bearer token if given
+func (p *SocialProvider) getJSON(ctx context.Context, url string, accessToken *string, v interface{}) error {
+	if url == "" {
+		return errors.New("no URL to fetch")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != nil {
+		req.Header.Set("Authorization", "Bearer "+*accessToken)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s from %s", resp.Status, url)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func stringClaim(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// splitName splits a full name into a first name and the rest
+func splitName(name string) (string, string) {
+	firstName, lastName, _ := strings.Cut(strings.TrimSpace(name), " ")
+	return firstName, strings.TrimSpace(lastName)
+}
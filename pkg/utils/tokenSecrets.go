@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"sync/atomic"
+)
+
+// tokenKeySet holds the key every kind of HMAC signed token is signed with
+type tokenKeySet struct {
+	access              []byte
+	refresh             []byte
+	resetPassword       []byte
+	invitation          []byte
+	magicLink           []byte
+	authorizationTicket []byte
+	socialLogin         []byte
+	socialLink          []byte
+}
+
+// currentTokenKeys is replaced as a whole when the secret is reloaded, so
+// requests in flight always see keys derived from a single secret
+var currentTokenKeys atomic.Pointer[tokenKeySet]
+
+func init() {
+	currentTokenKeys.Store(&tokenKeySet{})
+}
+
+// tokenKeys returns the keys tokens are currently signed with
+func tokenKeys() *tokenKeySet {
+	return currentTokenKeys.Load()
+}
+
+// SetTokenSecret derives the keys every kind of HMAC signed token is signed
+// with from the secret, so a token of one kind can never pass for another.
+// It can be called again to rotate the secret, which invalidates the tokens
+// signed with the previous one.
+func SetTokenSecret(secret string) {
+	currentTokenKeys.Store(&tokenKeySet{
+		access:              deriveTokenKey(secret, "access"),
+		refresh:             deriveTokenKey(secret, "refresh"),
+		resetPassword:       deriveTokenKey(secret, "reset-password"),
+		invitation:          deriveTokenKey(secret, "invitation"),
+		magicLink:           deriveTokenKey(secret, "magic-link"),
+		authorizationTicket: deriveTokenKey(secret, "authorization-ticket"),
+		socialLogin:         deriveTokenKey(secret, "social-login"),
+		socialLink:          deriveTokenKey(secret, "social-link"),
+	})
+}
+
+// deriveTokenKey derives the key of a kind of token from the secret
+func deriveTokenKey(secret string, kind string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(kind))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,27 @@
+-- name: CreateImpersonation :one
+INSERT INTO impersonations (id, actor_id, actor_username, target_id, org_id, reason, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: CreateImpersonationEvent :exec
+INSERT INTO impersonation_events (id, impersonation_id, method, path)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetImpersonationByID :one
+SELECT * FROM impersonations
+WHERE id = $1;
+
+-- name: ListUserImpersonations :many
+SELECT * FROM impersonations
+WHERE target_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListUserImpersonationEvents :many
+SELECT impersonation_events.* FROM impersonation_events
+JOIN impersonations ON impersonations.id = impersonation_events.impersonation_id
+WHERE impersonations.target_id = $1
+ORDER BY impersonation_events.created_at;
+
+-- name: CountActiveImpersonations :one
+SELECT COUNT(*) FROM impersonations
+WHERE expires_at > NOW();
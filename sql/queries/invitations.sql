@@ -0,0 +1,28 @@
+-- name: CreateInvitation :one
+INSERT INTO organization_invitations (id, org_id, email, org_role, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetInvitationByID :one
+SELECT * FROM organization_invitations
+WHERE id = $1;
+
+-- name: ListPendingInvitations :many
+SELECT * FROM organization_invitations
+WHERE org_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY created_at;
+
+-- name: AcceptInvitation :execrows
+UPDATE organization_invitations
+SET accepted_at = NOW()
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW();
+
+-- name: RevokeInvitation :execrows
+UPDATE organization_invitations
+SET revoked_at = NOW()
+WHERE id = $1 AND org_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL;
+
+-- name: RevokePendingInvitations :exec
+UPDATE organization_invitations
+SET revoked_at = NOW()
+WHERE org_id = $1 AND email = $2 AND accepted_at IS NULL AND revoked_at IS NULL;
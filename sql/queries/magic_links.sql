@@ -0,0 +1,15 @@
+-- name: CreateMagicLink :one
+INSERT INTO magic_links (id, user_id, browser_binding_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: CountRecentMagicLinks :one
+SELECT COUNT(*) FROM magic_links
+WHERE user_id = $1 AND created_at > $2;
+
+-- name: ConsumeMagicLink :one
+UPDATE magic_links
+SET used_at = NOW()
+WHERE id = $1 AND used_at IS NULL AND expires_at > NOW()
+    AND (browser_binding_hash IS NULL OR browser_binding_hash = $2)
+RETURNING *;
@@ -0,0 +1,40 @@
+-- name: CreateOrganization :one
+INSERT INTO organizations (id, name, slug)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetOrganizationByID :one
+SELECT * FROM organizations
+WHERE id = $1;
+
+-- name: ListOrganizations :many
+SELECT * FROM organizations
+ORDER BY name
+LIMIT $1 OFFSET $2;
+
+-- name: UpsertOrganizationMember :one
+INSERT INTO organization_members (org_id, user_id, org_role)
+VALUES ($1, $2, $3)
+ON CONFLICT (org_id, user_id) DO UPDATE SET
+    org_role = EXCLUDED.org_role
+RETURNING *;
+
+-- name: DeleteOrganizationMember :exec
+DELETE FROM organization_members
+WHERE org_id = $1 AND user_id = $2;
+
+-- name: GetOrganizationMember :one
+SELECT * FROM organization_members
+WHERE org_id = $1 AND user_id = $2;
+
+-- name: GetUserOrganizationMemberships :many
+SELECT * FROM organization_members
+WHERE user_id = $1
+ORDER BY created_at, org_id;
+
+-- name: GetOrganizationUsers :many
+SELECT u.* FROM users u
+JOIN organization_members m ON m.user_id = u.id
+WHERE m.org_id = $1
+ORDER BY u.username
+LIMIT $2 OFFSET $3;
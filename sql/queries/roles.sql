@@ -0,0 +1,107 @@
+-- name: GetRolePermissions :many
+SELECT permission_name FROM role_permissions
+WHERE role_name = $1
+ORDER BY permission_name;
+
+-- name: GetRoleByName :one
+SELECT * FROM roles
+WHERE name = $1;
+
+-- name: LockRole :exec
+-- Locks the role so concurrent changes to its permissions are serialized
+SELECT name FROM roles
+WHERE name = $1
+FOR UPDATE;
+
+-- name: ListRolesWithMemberCount :many
+SELECT r.name, r.description, r.rank, r.built_in, r.created_at, COUNT(ur.user_id) AS member_count
+FROM roles r
+LEFT JOIN user_roles ur ON ur.role_name = r.name
+GROUP BY r.name
+ORDER BY r.rank DESC, r.name;
+
+-- name: CountActiveRoleMembers :one
+SELECT COUNT(*) FROM user_roles ur
+JOIN users u ON u.id = ur.user_id
+WHERE ur.role_name = $1 AND u.account_status = 'active';
+
+-- name: CountRoleMembers :one
+SELECT COUNT(*) FROM user_roles
+WHERE role_name = $1;
+
+-- name: CreateRole :one
+INSERT INTO roles (name, description, rank)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: RenameRole :one
+UPDATE roles SET
+    name = sqlc.arg(new_name),
+    description = sqlc.arg(description)
+WHERE name = sqlc.arg(name)
+RETURNING *;
+
+-- name: DeleteRole :exec
+DELETE FROM roles
+WHERE name = $1;
+
+-- name: ListPermissions :many
+SELECT * FROM permissions
+ORDER BY name;
+
+-- name: SetRolePermissions :exec
+DELETE FROM role_permissions
+WHERE role_name = sqlc.arg(role_name)
+AND permission_name <> ALL(sqlc.arg(permissions)::text[]);
+
+-- name: AddRolePermissions :exec
+INSERT INTO role_permissions (role_name, permission_name)
+SELECT sqlc.arg(role_name)::varchar, unnest(sqlc.arg(permissions)::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: AssignUserRole :exec
+INSERT INTO user_roles (user_id, role_name)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RevokeUserRole :exec
+DELETE FROM user_roles
+WHERE user_id = $1 AND role_name = $2;
+
+-- name: RevokeUserRoleUnlessLast :execrows
+-- Locks the members of the role so concurrent revocations cannot both see
+-- another member left and remove the last one
+DELETE FROM user_roles
+WHERE user_id = $1 AND role_name = $2
+AND (
+    SELECT COUNT(*) FROM (
+        SELECT 1 FROM user_roles members
+        WHERE members.role_name = $2
+        FOR UPDATE
+    ) AS locked_members
+) > 1;
+
+-- name: GetUserRoles :many
+SELECT r.name, r.description, r.created_at, r.rank, r.built_in FROM roles r
+JOIN user_roles ur ON ur.role_name = r.name
+WHERE ur.user_id = $1
+ORDER BY r.rank DESC, r.name;
+
+-- name: UserHasPermission :one
+SELECT EXISTS (
+    SELECT 1 FROM user_roles ur
+    JOIN role_permissions rp ON rp.role_name = ur.role_name
+    WHERE ur.user_id = $1 AND rp.permission_name = $2
+);
+
+-- name: SyncUserPrimaryRole :one
+UPDATE users SET
+    user_role = COALESCE((
+        SELECT ur.role_name FROM user_roles ur
+        JOIN roles r ON r.name = ur.role_name
+        WHERE ur.user_id = users.id
+        ORDER BY r.rank DESC, r.name
+        LIMIT 1
+    ), 'user')
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,41 @@
+-- name: CreateServiceAccount :one
+INSERT INTO service_accounts (id, client_id, name, description, org_id, secret_hash, public_key, permissions, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetServiceAccountByID :one
+SELECT * FROM service_accounts
+WHERE id = $1;
+
+-- name: GetServiceAccountByClientID :one
+SELECT * FROM service_accounts
+WHERE client_id = $1;
+
+-- name: ListServiceAccounts :many
+SELECT * FROM service_accounts
+ORDER BY name;
+
+-- name: ListOrganizationServiceAccounts :many
+SELECT * FROM service_accounts
+WHERE org_id = $1
+ORDER BY name;
+
+-- name: UpdateServiceAccount :one
+UPDATE service_accounts
+SET name = $2, description = $3, public_key = $4, permissions = $5
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateServiceAccountSecret :exec
+UPDATE service_accounts
+SET secret_hash = $2
+WHERE id = $1;
+
+-- name: TouchServiceAccount :exec
+UPDATE service_accounts
+SET last_used_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteServiceAccount :execrows
+DELETE FROM service_accounts
+WHERE id = $1;
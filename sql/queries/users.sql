@@ -44,41 +44,57 @@ WHERE hashed_password = $1;
 -- name: GetActiveUsers :many
 SELECT * FROM users
 WHERE account_status = 'active'
-LIMIT $1 OFFSET $2;
+AND (sqlc.narg(org_id)::uuid IS NULL OR id IN (
+    SELECT user_id FROM organization_members WHERE org_id = sqlc.narg(org_id)::uuid
+))
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: GetInactiveUsers :many
 SELECT * FROM users
 WHERE account_status = 'inactive'
-LIMIT $1 OFFSET $2;
+AND (sqlc.narg(org_id)::uuid IS NULL OR id IN (
+    SELECT user_id FROM organization_members WHERE org_id = sqlc.narg(org_id)::uuid
+))
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: GetSuspendedUsers :many
 SELECT * FROM users
 WHERE account_status = 'suspended'
-LIMIT $1 OFFSET $2;
+AND (sqlc.narg(org_id)::uuid IS NULL OR id IN (
+    SELECT user_id FROM organization_members WHERE org_id = sqlc.narg(org_id)::uuid
+))
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: GetDeletedUsers :many
 SELECT * FROM users
 WHERE account_status = 'deleted'
-LIMIT $1 OFFSET $2;
+AND (sqlc.narg(org_id)::uuid IS NULL OR id IN (
+    SELECT user_id FROM organization_members WHERE org_id = sqlc.narg(org_id)::uuid
+))
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: GetAdminUsers :many
 SELECT * FROM users
 WHERE user_role = 'admin'
-LIMIT $1 OFFSET $2;
+AND (sqlc.narg(org_id)::uuid IS NULL OR id IN (
+    SELECT user_id FROM organization_members WHERE org_id = sqlc.narg(org_id)::uuid
+))
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: GetSuperAdminUsers :many
 SELECT * FROM users
 WHERE user_role = 'superadmin'
-LIMIT $1 OFFSET $2;
-
--- name: GetCustomers :many
-SELECT * FROM users
-WHERE user_role = 'customer'
-LIMIT $1 OFFSET $2;
+AND (sqlc.narg(org_id)::uuid IS NULL OR id IN (
+    SELECT user_id FROM organization_members WHERE org_id = sqlc.narg(org_id)::uuid
+))
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: GetAllUsers :many
 SELECT * FROM users
-LIMIT $1 OFFSET $2;
+WHERE (sqlc.narg(org_id)::uuid IS NULL OR id IN (
+    SELECT user_id FROM organization_members WHERE org_id = sqlc.narg(org_id)::uuid
+))
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: CountAllUsers :one
 SELECT COUNT(*) FROM users;
@@ -107,10 +123,6 @@ WHERE user_role = 'admin';
 SELECT COUNT(*) FROM users
 WHERE user_role = 'superadmin';
 
--- name: CountCustomers :one
-SELECT COUNT(*) FROM users
-WHERE user_role = 'customer';
-
 -- name: CountAllUsersByUsername :one
 SELECT COUNT(*) FROM users
 WHERE username = $1;
@@ -127,6 +139,21 @@ UPDATE users SET
 WHERE id = $1
 RETURNING *;
 
+-- name: SetAccountStatusUnlessLastSuperAdmin :execrows
+-- Locks the active super administrators so concurrent suspensions and
+-- deletions cannot both see another one left and deactivate the last one
+UPDATE users SET
+    account_status = sqlc.arg(account_status)
+WHERE id = sqlc.arg(id)
+AND (
+    SELECT COUNT(*) FROM (
+        SELECT 1 FROM users members
+        JOIN user_roles ur ON ur.user_id = members.id
+        WHERE ur.role_name = 'superadmin' AND members.account_status = 'active'
+        FOR UPDATE OF members
+    ) AS locked_members
+) > 1;
+
 -- name: ActivateUser :one
 UPDATE users SET
     account_status = 'active'
@@ -139,42 +166,6 @@ UPDATE users SET
 WHERE id = $1
 RETURNING *;
 
--- name: PromoteUserToAdmin :one
-UPDATE users SET
-    user_role = 'admin'
-WHERE id = $1
-RETURNING *;
-
--- name: PromoteUserToSuperAdmin :one
-UPDATE users SET
-    user_role = 'superadmin'
-WHERE id = $1
-RETURNING *;
-
--- name: DemoteSuperAdminToAdmin :one
-UPDATE users SET
-    user_role = 'admin'
-WHERE id = $1
-RETURNING *;
-
--- name: DemoteSuperAdminToUser :one
-UPDATE users SET
-    user_role = 'user'
-WHERE id = $1
-RETURNING *;
-
--- name: DemoteAdminToUser :one
-UPDATE users SET
-    user_role = 'user'
-WHERE id = $1
-RETURNING *;
-
--- name: DemoteUserToCustomer :one
-UPDATE users SET
-    user_role = 'customer'
-WHERE id = $1
-RETURNING *;
-
 -- name: UpdateUserPassword :one
 UPDATE users SET
     hashed_password = $2
@@ -213,6 +204,29 @@ RETURNING *;
 SELECT * FROM refresh_tokens
 WHERE user_id = $1;
 
+-- name: GetRefreshToken :one
+SELECT * FROM refresh_tokens
+WHERE token = $1;
+
+-- name: RevokeRefreshToken :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeAllRefreshTokens :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE revoked_at IS NULL;
+
+-- name: CountActiveRefreshTokens :one
+SELECT COUNT(*) FROM refresh_tokens
+WHERE revoked_at IS NULL AND expires_at > NOW();
+
 
 -- name: GetUserByRefreshToken :one
 SELECT * FROM users
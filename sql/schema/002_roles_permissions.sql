@@ -0,0 +1,55 @@
+-- +goose Up
+CREATE TABLE roles (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE permissions (
+    name VARCHAR(100) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+    role_name VARCHAR(50) NOT NULL,
+    permission_name VARCHAR(100) NOT NULL,
+    PRIMARY KEY (role_name, permission_name),
+    FOREIGN KEY (role_name) REFERENCES roles (name) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (permission_name) REFERENCES permissions (name) ON DELETE CASCADE
+);
+
+CREATE INDEX role_permissions_permission_name_idx ON role_permissions (permission_name);
+
+INSERT INTO roles (name, description) VALUES
+    ('user', 'Regular account with no administrative access'),
+    ('admin', 'Manages user accounts'),
+    ('superadmin', 'Manages user accounts and administrator roles');
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:read', 'List user accounts'),
+    ('users:suspend', 'Suspend user accounts'),
+    ('users:recover', 'Recover suspended or deleted user accounts'),
+    ('users:delete', 'Delete user accounts'),
+    ('roles:grant-admin', 'Promote users to administrator'),
+    ('roles:grant-superadmin', 'Promote users to super administrator'),
+    ('roles:revoke-admin', 'Demote administrators'),
+    ('roles:revoke-superadmin', 'Demote super administrators');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('admin', 'users:read'),
+    ('admin', 'users:suspend'),
+    ('admin', 'users:recover'),
+    ('admin', 'users:delete'),
+    ('superadmin', 'users:read'),
+    ('superadmin', 'users:suspend'),
+    ('superadmin', 'users:recover'),
+    ('superadmin', 'users:delete'),
+    ('superadmin', 'roles:grant-admin'),
+    ('superadmin', 'roles:grant-superadmin'),
+    ('superadmin', 'roles:revoke-admin'),
+    ('superadmin', 'roles:revoke-superadmin');
+
+-- +goose Down
+DROP TABLE role_permissions;
+DROP TABLE permissions;
+DROP TABLE roles;
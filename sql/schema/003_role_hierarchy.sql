@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE roles ADD COLUMN rank INTEGER NOT NULL DEFAULT 0;
+
+UPDATE roles SET rank = 0 WHERE name = 'user';
+UPDATE roles SET rank = 50 WHERE name = 'admin';
+UPDATE roles SET rank = 100 WHERE name = 'superadmin';
+
+-- +goose Down
+ALTER TABLE roles DROP COLUMN rank;
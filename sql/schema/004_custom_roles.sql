@@ -0,0 +1,69 @@
+-- +goose Up
+ALTER TABLE roles ADD COLUMN built_in BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE roles SET built_in = TRUE WHERE name IN ('user', 'admin', 'superadmin');
+
+-- users.user_role now holds the highest ranked role of the user and must name an existing role
+UPDATE users SET user_role = 'user' WHERE user_role NOT IN (SELECT name FROM roles);
+ALTER TABLE users DROP CONSTRAINT users_user_role_check;
+ALTER TABLE users ALTER COLUMN user_role TYPE VARCHAR(50);
+ALTER TABLE users ALTER COLUMN user_role SET DEFAULT 'user';
+ALTER TABLE users ADD CONSTRAINT users_user_role_fkey
+    FOREIGN KEY (user_role) REFERENCES roles (name) ON UPDATE CASCADE;
+
+CREATE TABLE user_roles (
+    user_id UUID NOT NULL,
+    role_name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role_name),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (role_name) REFERENCES roles (name) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX user_roles_role_name_idx ON user_roles (role_name);
+
+INSERT INTO user_roles (user_id, role_name)
+SELECT id, user_role FROM users;
+
+-- the generic role assignment API replaces the grant/revoke permissions
+DELETE FROM permissions WHERE name IN (
+    'roles:grant-admin', 'roles:grant-superadmin', 'roles:revoke-admin', 'roles:revoke-superadmin'
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('roles:read', 'List roles, permissions and role memberships'),
+    ('roles:assign', 'Assign and revoke roles ranked below your own'),
+    ('roles:manage', 'Create, rename and delete custom roles');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('admin', 'roles:read'),
+    ('admin', 'roles:assign'),
+    ('superadmin', 'roles:read'),
+    ('superadmin', 'roles:assign'),
+    ('superadmin', 'roles:manage');
+
+-- +goose Down
+DELETE FROM permissions WHERE name IN ('roles:read', 'roles:assign', 'roles:manage');
+
+INSERT INTO permissions (name, description) VALUES
+    ('roles:grant-admin', 'Promote users to administrator'),
+    ('roles:grant-superadmin', 'Promote users to super administrator'),
+    ('roles:revoke-admin', 'Demote administrators'),
+    ('roles:revoke-superadmin', 'Demote super administrators');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('superadmin', 'roles:grant-admin'),
+    ('superadmin', 'roles:grant-superadmin'),
+    ('superadmin', 'roles:revoke-admin'),
+    ('superadmin', 'roles:revoke-superadmin');
+
+DROP TABLE user_roles;
+
+UPDATE users SET user_role = 'user' WHERE user_role NOT IN ('user', 'admin', 'superadmin');
+ALTER TABLE users DROP CONSTRAINT users_user_role_fkey;
+ALTER TABLE users ALTER COLUMN user_role SET DEFAULT 'customer';
+ALTER TABLE users ALTER COLUMN user_role TYPE VARCHAR(10);
+ALTER TABLE users ADD CONSTRAINT users_user_role_check
+    CHECK (user_role IN ('user', 'admin', 'superadmin'));
+
+DELETE FROM roles WHERE built_in = FALSE;
+ALTER TABLE roles DROP COLUMN built_in;
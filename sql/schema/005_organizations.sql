@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (slug)
+);
+
+CREATE TABLE organization_members (
+    org_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    org_role VARCHAR(20) NOT NULL DEFAULT 'member',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (org_id, user_id),
+    FOREIGN KEY (org_id) REFERENCES organizations (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CHECK (org_role IN ('admin', 'member'))
+);
+
+CREATE INDEX organization_members_user_id_idx ON organization_members (user_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('orgs:manage', 'Create organizations and manage their memberships');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('superadmin', 'orgs:manage');
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'orgs:manage';
+DROP TABLE organization_members;
+DROP TABLE organizations;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE organization_invitations (
+    id UUID PRIMARY KEY,
+    org_id UUID NOT NULL,
+    email VARCHAR(50) NOT NULL,
+    org_role VARCHAR(20) NOT NULL DEFAULT 'member',
+    invited_by UUID NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (org_id) REFERENCES organizations (id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users (id) ON DELETE SET NULL,
+    CHECK (org_role IN ('admin', 'member'))
+);
+
+-- an address has at most one open invitation per organization
+CREATE UNIQUE INDEX organization_invitations_pending_idx ON organization_invitations (org_id, email)
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
+
+-- +goose Down
+DROP TABLE organization_invitations;
@@ -0,0 +1,57 @@
+-- +goose Up
+CREATE TABLE oauth_clients (
+    id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    secret_hash VARCHAR(60) NULL,
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+    grant_types TEXT[] NOT NULL DEFAULT '{}',
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE oauth_authorization_codes (
+    code_hash VARCHAR(64) PRIMARY KEY,
+    client_id VARCHAR(50) NOT NULL,
+    user_id UUID NOT NULL,
+    redirect_uri TEXT NOT NULL,
+    scope TEXT NOT NULL DEFAULT '',
+    code_challenge VARCHAR(128) NOT NULL,
+    code_challenge_method VARCHAR(10) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (client_id) REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX oauth_authorization_codes_expires_at_idx ON oauth_authorization_codes (expires_at);
+
+CREATE TABLE oauth_consents (
+    user_id UUID NOT NULL,
+    client_id VARCHAR(50) NOT NULL,
+    scope TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, client_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (client_id) REFERENCES oauth_clients (id) ON DELETE CASCADE
+);
+
+-- refresh tokens issued to OAuth clients remember the client and the granted scope
+ALTER TABLE refresh_tokens
+    ADD COLUMN client_id VARCHAR(50) NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    ADD COLUMN scope TEXT NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('oauth_clients:manage', 'Register and remove OAuth clients');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('superadmin', 'oauth_clients:manage');
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'oauth_clients:manage';
+ALTER TABLE refresh_tokens
+    DROP COLUMN scope,
+    DROP COLUMN client_id;
+DROP TABLE oauth_consents;
+DROP TABLE oauth_authorization_codes;
+DROP TABLE oauth_clients;
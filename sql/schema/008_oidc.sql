@@ -0,0 +1,14 @@
+-- +goose Up
+-- the nonce of an OpenID Connect authentication request is echoed in the ID token
+ALTER TABLE oauth_authorization_codes
+    ADD COLUMN nonce TEXT NOT NULL DEFAULT '';
+
+-- where relying parties may send users after RP-initiated logout
+ALTER TABLE oauth_clients
+    ADD COLUMN post_logout_redirect_uris TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE oauth_clients
+    DROP COLUMN post_logout_redirect_uris;
+ALTER TABLE oauth_authorization_codes
+    DROP COLUMN nonce;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- one-time login links; a link bound to a browser can only be redeemed with its binding cookie
+CREATE TABLE magic_links (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    browser_binding_hash VARCHAR(64) NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX magic_links_user_id_created_at_idx ON magic_links (user_id, created_at);
+
+-- +goose Down
+DROP TABLE magic_links;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- non-human principals of an organization, or of the platform when org_id is NULL. They authenticate
+-- at the token endpoint with a client secret (bcrypt hash) or assertions signed by a registered public key.
+CREATE TABLE service_accounts (
+    id UUID PRIMARY KEY,
+    client_id VARCHAR(50) NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    org_id UUID NULL,
+    secret_hash VARCHAR(60) NULL,
+    public_key TEXT NULL,
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    created_by UUID NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP WITH TIME ZONE NULL,
+    UNIQUE (client_id),
+    FOREIGN KEY (org_id) REFERENCES organizations (id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX service_accounts_org_id_idx ON service_accounts (org_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('service_accounts:manage', 'Create service accounts and manage their credentials and permissions');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('admin', 'service_accounts:manage'),
+    ('superadmin', 'service_accounts:manage');
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'service_accounts:manage';
+DROP TABLE service_accounts;
@@ -0,0 +1,40 @@
+-- +goose Up
+-- admins acting as a user through a short-lived access token. The actor's username is kept
+-- so the audit trail survives the actor's account; every request made with the token is an event.
+CREATE TABLE impersonations (
+    id UUID PRIMARY KEY,
+    actor_id UUID NULL,
+    actor_username VARCHAR(50) NOT NULL,
+    target_id UUID NOT NULL,
+    org_id UUID NULL,
+    reason VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (actor_id) REFERENCES users (id) ON DELETE SET NULL,
+    FOREIGN KEY (target_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX impersonations_target_id_idx ON impersonations (target_id);
+
+CREATE TABLE impersonation_events (
+    id UUID PRIMARY KEY,
+    impersonation_id UUID NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (impersonation_id) REFERENCES impersonations (id) ON DELETE CASCADE
+);
+
+CREATE INDEX impersonation_events_impersonation_id_idx ON impersonation_events (impersonation_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:impersonate', 'Act as a user through a short-lived access token');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('admin', 'users:impersonate'),
+    ('superadmin', 'users:impersonate');
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'users:impersonate';
+DROP TABLE impersonation_events;
+DROP TABLE impersonations;
@@ -0,0 +1,10 @@
+-- +goose Up
+INSERT INTO permissions (name, description) VALUES
+    ('users:create', 'Create users and choose their roles');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('admin', 'users:create'),
+    ('superadmin', 'users:create');
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'users:create';
@@ -0,0 +1,10 @@
+// Package schema embeds the database migrations, so the server binary can
+// apply them itself. The files use goose annotations and are also read by sqlc.
+package schema
+
+import "embed"
+
+// FS holds the migration files
+//
+//go:embed *.sql
+var FS embed.FS